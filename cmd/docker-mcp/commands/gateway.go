@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/catalog"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/gateway"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/mounts"
 )
 
 func gatewayCommand(docker docker.Client, dockerCli command.Cli) *cobra.Command {
@@ -19,45 +21,38 @@ func gatewayCommand(docker docker.Client, dockerCli command.Cli) *cobra.Command
 		Short: "Manage the MCP Server gateway",
 	}
 
-	// Have different defaults for the on-host gateway and the in-container gateway.
-	var options gateway.Config
+	options := defaultGatewayOptions()
 	var additionalCatalogs []string
 	var additionalRegistries []string
 	var additionalConfigs []string
 	var additionalToolsConfig []string
 	var useConfiguredCatalogs bool
-	if os.Getenv("DOCKER_MCP_IN_CONTAINER") == "1" {
-		// In-container.
-		options = gateway.Config{
-			CatalogPath: []string{catalog.DockerCatalogURL},
-			SecretsPath: "docker-desktop:/run/secrets/mcp_secret:/.env",
-			Options: gateway.Options{
-				Cpus:             1,
-				Memory:           "2Gb",
-				Transport:        "stdio",
-				LogCalls:         true,
-				BlockSecrets:     true,
-				VerifySignatures: true,
-				Verbose:          true,
-			},
-		}
-	} else {
-		// On-host.
-		options = gateway.Config{
-			CatalogPath:  []string{catalog.DockerCatalogFilename},
-			RegistryPath: []string{"registry.yaml"},
-			ConfigPath:   []string{"config.yaml"},
-			ToolsPath:    []string{"tools.yaml"},
-			SecretsPath:  "docker-desktop",
-			Options: gateway.Options{
-				Cpus:         1,
-				Memory:       "2Gb",
-				Transport:    "stdio",
-				LogCalls:     true,
-				BlockSecrets: true,
-				Watch:        true,
-			},
+
+	// resolvePaths applies the catalogs, registries and configs from the flags
+	// on top of the defaults.
+	resolvePaths := func() {
+		// Build catalog path list with proper precedence order
+		catalogPaths := options.CatalogPath // Start with existing catalog paths (includes docker-mcp.yaml default)
+
+		// Add configured catalogs if requested
+		if useConfiguredCatalogs {
+			configuredPaths := getConfiguredCatalogPaths()
+			// Insert configured catalogs after docker-mcp.yaml but before CLI-specified catalogs
+			if len(catalogPaths) > 0 {
+				// Insert after the first element (docker-mcp.yaml)
+				catalogPaths = append(catalogPaths[:1], append(configuredPaths, catalogPaths[1:]...)...)
+			} else {
+				catalogPaths = append(catalogPaths, configuredPaths...)
+			}
 		}
+
+		// Append additional catalogs (CLI-specified have highest precedence)
+		catalogPaths = append(catalogPaths, additionalCatalogs...)
+		options.CatalogPath = catalogPaths
+
+		options.RegistryPath = append(options.RegistryPath, additionalRegistries...)
+		options.ConfigPath = append(options.ConfigPath, additionalConfigs...)
+		options.ToolsPath = append(options.ToolsPath, additionalToolsConfig...)
 	}
 
 	runCmd := &cobra.Command{
@@ -89,29 +84,11 @@ func gatewayCommand(docker docker.Client, dockerCli command.Cli) *cobra.Command
 				options.Port = 8811
 			}
 
-			// Build catalog path list with proper precedence order
-			catalogPaths := options.CatalogPath // Start with existing catalog paths (includes docker-mcp.yaml default)
-
-			// Add configured catalogs if requested
-			if useConfiguredCatalogs {
-				configuredPaths := getConfiguredCatalogPaths()
-				// Insert configured catalogs after docker-mcp.yaml but before CLI-specified catalogs
-				if len(catalogPaths) > 0 {
-					// Insert after the first element (docker-mcp.yaml)
-					catalogPaths = append(catalogPaths[:1], append(configuredPaths, catalogPaths[1:]...)...)
-				} else {
-					catalogPaths = append(catalogPaths, configuredPaths...)
-				}
+			resolvePaths()
+			if err := applyLocalSecretPolicy(cmd.Context(), dockerCli, &options); err != nil {
+				return err
 			}
 
-			// Append additional catalogs (CLI-specified have highest precedence)
-			catalogPaths = append(catalogPaths, additionalCatalogs...)
-			options.CatalogPath = catalogPaths
-
-			options.RegistryPath = append(options.RegistryPath, additionalRegistries...)
-			options.ConfigPath = append(options.ConfigPath, additionalConfigs...)
-			options.ToolsPath = append(options.ToolsPath, additionalToolsConfig...)
-
 			return gateway.NewGateway(options, docker).Run(cmd.Context())
 		},
 	}
@@ -125,23 +102,41 @@ func gatewayCommand(docker docker.Client, dockerCli command.Cli) *cobra.Command
 	runCmd.Flags().StringSliceVar(&additionalConfigs, "additional-config", nil, "Additional config paths to merge with the default config.yaml")
 	runCmd.Flags().StringSliceVar(&options.ToolsPath, "tools-config", options.ToolsPath, "Paths to the tools files (absolute or relative to ~/.docker/mcp/)")
 	runCmd.Flags().StringSliceVar(&additionalToolsConfig, "additional-tools-config", nil, "Additional tools paths to merge with the default tools.yaml")
-	runCmd.Flags().StringVar(&options.SecretsPath, "secrets", options.SecretsPath, "Colon separated paths to search for secrets. Can be `docker-desktop` or a path to a .env file (default to using Docker Desktop's secrets API)")
+	runCmd.Flags().StringVar(&options.SecretsPath, "secrets", options.SecretsPath, "Comma separated chain of secret providers: `docker-desktop`, credstore, exec:<program>, vault:<mount>/<path>, env:<PREFIX>, or the path of a .env file or of a directory with a file per secret (default to using Docker Desktop's secrets API)")
 	runCmd.Flags().StringSliceVar(&options.ToolNames, "tools", options.ToolNames, "List of tools to enable")
 	runCmd.Flags().StringArrayVar(&options.Interceptors, "interceptor", options.Interceptors, "List of interceptors to use (format: when:type:path, e.g. 'before:exec:/bin/path')")
 	runCmd.Flags().IntVar(&options.Port, "port", options.Port, "TCP port to listen on (default is to listen on stdio)")
 	runCmd.Flags().StringVar(&options.Transport, "transport", options.Transport, "stdio, sse or streaming (default is stdio)")
 	runCmd.Flags().BoolVar(&options.LogCalls, "log-calls", options.LogCalls, "Log calls to the tools")
 	runCmd.Flags().BoolVar(&options.BlockSecrets, "block-secrets", options.BlockSecrets, "Block secrets from being/received sent to/from tools")
-	runCmd.Flags().BoolVar(&options.BlockNetwork, "block-network", options.BlockNetwork, "Block tools from accessing forbidden network resources")
+	runCmd.Flags().StringVar(&options.ToolScan, "scan-tools", options.ToolScan, "What to do with tools whose metadata looks poisoned: off, warn, strip or refuse")
+	runCmd.Flags().BoolVar(&options.ScanToolResults, "scan-tool-results", options.ScanToolResults, "Also scan tool results for prompt injection, applying the --scan-tools action")
+	runCmd.Flags().StringVar(&options.PinTools, "pin-tools", options.PinTools, "What to do when tool definitions change after they were approved: off, warn, approve (hold back changed tools) or block (hold back the whole server)")
+	runCmd.Flags().StringVar(&options.DataFlowPolicy, "data-flow-policy", options.DataFlowPolicy, "What to do when a session that received private data calls a tool with public egress: off, warn, approve (ask the user) or block")
+	runCmd.Flags().StringVar(&options.DataFlowLabels, "data-flow-labels", options.DataFlowLabels, "Path to a file overriding the data flow labels of servers and tools (absolute or relative to ~/.docker/mcp/)")
+	runCmd.Flags().StringVar(&options.AuditLog, "audit-log", options.AuditLog, "Path to a file where security decisions are appended as JSON lines (absolute or relative to ~/.docker/mcp/)")
+	runCmd.Flags().BoolVar(&options.ReadOnly, "read-only", options.ReadOnly, "Only expose read-only tools and mount every volume read-only")
+	runCmd.Flags().StringSliceVar(&options.ReadOnlyTools, "read-only-tools", options.ReadOnlyTools, "Tools to consider read-only even if they are not annotated as such (tool, server:tool or server:*)")
+	runCmd.Flags().BoolVar(&options.ReadOnlyTool, "read-only-tool", options.ReadOnlyTool, "Expose a tool that lets a client switch its session to read-only mode")
+	runCmd.Flags().StringSliceVar(&options.MountAllow, "mount-allow", options.MountAllow, "Host path prefixes that servers can mount (default: any path that's not denied)")
+	runCmd.Flags().StringSliceVar(&options.MountDeny, "mount-deny", options.MountDeny, "Host paths that servers can't mount, nor any of their parents")
+	runCmd.Flags().StringSliceVar(&options.MountReadOnly, "mount-read-only", options.MountReadOnly, "Host path prefixes that are always mounted read-only")
+	runCmd.Flags().BoolVar(&options.AllowSocketMounts, "allow-socket-mounts", options.AllowSocketMounts, "Allow servers to mount unix sockets and named pipes, such as the Docker socket")
+	runCmd.Flags().StringVar(&options.Hardening, "hardening", options.Hardening, "Hardening profile of the MCP server containers: default or strict (read-only root filesystem, no capabilities, limited processes). Servers can opt out with their own profile")
+	runCmd.Flags().BoolVar(&options.BlockNetwork, "block-network", options.BlockNetwork, "Block tools from accessing forbidden network resources. Servers without allowHosts get no network at all")
+	runCmd.Flags().StringSliceVar(&options.UnrestrictedNetwork, "unrestricted-network", options.UnrestrictedNetwork, "Trusted servers that keep an unrestricted network access with --block-network. POCI tools are matched by their server name or by server:tool")
 	runCmd.Flags().BoolVar(&options.VerifySignatures, "verify-signatures", options.VerifySignatures, "Verify signatures of the server images")
 	runCmd.Flags().BoolVar(&options.DryRun, "dry-run", options.DryRun, "Start the gateway but do not listen for connections (useful for testing the configuration)")
 	runCmd.Flags().BoolVar(&options.Verbose, "verbose", options.Verbose, "Verbose output")
 	runCmd.Flags().BoolVar(&options.LongLived, "long-lived", options.LongLived, "Containers are long-lived and will not be removed until the gateway is stopped, useful for stateful servers")
 	runCmd.Flags().BoolVar(&options.DebugDNS, "debug-dns", options.DebugDNS, "Debug DNS resolution")
 	runCmd.Flags().BoolVar(&options.Watch, "watch", options.Watch, "Watch for changes and reconfigure the gateway")
+	runCmd.Flags().DurationVar(&options.SecretsRefresh, "secrets-refresh", options.SecretsRefresh, "With --watch, how often to read the secrets again, to notice the rotations of providers that aren't files, such as vault or exec (0 to never)")
 	runCmd.Flags().IntVar(&options.Cpus, "cpus", options.Cpus, "CPUs allocated to each MCP Server (default is 1)")
 	runCmd.Flags().StringVar(&options.Memory, "memory", options.Memory, "Memory allocated to each MCP Server (default is 2Gb)")
-	runCmd.Flags().BoolVar(&options.Static, "static", options.Static, "Enable static mode (aka pre-started servers)")
+	runCmd.Flags().StringVar(&options.Runtime, "runtime", options.Runtime, "How to run the MCP server containers: docker (Docker Engine API), docker-cli (the docker CLI binary) or podman. Servers can pick their own")
+	runCmd.Flags().StringVar(&options.MissingValues, "missing-values", options.MissingValues, "What to do with servers that are missing secrets or config values: refuse (don't start them), unhealthy (don't start them and report the gateway as unhealthy) or elicit (ask the client at first use)")
+	runCmd.Flags().BoolVar(&options.Static, "static", options.Static, "Enable static mode (aka pre-started servers, found from their docker-mcp-name label)")
 
 	// Configured catalogs feature
 	runCmd.Flags().BoolVar(&useConfiguredCatalogs, "use-configured-catalogs", false, "Include user-managed catalogs (requires 'configured-catalogs' feature to be enabled)")
@@ -152,6 +147,111 @@ func gatewayCommand(docker docker.Client, dockerCli command.Cli) *cobra.Command
 
 	cmd.AddCommand(runCmd)
 
+	composeOptions := gateway.ComposeOptions{
+		GatewayImage: "docker/mcp-gateway",
+	}
+	var composeOutput string
+	composeCmd := &cobra.Command{
+		Use:   "compose",
+		Short: "Generate a compose file that runs the gateway in static mode, with every enabled server pre-started",
+		Args:  cobra.NoArgs,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return validateConfiguredCatalogsFeatureForCli(dockerCli, useConfiguredCatalogs)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			resolvePaths()
+			// Secrets are declared, their values are never read.
+			options.SecretsPath = ""
+			options.Watch = false
+
+			var out bytes.Buffer
+			if err := gateway.NewGateway(options, docker).Compose(cmd.Context(), &out, composeOptions); err != nil {
+				return err
+			}
+			if composeOutput == "" || composeOutput == "-" {
+				_, err := cmd.OutOrStdout().Write(out.Bytes())
+				return err
+			}
+			return os.WriteFile(composeOutput, out.Bytes(), 0o644)
+		},
+	}
+	composeCmd.Flags().StringVarP(&composeOutput, "output", "o", "", "Path of the compose file to write (default is stdout)")
+	composeCmd.Flags().StringVar(&composeOptions.GatewayImage, "gateway-image", composeOptions.GatewayImage, "Image of the gateway, that also provides docker-mcp-bridge to the servers")
+	composeCmd.Flags().IntVar(&options.Port, "port", 8811, "Port the gateway listens on")
+	composeCmd.Flags().StringSliceVar(&options.ServerNames, "servers", nil, "Names of the servers to enable (if non empty, ignore --registry flag)")
+	composeCmd.Flags().StringSliceVar(&options.CatalogPath, "catalog", options.CatalogPath, "Paths to docker catalogs (absolute or relative to ~/.docker/mcp/catalogs/)")
+	composeCmd.Flags().StringSliceVar(&additionalCatalogs, "additional-catalog", nil, "Additional catalog paths to append to the default catalogs")
+	composeCmd.Flags().StringSliceVar(&options.RegistryPath, "registry", options.RegistryPath, "Paths to the registry files (absolute or relative to ~/.docker/mcp/)")
+	composeCmd.Flags().StringSliceVar(&additionalRegistries, "additional-registry", nil, "Additional registry paths to merge with the default registry.yaml")
+	composeCmd.Flags().StringSliceVar(&options.ConfigPath, "config", options.ConfigPath, "Paths to the config files (absolute or relative to ~/.docker/mcp/)")
+	composeCmd.Flags().StringSliceVar(&additionalConfigs, "additional-config", nil, "Additional config paths to merge with the default config.yaml")
+	composeCmd.Flags().BoolVar(&options.ReadOnly, "read-only", options.ReadOnly, "Only expose read-only tools and mount every volume read-only")
+	composeCmd.Flags().StringSliceVar(&options.MountAllow, "mount-allow", options.MountAllow, "Host path prefixes that servers can mount (default: any path that's not denied)")
+	composeCmd.Flags().StringSliceVar(&options.MountDeny, "mount-deny", options.MountDeny, "Host paths that servers can't mount, nor any of their parents")
+	composeCmd.Flags().StringSliceVar(&options.MountReadOnly, "mount-read-only", options.MountReadOnly, "Host path prefixes that are always mounted read-only")
+	composeCmd.Flags().BoolVar(&options.AllowSocketMounts, "allow-socket-mounts", options.AllowSocketMounts, "Allow servers to mount unix sockets and named pipes, such as the Docker socket")
+	composeCmd.Flags().StringVar(&options.Hardening, "hardening", options.Hardening, "Hardening profile of the MCP server containers: default or strict. Servers can opt out with their own profile")
+	composeCmd.Flags().BoolVar(&options.BlockNetwork, "block-network", options.BlockNetwork, "Servers can only reach their allowHosts, through a proxy service. Servers without allowHosts get no network at all")
+	composeCmd.Flags().StringSliceVar(&options.UnrestrictedNetwork, "unrestricted-network", options.UnrestrictedNetwork, "Trusted servers that keep an unrestricted network access with --block-network. POCI tools are matched by their server name or by server:tool")
+	composeCmd.Flags().IntVar(&options.Cpus, "cpus", options.Cpus, "CPUs allocated to each MCP Server (default is 1)")
+	composeCmd.Flags().StringVar(&options.Memory, "memory", options.Memory, "Memory allocated to each MCP Server (default is 2Gb)")
+	composeCmd.Flags().BoolVar(&useConfiguredCatalogs, "use-configured-catalogs", false, "Include user-managed catalogs (requires 'configured-catalogs' feature to be enabled)")
+	cmd.AddCommand(composeCmd)
+
+	kubernetesOptions := gateway.KubernetesOptions{
+		Mode:       gateway.KubernetesStatic,
+		SecretName: "mcp-secrets",
+	}
+	var kubernetesOutput string
+	kubernetesCmd := &cobra.Command{
+		Use:   "kubernetes",
+		Short: "Generate the Kubernetes manifests that run the gateway and its enabled servers",
+		Args:  cobra.NoArgs,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return validateConfiguredCatalogsFeatureForCli(dockerCli, useConfiguredCatalogs)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			resolvePaths()
+			// Secrets are referenced by name, their values are never read.
+			options.SecretsPath = ""
+			options.Watch = false
+
+			var out bytes.Buffer
+			if err := gateway.NewGateway(options, docker).Kubernetes(cmd.Context(), &out, kubernetesOptions); err != nil {
+				return err
+			}
+			if kubernetesOutput == "" || kubernetesOutput == "-" {
+				_, err := cmd.OutOrStdout().Write(out.Bytes())
+				return err
+			}
+			return os.WriteFile(kubernetesOutput, out.Bytes(), 0o644)
+		},
+	}
+	kubernetesCmd.Flags().StringVarP(&kubernetesOutput, "output", "o", "", "Path of the manifests file to write (default is stdout)")
+	kubernetesCmd.Flags().StringVar(&kubernetesOptions.Mode, "mode", kubernetesOptions.Mode, "How servers run: static, as a Deployment per server, or per-call, in the gateway's own Docker Engine")
+	kubernetesCmd.Flags().StringVar(&kubernetesOptions.GatewayImage, "gateway-image", "", "Image of the gateway, that also provides docker-mcp-bridge to the servers (default is docker/mcp-gateway, or docker/mcp-gateway:dind in per-call mode)")
+	kubernetesCmd.Flags().StringVar(&kubernetesOptions.SecretName, "secret-name", kubernetesOptions.SecretName, "Name of the Kubernetes Secret that holds a key per secret")
+	kubernetesCmd.Flags().IntVar(&options.Port, "port", 8811, "Port the gateway listens on")
+	kubernetesCmd.Flags().StringSliceVar(&options.ServerNames, "servers", nil, "Names of the servers to enable (if non empty, ignore --registry flag)")
+	kubernetesCmd.Flags().StringSliceVar(&options.CatalogPath, "catalog", options.CatalogPath, "Paths to docker catalogs (absolute or relative to ~/.docker/mcp/catalogs/)")
+	kubernetesCmd.Flags().StringSliceVar(&additionalCatalogs, "additional-catalog", nil, "Additional catalog paths to append to the default catalogs")
+	kubernetesCmd.Flags().StringSliceVar(&options.RegistryPath, "registry", options.RegistryPath, "Paths to the registry files (absolute or relative to ~/.docker/mcp/)")
+	kubernetesCmd.Flags().StringSliceVar(&additionalRegistries, "additional-registry", nil, "Additional registry paths to merge with the default registry.yaml")
+	kubernetesCmd.Flags().StringSliceVar(&options.ConfigPath, "config", options.ConfigPath, "Paths to the config files (absolute or relative to ~/.docker/mcp/)")
+	kubernetesCmd.Flags().StringSliceVar(&additionalConfigs, "additional-config", nil, "Additional config paths to merge with the default config.yaml")
+	kubernetesCmd.Flags().BoolVar(&options.ReadOnly, "read-only", options.ReadOnly, "Only expose read-only tools and mount every volume read-only")
+	kubernetesCmd.Flags().StringSliceVar(&options.MountAllow, "mount-allow", options.MountAllow, "Host path prefixes that servers can mount (default: any path that's not denied)")
+	kubernetesCmd.Flags().StringSliceVar(&options.MountDeny, "mount-deny", options.MountDeny, "Host paths that servers can't mount, nor any of their parents")
+	kubernetesCmd.Flags().StringSliceVar(&options.MountReadOnly, "mount-read-only", options.MountReadOnly, "Host path prefixes that are always mounted read-only")
+	kubernetesCmd.Flags().BoolVar(&options.AllowSocketMounts, "allow-socket-mounts", options.AllowSocketMounts, "Allow servers to mount unix sockets and named pipes, such as the Docker socket")
+	kubernetesCmd.Flags().StringVar(&options.Hardening, "hardening", options.Hardening, "Hardening profile of the MCP server containers: default or strict. Servers can opt out with their own profile")
+	kubernetesCmd.Flags().BoolVar(&options.BlockNetwork, "block-network", options.BlockNetwork, "Servers can only reach their allowHosts, enforced by NetworkPolicies. Servers without allowHosts get no egress at all")
+	kubernetesCmd.Flags().StringSliceVar(&options.UnrestrictedNetwork, "unrestricted-network", options.UnrestrictedNetwork, "Trusted servers that keep an unrestricted network access with --block-network. POCI tools are matched by their server name or by server:tool")
+	kubernetesCmd.Flags().IntVar(&options.Cpus, "cpus", options.Cpus, "CPUs allocated to each MCP Server (default is 1)")
+	kubernetesCmd.Flags().StringVar(&options.Memory, "memory", options.Memory, "Memory allocated to each MCP Server (default is 2Gb)")
+	kubernetesCmd.Flags().BoolVar(&useConfiguredCatalogs, "use-configured-catalogs", false, "Include user-managed catalogs (requires 'configured-catalogs' feature to be enabled)")
+	cmd.AddCommand(kubernetesCmd)
+
 	return cmd
 }
 
@@ -226,3 +326,57 @@ func isOAuthInterceptorFeatureEnabled(dockerCli command.Cli) bool {
 
 	return value == "enabled"
 }
+
+// defaultGatewayOptions are different for the on-host gateway and the
+// in-container gateway.
+func defaultGatewayOptions() gateway.Config {
+	if os.Getenv("DOCKER_MCP_IN_CONTAINER") == "1" {
+		// In-container.
+		return gateway.Config{
+			CatalogPath: []string{catalog.DockerCatalogURL},
+			SecretsPath: "docker-desktop:/run/secrets/mcp_secret:/.env",
+			Options: gateway.Options{
+				Cpus:             1,
+				Memory:           "2Gb",
+				Transport:        "stdio",
+				LogCalls:         true,
+				BlockSecrets:     true,
+				VerifySignatures: true,
+				Verbose:          true,
+				ToolScan:         "warn",
+				PinTools:         "warn",
+				DataFlowPolicy:   "off",
+				DataFlowLabels:   "dataflow.yaml",
+				MountDeny:        mounts.DefaultDenied,
+				Hardening:        "strict",
+				Runtime:          "docker",
+				MissingValues:    "refuse",
+			},
+		}
+	}
+
+	// On-host.
+	return gateway.Config{
+		CatalogPath:  []string{catalog.DockerCatalogFilename},
+		RegistryPath: []string{"registry.yaml"},
+		ConfigPath:   []string{"config.yaml"},
+		ToolsPath:    []string{"tools.yaml"},
+		SecretsPath:  "docker-desktop",
+		Options: gateway.Options{
+			Cpus:           1,
+			Memory:         "2Gb",
+			Transport:      "stdio",
+			LogCalls:       true,
+			BlockSecrets:   true,
+			Watch:          true,
+			ToolScan:       "warn",
+			PinTools:       "warn",
+			DataFlowPolicy: "off",
+			DataFlowLabels: "dataflow.yaml",
+			MountDeny:      mounts.DefaultDenied,
+			Hardening:      "strict",
+			Runtime:        "docker",
+			MissingValues:  "refuse",
+		},
+	}
+}
@@ -1,11 +1,15 @@
 package commands
 
 import (
+	"context"
 	"os"
 	"strings"
 
+	"github.com/docker/cli/cli/command"
 	"github.com/spf13/cobra"
 
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/gateway"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/tui"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/secret-management/policy"
 )
@@ -21,7 +25,7 @@ docker mcp policy set "my-secret allows postgres"
 cat policy.conf | docker mcp policy set
 `
 
-func policyCommand() *cobra.Command {
+func policyCommand(dockerCli command.Cli) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "policy",
 		Aliases: []string{"policies"},
@@ -30,7 +34,7 @@ func policyCommand() *cobra.Command {
 
 	cmd.AddCommand(&cobra.Command{
 		Use:   "set <content>",
-		Short: "Set a policy for secret management in Docker Desktop",
+		Short: "Set a policy for secret management in Docker Desktop, or in the gateway on Docker CE",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
@@ -40,6 +44,14 @@ func policyCommand() *cobra.Command {
 				}
 				args = append(args, string(bytes))
 			}
+
+			local, err := localSecretPolicy(cmd.Context(), dockerCli)
+			if err != nil {
+				return err
+			}
+			if local {
+				return policy.SetLocal(args[0])
+			}
 			return policy.Set(cmd.Context(), args[0])
 		},
 		Example: strings.Trim(setPolicyExample, "\n"),
@@ -50,9 +62,38 @@ func policyCommand() *cobra.Command {
 		Short: "Dump the policy content",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			local, err := localSecretPolicy(cmd.Context(), dockerCli)
+			if err != nil {
+				return err
+			}
+			if local {
+				return policy.DumpLocal()
+			}
 			return policy.Dump(cmd.Context())
 		},
 	})
 
 	return cmd
 }
+
+// localSecretPolicy tells whether the secret policy is stored and enforced by
+// the gateway, because there's no Docker Desktop to do it.
+func localSecretPolicy(ctx context.Context, dockerCli command.Cli) (bool, error) {
+	if os.Getenv("DOCKER_MCP_IN_CONTAINER") == "1" {
+		return false, nil
+	}
+	return docker.RunningInDockerCE(ctx, dockerCli)
+}
+
+// applyLocalSecretPolicy makes the gateway enforce the secret policy on
+// Docker CE.
+func applyLocalSecretPolicy(ctx context.Context, dockerCli command.Cli, options *gateway.Config) error {
+	local, err := localSecretPolicy(ctx, dockerCli)
+	if err != nil {
+		return err
+	}
+	if local {
+		options.PolicyPath = "policy.conf"
+	}
+	return nil
+}
@@ -76,9 +76,9 @@ func Root(ctx context.Context, cwd string, dockerCli command.Cli) *cobra.Command
 	cmd.AddCommand(featureCommand(dockerCli))
 	cmd.AddCommand(gatewayCommand(dockerClient, dockerCli))
 	cmd.AddCommand(oauthCommand())
-	cmd.AddCommand(policyCommand())
+	cmd.AddCommand(policyCommand(dockerCli))
 	cmd.AddCommand(secretCommand(dockerClient))
-	cmd.AddCommand(serverCommand(dockerClient))
+	cmd.AddCommand(serverCommand(dockerClient, dockerCli))
 	cmd.AddCommand(toolsCommand(dockerClient))
 	cmd.AddCommand(versionCommand())
 
@@ -3,11 +3,17 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 
+	"filippo.io/age"
 	"github.com/spf13/cobra"
 
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/secrets"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/tui"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/secret-management/secret"
 )
 
@@ -33,6 +39,9 @@ func secretCommand(docker docker.Client) *cobra.Command {
 	cmd.AddCommand(listSecretCommand())
 	cmd.AddCommand(setSecretCommand())
 	cmd.AddCommand(exportSecretCommand(docker))
+	cmd.AddCommand(encryptSecretCommand())
+	cmd.AddCommand(decryptSecretCommand())
+	cmd.AddCommand(editSecretCommand())
 	return cmd
 }
 
@@ -132,3 +141,270 @@ func exportSecretCommand(docker docker.Client) *cobra.Command {
 		},
 	}
 }
+
+const encryptSecretExample = `
+### Encrypt a secrets file for yourself and a teammate
+
+> docker mcp secret encrypt .env --recipient age1... -o .env.age
+> docker mcp gateway run --secrets=.env.age
+
+### Encrypt a secrets file for a team, and commit the recipients
+
+> docker mcp secret encrypt .env -R team.txt -o .env.age
+> cp team.txt .env.age.recipients
+`
+
+const editSecretExample = `
+### Change a secret without decrypting the file to disk
+
+The file is re-encrypted for the recipients listed in .env.age.recipients
+
+> docker mcp secret edit .env.age --set github.personal_access_token
+
+### Remove a secret, re-encrypting for explicit recipients
+
+> docker mcp secret edit .env.age --unset github.personal_access_token -r age1... -r age1...
+`
+
+type recipientsOpts struct {
+	Recipients     []string
+	RecipientsFile string
+}
+
+func (opts *recipientsOpts) addFlags(cmd *cobra.Command, defaultRecipients string) {
+	flags := cmd.Flags()
+	flags.StringSliceVarP(&opts.Recipients, "recipient", "r", nil, "age recipients (age1...) to encrypt for (default is "+defaultRecipients+")")
+	flags.StringVarP(&opts.RecipientsFile, "recipients-file", "R", "", "File with age recipients, one per line")
+}
+
+// editRecipients returns the recipients of the flags, or those listed in the
+// recipients file next to an encrypted secrets file. A shared file is never
+// silently re-encrypted for the user's age key only.
+func (opts *recipientsOpts) editRecipients(path string) ([]age.Recipient, error) {
+	if len(opts.Recipients) > 0 || opts.RecipientsFile != "" {
+		return opts.resolve()
+	}
+
+	recipientsFile := secrets.RecipientsFile(path)
+	buf, err := os.ReadFile(recipientsFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no recipients to re-encrypt %s for, use --recipient, --recipients-file or list them in %s", path, recipientsFile)
+		}
+		return nil, err
+	}
+	recipients, err := secrets.ParseRecipients(string(buf))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", recipientsFile, err)
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipients in %s", recipientsFile)
+	}
+	return recipients, nil
+}
+
+// resolve returns the recipients of the flags, or those of the user's age
+// key if there are none.
+func (opts *recipientsOpts) resolve() ([]age.Recipient, error) {
+	list := strings.Join(opts.Recipients, "\n")
+	if opts.RecipientsFile != "" {
+		buf, err := os.ReadFile(opts.RecipientsFile)
+		if err != nil {
+			return nil, err
+		}
+		list += "\n" + string(buf)
+	}
+
+	recipients, err := secrets.ParseRecipients(list)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipients) > 0 {
+		return recipients, nil
+	}
+
+	identities, err := secrets.AgeIdentities()
+	if err != nil {
+		return nil, fmt.Errorf("no recipients: %w", err)
+	}
+	return secrets.IdentityRecipients(identities), nil
+}
+
+func encryptSecretCommand() *cobra.Command {
+	opts := &recipientsOpts{}
+	var output string
+	cmd := &cobra.Command{
+		Use:     "encrypt [file]",
+		Short:   "Encrypt a .env or YAML secrets file with age",
+		Example: strings.Trim(encryptSecretExample, "\n"),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			recipients, err := opts.resolve()
+			if err != nil {
+				return err
+			}
+
+			var plaintext []byte
+			if len(args) == 0 || args[0] == "-" {
+				plaintext, err = tui.ReadAllWithContext(cmd.Context(), os.Stdin)
+			} else {
+				plaintext, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return err
+			}
+			if secrets.IsEncrypted(plaintext) {
+				return errors.New("the file is already encrypted")
+			}
+
+			ciphertext, err := secrets.Encrypt(plaintext, recipients)
+			if err != nil {
+				return err
+			}
+			return writeOutput(cmd.OutOrStdout(), output, ciphertext)
+		},
+	}
+	opts.addFlags(cmd, "the recipient of your age key")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Path of the encrypted file to write (default is stdout)")
+	return cmd
+}
+
+func decryptSecretCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "decrypt file",
+		Short: "Decrypt an age encrypted secrets file to stdout",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plaintext, err := decryptSecretsFile(args[0])
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(plaintext)
+			return err
+		},
+	}
+}
+
+func editSecretCommand() *cobra.Command {
+	opts := &recipientsOpts{}
+	var (
+		set   []string
+		unset []string
+	)
+	cmd := &cobra.Command{
+		Use:     "edit file",
+		Short:   "Set or remove secrets in an age encrypted secrets file, without writing the plaintext to disk",
+		Example: strings.Trim(editSecretExample, "\n"),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(set) == 0 && len(unset) == 0 {
+				return errors.New("nothing to edit, use --set or --unset")
+			}
+
+			values := map[string]string{}
+			readFromStdin := false
+			for _, arg := range set {
+				key, value, found := strings.Cut(arg, "=")
+				if !found {
+					// The value is read from stdin, so that it doesn't show in the shell's history.
+					if readFromStdin {
+						return errors.New("only one secret can be read from stdin")
+					}
+					readFromStdin = true
+					buf, err := tui.ReadAllWithContext(cmd.Context(), os.Stdin)
+					if err != nil {
+						return err
+					}
+					value = strings.TrimSuffix(string(buf), "\n")
+				}
+				values[key] = value
+			}
+
+			recipients, err := opts.editRecipients(args[0])
+			if err != nil {
+				return err
+			}
+
+			buf, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			stanzas, err := secrets.CountStanzas(buf)
+			if err != nil {
+				return fmt.Errorf("%s: %w", args[0], err)
+			}
+			if stanzas > len(recipients) {
+				return fmt.Errorf("%s is encrypted for %d recipients but would be re-encrypted for %d only, pass all of them", args[0], stanzas, len(recipients))
+			}
+
+			plaintext, err := decryptSecretsFile(args[0])
+			if err != nil {
+				return err
+			}
+			plaintext, err = secrets.EditSecretsFile(args[0], plaintext, values, unset)
+			if err != nil {
+				return err
+			}
+			ciphertext, err := secrets.Encrypt(plaintext, recipients)
+			if err != nil {
+				return err
+			}
+			return writeFileAtomically(args[0], ciphertext)
+		},
+	}
+	opts.addFlags(cmd, "the recipients listed in <file>.recipients")
+	cmd.Flags().StringArrayVar(&set, "set", nil, "Secret to set, as name=value, or name to read the value from stdin")
+	cmd.Flags().StringArrayVar(&unset, "unset", nil, "Secret to remove")
+	return cmd
+}
+
+func decryptSecretsFile(path string) ([]byte, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !secrets.IsEncrypted(buf) {
+		return nil, fmt.Errorf("%s is not encrypted", path)
+	}
+
+	identities, err := secrets.AgeIdentities()
+	if err != nil {
+		return nil, err
+	}
+	return secrets.Decrypt(buf, identities)
+}
+
+func writeOutput(stdout io.Writer, path string, buf []byte) error {
+	if path == "" || path == "-" {
+		_, err := stdout.Write(buf)
+		return err
+	}
+	return os.WriteFile(path, buf, 0o644)
+}
+
+// writeFileAtomically replaces a file, keeping its permissions.
+func writeFileAtomically(path string, buf []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(info.Mode().Perm()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
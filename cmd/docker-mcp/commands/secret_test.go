@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/secrets"
+)
+
+func TestEditSecretKeepsRecipients(t *testing.T) {
+	me, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	teammate, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	t.Setenv(secrets.AgeKeyEnv, me.String())
+
+	path := filepath.Join(t.TempDir(), ".env.age")
+	ciphertext, err := secrets.Encrypt([]byte("github.token=s3cr3t\n"), []age.Recipient{me.Recipient(), teammate.Recipient()})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, ciphertext, 0o600))
+
+	edit := func(args ...string) error {
+		cmd := editSecretCommand()
+		cmd.SetArgs(append([]string{path, "--set", "notes.key=k3y"}, args...))
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return cmd.Execute()
+	}
+
+	// Never re-encrypted for the caller's key only.
+	require.ErrorContains(t, edit(), "no recipients to re-encrypt")
+	require.EqualError(t, edit("-r", me.Recipient().String()), path+" is encrypted for 2 recipients but would be re-encrypted for 1 only, pass all of them")
+
+	recipients := me.Recipient().String() + "\n" + teammate.Recipient().String() + "\n"
+	require.NoError(t, os.WriteFile(secrets.RecipientsFile(path), []byte(recipients), 0o644))
+	require.NoError(t, edit())
+
+	buf, err := os.ReadFile(path)
+	require.NoError(t, err)
+	plaintext, err := secrets.Decrypt(buf, []age.Identity{teammate})
+	require.NoError(t, err)
+	assert.Contains(t, string(plaintext), "notes.key=k3y")
+}
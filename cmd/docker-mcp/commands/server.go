@@ -5,14 +5,16 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/docker/cli/cli/command"
 	"github.com/spf13/cobra"
 
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/config"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/gateway"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/server"
 )
 
-func serverCommand(docker docker.Client) *cobra.Command {
+func serverCommand(docker docker.Client, dockerCli command.Cli) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "server",
 		Short: "Manage servers",
@@ -89,6 +91,8 @@ func serverCommand(docker docker.Client) *cobra.Command {
 		},
 	})
 
+	cmd.AddCommand(serverCheckCommand(docker, dockerCli))
+
 	cmd.AddCommand(&cobra.Command{
 		Use:   "reset",
 		Short: "Disable all the servers",
@@ -100,3 +104,33 @@ func serverCommand(docker docker.Client) *cobra.Command {
 
 	return cmd
 }
+
+func serverCheckCommand(docker docker.Client, dockerCli command.Cli) *cobra.Command {
+	options := defaultGatewayOptions()
+	var additionalCatalogs []string
+	var additionalConfigs []string
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check that servers have all the secrets and config values they need to start",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.ServerNames = args
+			options.CatalogPath = append(options.CatalogPath, additionalCatalogs...)
+			options.ConfigPath = append(options.ConfigPath, additionalConfigs...)
+			options.Watch = false
+			if err := applyLocalSecretPolicy(cmd.Context(), dockerCli, &options); err != nil {
+				return err
+			}
+
+			return gateway.NewGateway(options, docker).Check(cmd.Context(), cmd.OutOrStdout(), args)
+		},
+	}
+	cmd.Flags().StringSliceVar(&options.CatalogPath, "catalog", options.CatalogPath, "Paths to docker catalogs (absolute or relative to ~/.docker/mcp/catalogs/)")
+	cmd.Flags().StringSliceVar(&additionalCatalogs, "additional-catalog", nil, "Additional catalog paths to append to the default catalogs")
+	cmd.Flags().StringSliceVar(&options.ConfigPath, "config", options.ConfigPath, "Paths to the config files (absolute or relative to ~/.docker/mcp/)")
+	cmd.Flags().StringSliceVar(&additionalConfigs, "additional-config", nil, "Additional config paths to merge with the default config.yaml")
+	cmd.Flags().StringVar(&options.SecretsPath, "secrets", options.SecretsPath, "Comma separated chain of secret providers, like for `docker mcp gateway run`")
+
+	return cmd
+}
@@ -59,6 +59,23 @@ func toolsCommand(docker docker.Client) *cobra.Command {
 		},
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use:   "diff [server1] [server2] ...",
+		Short: "Show how the tools of servers changed since they were approved",
+		RunE: func(_ *cobra.Command, args []string) error {
+			return tools.Diff(args, format)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "approve <server1> [server2] ...",
+		Short: "Approve the changed tools of one or more servers",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return tools.Approve(args)
+		},
+	})
+
 	var enableServerName string
 	enableCmd := &cobra.Command{
 		Use:   "enable [tool1] [tool2] ...",
@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/telemetry"
+)
+
+// Decisions
+const (
+	Allowed  = "allowed"
+	Warned   = "warned"
+	Approved = "approved"
+	Denied   = "denied"
+	Blocked  = "blocked"
+)
+
+// Event is a security decision taken by the gateway.
+type Event struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Decision string    `json:"decision"`
+	Session  string    `json:"session,omitempty"`
+	Server   string    `json:"server,omitempty"`
+	Tool     string    `json:"tool,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	Sources  []string  `json:"sources,omitempty"`
+
+	// Network egress
+	Destination   string `json:"destination,omitempty"`
+	Method        string `json:"method,omitempty"`
+	BytesSent     int64  `json:"bytesSent,omitempty"`
+	BytesReceived int64  `json:"bytesReceived,omitempty"`
+}
+
+// Logger writes audit events as JSON lines.
+// A nil Logger only records telemetry.
+type Logger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+func New(w io.Writer) *Logger {
+	return &Logger{w: w}
+}
+
+// OpenFile appends audit events to a file.
+func OpenFile(path string) (*Logger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Logger{w: f, closer: f}, nil
+}
+
+func (l *Logger) Record(ctx context.Context, event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	telemetry.RecordAuditDecision(ctx, event.Kind, event.Decision, event.Server)
+
+	if l == nil || l.w == nil {
+		return
+	}
+
+	buf, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(append(buf, '\n'))
+}
+
+func (l *Logger) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf)
+
+	logger.Record(context.Background(), Event{
+		Time:     time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC),
+		Kind:     "dataflow",
+		Decision: Blocked,
+		Server:   "fetch",
+		Tool:     "fetch",
+		Sources:  []string{"github:get_file_contents"},
+	})
+
+	assert.JSONEq(t, `{"time":"2025-08-01T10:00:00Z","kind":"dataflow","decision":"blocked","server":"fetch","tool":"fetch","sources":["github:get_file_contents"]}`, buf.String())
+}
+
+func TestRecordNilLogger(t *testing.T) {
+	var logger *Logger
+
+	assert.NotPanics(t, func() {
+		logger.Record(context.Background(), Event{Kind: "dataflow", Decision: Allowed})
+	})
+	require.NoError(t, logger.Close())
+}
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.log")
+
+	logger, err := OpenFile(path)
+	require.NoError(t, err)
+	logger.Record(context.Background(), Event{Kind: "dataflow", Decision: Warned})
+	logger.Record(context.Background(), Event{Kind: "dataflow", Decision: Denied})
+	require.NoError(t, logger.Close())
+
+	buf, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(buf), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var event Event
+	require.NoError(t, json.Unmarshal(lines[1], &event))
+	assert.Equal(t, Denied, event.Decision)
+	assert.False(t, event.Time.IsZero())
+}
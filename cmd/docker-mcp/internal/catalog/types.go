@@ -13,23 +13,33 @@ type topLevel struct {
 // MCP Servers
 
 type Server struct {
-	Image          string   `yaml:"image" json:"image"`
-	LongLived      bool     `yaml:"longLived,omitempty" json:"longLived,omitempty"`
-	Remote         Remote   `yaml:"remote,omitempty" json:"remote,omitempty"`
-	SSEEndpoint    string   `yaml:"sseEndpoint,omitempty" json:"sseEndpoint,omitempty"` // Deprecated: Use Remote instead
-	Secrets        []Secret `yaml:"secrets,omitempty" json:"secrets,omitempty"`
-	Env            []Env    `yaml:"env,omitempty" json:"env,omitempty"`
-	Command        []string `yaml:"command,omitempty" json:"command,omitempty"`
-	Volumes        []string `yaml:"volumes,omitempty" json:"volumes,omitempty"`
-	User           string   `yaml:"user,omitempty" json:"user,omitempty"`
-	DisableNetwork bool     `yaml:"disableNetwork,omitempty" json:"disableNetwork,omitempty"`
-	AllowHosts     []string `yaml:"allowHosts,omitempty" json:"allowHosts,omitempty"`
-	Tools          []Tool   `yaml:"tools,omitempty" json:"tools,omitempty"`
+	Image          string         `yaml:"image" json:"image"`
+	LongLived      bool           `yaml:"longLived,omitempty" json:"longLived,omitempty"`
+	Remote         Remote         `yaml:"remote,omitempty" json:"remote,omitempty"`
+	SSEEndpoint    string         `yaml:"sseEndpoint,omitempty" json:"sseEndpoint,omitempty"` // Deprecated: Use Remote instead
+	Secrets        []Secret       `yaml:"secrets,omitempty" json:"secrets,omitempty"`
+	Env            []Env          `yaml:"env,omitempty" json:"env,omitempty"`
+	Command        []string       `yaml:"command,omitempty" json:"command,omitempty"`
+	Volumes        []string       `yaml:"volumes,omitempty" json:"volumes,omitempty"`
+	User           string         `yaml:"user,omitempty" json:"user,omitempty"`
+	DisableNetwork bool           `yaml:"disableNetwork,omitempty" json:"disableNetwork,omitempty"`
+	AllowHosts     []string       `yaml:"allowHosts,omitempty" json:"allowHosts,omitempty"`
+	Tools          []Tool         `yaml:"tools,omitempty" json:"tools,omitempty"`
+	DataFlow       *DataFlow      `yaml:"dataFlow,omitempty" json:"dataFlow,omitempty"`
+	Hardening      *Hardening     `yaml:"hardening,omitempty" json:"hardening,omitempty"`
+	Runtime        string         `yaml:"runtime,omitempty" json:"runtime,omitempty"`
+	Process        *Process       `yaml:"process,omitempty" json:"process,omitempty"`
+	Config         []ConfigSchema `yaml:"config,omitempty" json:"config,omitempty"`
 }
 
 type Secret struct {
 	Name string `yaml:"name" json:"name"`
-	Env  string `yaml:"env" json:"env"`
+	Env  string `yaml:"env,omitempty" json:"env,omitempty"`
+	// File is where the secret is written in the container, instead of the
+	// environment. Mode is octal and defaults to 0400.
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	UID  int    `yaml:"uid,omitempty" json:"uid,omitempty"`
 }
 
 type Env struct {
@@ -43,6 +53,62 @@ type Remote struct {
 	Headers   map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
 }
 
+// Config schema
+
+// ConfigSchema describes the config values a server reads with
+// {{name.property}} templates. Properties that are not required can be unset.
+type ConfigSchema struct {
+	Name        string                    `yaml:"name" json:"name"`
+	Description string                    `yaml:"description,omitempty" json:"description,omitempty"`
+	Type        string                    `yaml:"type,omitempty" json:"type,omitempty"`
+	Properties  map[string]ConfigProperty `yaml:"properties,omitempty" json:"properties,omitempty"`
+	Required    []string                  `yaml:"required,omitempty" json:"required,omitempty"`
+}
+
+type ConfigProperty struct {
+	Type        string `yaml:"type,omitempty" json:"type,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// Data flow labels
+
+type DataFlow struct {
+	DataFlowLabels `yaml:",inline"`
+	Tools          map[string]DataFlowLabels `yaml:"tools,omitempty" json:"tools,omitempty"`
+}
+
+type DataFlowLabels struct {
+	Sensitivity string `yaml:"sensitivity,omitempty" json:"sensitivity,omitempty"`
+	Egress      string `yaml:"egress,omitempty" json:"egress,omitempty"`
+}
+
+// Container hardening
+
+type Hardening struct {
+	// Profile is the gateway profile the server starts from. Setting it to "default"
+	// opts the server out of a stricter gateway profile.
+	Profile        string   `yaml:"profile,omitempty" json:"profile,omitempty"`
+	ReadOnlyRootfs *bool    `yaml:"readOnlyRootfs,omitempty" json:"readOnlyRootfs,omitempty"`
+	Tmpfs          []string `yaml:"tmpfs,omitempty" json:"tmpfs,omitempty"`
+	CapDrop        []string `yaml:"capDrop,omitempty" json:"capDrop,omitempty"`
+	CapAdd         []string `yaml:"capAdd,omitempty" json:"capAdd,omitempty"`
+	Seccomp        string   `yaml:"seccomp,omitempty" json:"seccomp,omitempty"`
+	AppArmor       string   `yaml:"apparmor,omitempty" json:"apparmor,omitempty"`
+	PidsLimit      int      `yaml:"pidsLimit,omitempty" json:"pidsLimit,omitempty"`
+	Ulimits        []string `yaml:"ulimits,omitempty" json:"ulimits,omitempty"`
+	Cpus           float64  `yaml:"cpus,omitempty" json:"cpus,omitempty"`
+	Memory         string   `yaml:"memory,omitempty" json:"memory,omitempty"`
+	Runtime        string   `yaml:"runtime,omitempty" json:"runtime,omitempty"`
+}
+
+// Local processes
+
+type Process struct {
+	Command    []string `yaml:"command" json:"command"`
+	WorkingDir string   `yaml:"workingDir,omitempty" json:"workingDir,omitempty"`
+	Sandbox    bool     `yaml:"sandbox,omitempty" json:"sandbox,omitempty"` // Run with bubblewrap
+}
+
 // POCI tools
 
 type Items struct {
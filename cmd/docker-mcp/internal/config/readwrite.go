@@ -41,6 +41,16 @@ func ReadCatalogFile(name string) ([]byte, error) {
 	return readFileOrEmpty(path)
 }
 
+// ReadPolicy reads the secret policy that the gateway enforces on Docker CE.
+func ReadPolicy() ([]byte, error) {
+	path, err := FilePath("policy.conf")
+	if err != nil {
+		return nil, err
+	}
+
+	return readFileOrEmpty(path)
+}
+
 func WriteTools(content []byte) error {
 	return writeConfigFile("tools.yaml", content)
 }
@@ -53,6 +63,10 @@ func WriteRegistry(content []byte) error {
 	return writeConfigFile("registry.yaml", content)
 }
 
+func WritePolicy(content []byte) error {
+	return writeConfigFile("policy.conf", content)
+}
+
 func WriteCatalog(content []byte) error {
 	return writeConfigFile("catalog.json", content)
 }
@@ -0,0 +1,179 @@
+package dataflow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+)
+
+// Sensitivity of the data returned by a server or a tool.
+const (
+	SensitivityPublic  = "public"
+	SensitivityPrivate = "private"
+)
+
+// Egress tells whether a server or a tool can send data outside.
+const (
+	EgressNone   = "none"
+	EgressPublic = "public"
+)
+
+// Mode is what the gateway does when a session that received private data
+// calls a tool with public egress.
+type Mode string
+
+const (
+	// ModeOff disables taint tracking.
+	ModeOff Mode = "off"
+	// ModeWarn logs and audits the call but lets it through.
+	ModeWarn Mode = "warn"
+	// ModeApprove asks the user, through elicitation, to approve the call.
+	// Clients that don't support elicitation are denied.
+	ModeApprove Mode = "approve"
+	// ModeBlock denies the call.
+	ModeBlock Mode = "block"
+)
+
+var modes = []Mode{ModeOff, ModeWarn, ModeApprove, ModeBlock}
+
+func ParseMode(value string) (Mode, error) {
+	mode := Mode(strings.ToLower(strings.TrimSpace(value)))
+	if mode == "" {
+		return ModeOff, nil
+	}
+	if !slices.Contains(modes, mode) {
+		return "", fmt.Errorf("invalid data flow policy %q, expected one of off, warn, approve or block", value)
+	}
+
+	return mode, nil
+}
+
+// Overrides are labels configured by the user, by server name. They take
+// precedence over the labels declared in the catalog.
+type Overrides map[string]catalog.DataFlow
+
+func ParseOverrides(buf []byte) (Overrides, error) {
+	var overrides Overrides
+	if err := yaml.Unmarshal(buf, &overrides); err != nil {
+		return nil, err
+	}
+
+	for serverName, dataFlow := range overrides {
+		if err := validate(dataFlow.DataFlowLabels); err != nil {
+			return nil, fmt.Errorf("server %s: %w", serverName, err)
+		}
+		for toolName, labels := range dataFlow.Tools {
+			if err := validate(labels); err != nil {
+				return nil, fmt.Errorf("tool %s:%s: %w", serverName, toolName, err)
+			}
+		}
+	}
+
+	return overrides, nil
+}
+
+func validate(labels catalog.DataFlowLabels) error {
+	switch labels.Sensitivity {
+	case "", SensitivityPublic, SensitivityPrivate:
+	default:
+		return fmt.Errorf("invalid sensitivity %q, expected public or private", labels.Sensitivity)
+	}
+
+	switch labels.Egress {
+	case "", EgressNone, EgressPublic:
+	default:
+		return fmt.Errorf("invalid egress %q, expected none or public", labels.Egress)
+	}
+
+	return nil
+}
+
+// Labels resolves the labels of a tool. From lowest to highest precedence:
+// server labels in the catalog, tool labels in the catalog, server labels in
+// the overrides, tool labels in the overrides.
+func Labels(serverName string, server catalog.Server, toolName string, overrides Overrides) catalog.DataFlowLabels {
+	var labels catalog.DataFlowLabels
+	if server.DataFlow != nil {
+		labels = server.DataFlow.DataFlowLabels
+		merge(&labels, server.DataFlow.Tools[toolName])
+	}
+
+	if override, found := overrides[serverName]; found {
+		merge(&labels, override.DataFlowLabels)
+		merge(&labels, override.Tools[toolName])
+	}
+
+	return labels
+}
+
+func merge(labels *catalog.DataFlowLabels, other catalog.DataFlowLabels) {
+	if other.Sensitivity != "" {
+		labels.Sensitivity = other.Sensitivity
+	}
+	if other.Egress != "" {
+		labels.Egress = other.Egress
+	}
+}
+
+// Taint tracks the private sources a session has received data from.
+type Taint struct {
+	mu      sync.Mutex
+	sources []Source
+}
+
+// Source is a tool or a resource that returned private data.
+type Source struct {
+	Server string
+	Name   string
+}
+
+func (s Source) String() string {
+	return s.Server + ":" + s.Name
+}
+
+// Mark records that a session received data from a private source.
+func (t *Taint) Mark(source Source) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !slices.Contains(t.sources, source) {
+		t.sources = append(t.sources, source)
+	}
+}
+
+// ForeignSources returns the private sources that don't belong to the given
+// server, in the order they were first seen. A server sending back its own
+// data isn't a cross-server flow.
+func (t *Taint) ForeignSources(serverName string) []Source {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var sources []Source
+	for _, source := range t.sources {
+		if source.Server != serverName {
+			sources = append(sources, source)
+		}
+	}
+
+	return sources
+}
+
+// ToxicSources returns the private sources whose data could leak if a tool
+// of the given server, with the given labels, was called. It returns nothing
+// if the call is safe.
+func ToxicSources(taint *Taint, serverName string, labels catalog.DataFlowLabels) []Source {
+	if labels.Egress != EgressPublic {
+		return nil
+	}
+
+	return taint.ForeignSources(serverName)
+}
@@ -0,0 +1,98 @@
+package dataflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+)
+
+func parseServer(t *testing.T, content string) catalog.Server {
+	t.Helper()
+	var server catalog.Server
+	require.NoError(t, yaml.Unmarshal([]byte(content), &server))
+	return server
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("")
+	require.NoError(t, err)
+	assert.Equal(t, ModeOff, mode)
+
+	mode, err = ParseMode("Approve")
+	require.NoError(t, err)
+	assert.Equal(t, ModeApprove, mode)
+
+	_, err = ParseMode("deny")
+	require.ErrorContains(t, err, `invalid data flow policy "deny"`)
+}
+
+func TestLabelsFromCatalog(t *testing.T) {
+	server := parseServer(t, `
+image: mcp/github
+dataFlow:
+  sensitivity: private
+  egress: public
+  tools:
+    search_repositories:
+      sensitivity: public
+`)
+
+	assert.Equal(t, catalog.DataFlowLabels{Sensitivity: "private", Egress: "public"}, Labels("github", server, "get_file_contents", nil))
+	assert.Equal(t, catalog.DataFlowLabels{Sensitivity: "public", Egress: "public"}, Labels("github", server, "search_repositories", nil))
+}
+
+func TestLabelsOverrides(t *testing.T) {
+	server := parseServer(t, `
+image: mcp/fetch
+dataFlow:
+  egress: public
+`)
+
+	overrides, err := ParseOverrides([]byte(`
+fetch:
+  sensitivity: public
+  tools:
+    fetch:
+      egress: none
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, catalog.DataFlowLabels{Sensitivity: "public", Egress: "none"}, Labels("fetch", server, "fetch", overrides))
+	assert.Equal(t, catalog.DataFlowLabels{Sensitivity: "public", Egress: "public"}, Labels("fetch", server, "other", overrides))
+}
+
+func TestParseOverridesInvalid(t *testing.T) {
+	_, err := ParseOverrides([]byte(`
+fetch:
+  egress: internet
+`))
+	require.ErrorContains(t, err, `server fetch: invalid egress "internet"`)
+
+	_, err = ParseOverrides([]byte(`
+github:
+  tools:
+    get_me:
+      sensitivity: secret
+`))
+	require.ErrorContains(t, err, `tool github:get_me: invalid sensitivity "secret"`)
+}
+
+func TestToxicSources(t *testing.T) {
+	taint := &Taint{}
+	egress := catalog.DataFlowLabels{Egress: EgressPublic}
+
+	assert.Empty(t, ToxicSources(taint, "fetch", egress))
+
+	taint.Mark(Source{Server: "github", Name: "get_file_contents"})
+	taint.Mark(Source{Server: "github", Name: "get_file_contents"})
+	taint.Mark(Source{Server: "fetch", Name: "fetch"})
+
+	assert.Equal(t, []Source{{Server: "github", Name: "get_file_contents"}}, ToxicSources(taint, "fetch", egress))
+	assert.Equal(t, []Source{{Server: "fetch", Name: "fetch"}}, ToxicSources(taint, "github", egress))
+	assert.Empty(t, ToxicSources(taint, "fetch", catalog.DataFlowLabels{Egress: EgressNone}))
+	assert.Empty(t, ToxicSources(nil, "fetch", egress))
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
@@ -26,13 +27,15 @@ type Client interface {
 	InspectContainer(ctx context.Context, containerID string) (container.InspectResponse, error)
 	ReadLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error)
 	ImageExists(ctx context.Context, name string) (bool, error)
+	InspectImage(ctx context.Context, name string) (image.InspectResponse, error)
 	PullImage(ctx context.Context, name string) error
 	PullImages(ctx context.Context, names ...string) error
-	CreateNetwork(ctx context.Context, name string, internal bool, labels map[string]string) error
+	CreateNetwork(ctx context.Context, name string, internal, ipv6 bool, labels map[string]string) error
 	RemoveNetwork(ctx context.Context, name string) error
 	ConnectNetwork(ctx context.Context, networkName, container, hostname string) error
 	InspectVolume(ctx context.Context, name string) (volume.Volume, error)
 	ReadSecrets(ctx context.Context, names []string, lenient bool) (map[string]string, error)
+	APIClient() client.APIClient
 }
 
 type dockerClient struct {
@@ -54,6 +57,11 @@ func NewClient(cli command.Cli) Client {
 	}
 }
 
+// APIClient gives direct access to the Docker Engine API.
+func (c *dockerClient) APIClient() client.APIClient {
+	return c.apiClient()
+}
+
 func RunningInDockerCE(ctx context.Context, dockerCli command.Cli) (bool, error) {
 	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
 		return false, nil
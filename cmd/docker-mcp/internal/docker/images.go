@@ -23,6 +23,10 @@ func (c *dockerClient) ImageExists(ctx context.Context, name string) (bool, erro
 	return err == nil, err
 }
 
+func (c *dockerClient) InspectImage(ctx context.Context, name string) (image.InspectResponse, error) {
+	return c.apiClient().ImageInspect(ctx, name)
+}
+
 func (c *dockerClient) PullImages(ctx context.Context, names ...string) error {
 	registryAuthFn := sync.OnceValue(func() string {
 		return getRegistryAuth(ctx)
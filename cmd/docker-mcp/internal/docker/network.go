@@ -6,10 +6,11 @@ import (
 	"github.com/docker/docker/api/types/network"
 )
 
-func (c *dockerClient) CreateNetwork(ctx context.Context, name string, internal bool, labels map[string]string) error {
+func (c *dockerClient) CreateNetwork(ctx context.Context, name string, internal, ipv6 bool, labels map[string]string) error {
 	_, err := c.apiClient().NetworkCreate(ctx, name, network.CreateOptions{
-		Internal: internal,
-		Labels:   labels,
+		Internal:   internal,
+		EnableIPv6: &ipv6,
+		Labels:     labels,
 	})
 	if err != nil {
 		return err
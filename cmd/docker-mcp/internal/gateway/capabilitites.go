@@ -23,8 +23,9 @@ type Capabilities struct {
 }
 
 type ToolRegistration struct {
-	Tool    *mcp.Tool
-	Handler mcp.ToolHandler
+	Tool     *mcp.Tool
+	Handler  mcp.ToolHandler
+	ReadOnly bool
 }
 
 type PromptRegistration struct {
@@ -48,6 +49,8 @@ func (g *Gateway) listCapabilities(ctx context.Context, configuration Configurat
 		allCapabilities []Capabilities
 	)
 
+	toolLock, toolLockPath := g.readToolLock()
+
 	errs, ctx := errgroup.WithContext(ctx)
 	errs.SetLimit(runtime.NumCPU())
 	for _, serverName := range serverNames {
@@ -76,13 +79,27 @@ func (g *Gateway) listCapabilities(ctx context.Context, configuration Configurat
 					// Record the number of tools discovered from this server
 					telemetry.RecordToolList(ctx, serverConfig.Name, len(tools.Tools))
 
+					isToolPinned := g.checkToolPins(toolLock, serverConfig.Name, tools.Tools)
+
 					for _, tool := range tools.Tools {
 						if !isToolEnabled(configuration, serverConfig.Name, serverConfig.Spec.Image, tool.Name, g.ToolNames) {
 							continue
 						}
+						if !isToolPinned(tool) {
+							continue
+						}
+						readOnly := isToolReadOnly(serverConfig.Name, tool, g.ReadOnlyTools)
+						if g.ReadOnly && !readOnly {
+							continue
+						}
+						tool, ok := g.scanTool(serverConfig.Name, tool)
+						if !ok {
+							continue
+						}
 						capabilities.Tools = append(capabilities.Tools, ToolRegistration{
-							Tool:    tool,
-							Handler: g.mcpServerToolHandler(serverConfig, g.mcpServer, tool.Annotations),
+							Tool:     tool,
+							Handler:  g.mcpServerToolHandler(serverConfig, g.mcpServer, tool.Annotations),
+							ReadOnly: readOnly,
 						})
 					}
 				}
@@ -175,9 +192,15 @@ func (g *Gateway) listCapabilities(ctx context.Context, configuration Configurat
 					// This is a complex conversion that needs proper implementation
 				}
 
+				readOnly := isToolReadOnly(serverName, &mcpTool, g.ReadOnlyTools)
+				if g.ReadOnly && !readOnly {
+					continue
+				}
+
 				capabilities.Tools = append(capabilities.Tools, ToolRegistration{
-					Tool:    &mcpTool,
-					Handler: g.mcpToolHandler(tool),
+					Tool:     &mcpTool,
+					Handler:  g.mcpToolHandler(serverName, tool),
+					ReadOnly: readOnly,
 				})
 			}
 
@@ -191,6 +214,12 @@ func (g *Gateway) listCapabilities(ctx context.Context, configuration Configurat
 		return nil, err
 	}
 
+	if toolLock != nil {
+		if err := toolLock.WriteFile(toolLockPath); err != nil {
+			logf("  > Can't write the tools lock file: %s", err)
+		}
+	}
+
 	// Merge all capabilities
 	var allTools []ToolRegistration
 	var allPrompts []PromptRegistration
@@ -2,11 +2,17 @@ package gateway
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
@@ -15,6 +21,9 @@ import (
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/eval"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/gateway/proxies"
 	mcpclient "github.com/docker/mcp-gateway/cmd/docker-mcp/internal/mcp"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/mounts"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/runtime"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/secretpolicy"
 )
 
 type clientKey struct {
@@ -35,6 +44,24 @@ type clientPool struct {
 	clientLock  sync.RWMutex
 	networks    []string
 	docker      docker.Client
+
+	// composeProject is the compose project of the gateway's container, if
+	// any. Pre-started servers are looked for there, in static mode.
+	composeProject string
+
+	// draining are the long-lived clients that are restarted, until their
+	// in-flight calls are done.
+	draining []*clientGetter
+
+	// runtimes are created on first use, by name.
+	runtimes     map[string]runtime.Runtime
+	runtimesLock sync.Mutex
+
+	// onEgress is called for every egress event of the network proxies.
+	onEgress func(serverName string, event proxies.EgressEvent)
+
+	// secretPolicy, if any, is enforced on every server that's started.
+	secretPolicy atomic.Pointer[secretpolicy.Policy]
 }
 
 type clientConfig struct {
@@ -48,9 +75,14 @@ func newClientPool(options Options, docker docker.Client) *clientPool {
 		Options:     options,
 		docker:      docker,
 		keptClients: make(map[clientKey]keptClient),
+		runtimes:    make(map[string]runtime.Runtime),
 	}
 }
 
+func (cp *clientPool) SetSecretPolicy(policy *secretpolicy.Policy) {
+	cp.secretPolicy.Store(policy)
+}
+
 func (cp *clientPool) UpdateRoots(ss *mcp.ServerSession, roots []*mcp.Root) {
 	cp.clientLock.RLock()
 	defer cp.clientLock.RUnlock()
@@ -83,16 +115,20 @@ func (cp *clientPool) AcquireClient(ctx context.Context, serverConfig *catalog.S
 	cp.clientLock.RLock()
 	if kc, exists := cp.keptClients[key]; exists {
 		getter = kc.Getter
+		getter.inFlight.Add(1)
 	}
 	cp.clientLock.RUnlock()
 
 	// No client found, create a new one
+	created := false
 	if getter == nil {
 		getter = newClientGetter(serverConfig, cp, config)
+		created = true
 
 		// If the client is long running, save it for later
 		if cp.longLived(serverConfig, config) {
 			c = context.Background()
+			getter.inFlight.Add(1)
 			cp.clientLock.Lock()
 			cp.keptClients[key] = keptClient{
 				Name:         serverConfig.Name,
@@ -112,30 +148,95 @@ func (cp *clientPool) AcquireClient(ctx context.Context, serverConfig *catalog.S
 		// Wasn't successful, remove it
 		if cp.longLived(serverConfig, config) {
 			delete(cp.keptClients, key)
+			getter.inFlight.Done()
 		}
 
 		return nil, err
 	}
 
+	// Pre-started servers can restart behind our back
+	if created && cp.longLived(serverConfig, config) && cp.isStatic(&serverConfig.Spec) {
+		go cp.watchStatic(key, getter, client)
+	}
+
 	return client, nil
 }
 
 func (cp *clientPool) ReleaseClient(client mcpclient.Client) {
-	foundKept := false
+	var kept *clientGetter
 	cp.clientLock.RLock()
 	for _, kc := range cp.keptClients {
 		if kc.Getter.IsClient(client) {
-			foundKept = true
+			kept = kc.Getter
 			break
 		}
 	}
+	if kept == nil {
+		if i := slices.IndexFunc(cp.draining, func(g *clientGetter) bool { return g.IsClient(client) }); i >= 0 {
+			kept = cp.draining[i]
+		}
+	}
 	cp.clientLock.RUnlock()
 
 	// Client was not kept, close it
-	if !foundKept {
-		client.Session().Close()
+	if kept == nil {
+		closeClient(client)
 		return
 	}
+	kept.inFlight.Done()
+}
+
+// RestartServers forgets the long-lived clients of some servers, so that
+// they are started again, with their new configuration, on next use. The
+// previous clients are closed once their in-flight calls are done, or after
+// drainTimeout.
+func (cp *clientPool) RestartServers(serverNames []string) {
+	var restarting []*clientGetter
+	cp.clientLock.Lock()
+	for key, kc := range cp.keptClients {
+		// Pre-started servers are not ours to restart.
+		if !slices.Contains(serverNames, key.serverName) || cp.isStatic(&kc.Config.Spec) {
+			continue
+		}
+		delete(cp.keptClients, key)
+		cp.draining = append(cp.draining, kc.Getter)
+		restarting = append(restarting, kc.Getter)
+	}
+	cp.clientLock.Unlock()
+
+	for _, getter := range restarting {
+		log("  - Restarting", getter.instanceName())
+		go cp.drain(getter)
+	}
+}
+
+// drainTimeout is how long the in-flight calls of a restarted server have to
+// complete.
+var drainTimeout = 30 * time.Second
+
+func (cp *clientPool) drain(getter *clientGetter) {
+	drained := make(chan struct{})
+	go func() {
+		getter.inFlight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(drainTimeout):
+		logf("  - Calls to %s are still in flight after %s, closing it anyway", getter.serverConfig.Name, drainTimeout)
+	}
+
+	client, err := getter.GetClient(context.TODO()) // should be cached
+	if err == nil {
+		closeClient(client)
+	}
+
+	// The calls that were cut short are released before it's forgotten.
+	<-drained
+	cp.clientLock.Lock()
+	cp.draining = slices.DeleteFunc(cp.draining, func(g *clientGetter) bool { return g == getter })
+	cp.clientLock.Unlock()
 }
 
 func (cp *clientPool) Close() {
@@ -148,21 +249,68 @@ func (cp *clientPool) Close() {
 	for _, keptClient := range existingMap {
 		client, err := keptClient.Getter.GetClient(context.TODO()) // should be cached
 		if err == nil {
-			client.Session().Close()
+			closeClient(client)
+		}
+	}
+}
+
+// CloseSession closes the long-lived clients of a client session.
+func (cp *clientPool) CloseSession(ss *mcp.ServerSession) {
+	var closing []keptClient
+	cp.clientLock.Lock()
+	for key, keptClient := range cp.keptClients {
+		if key.session == ss {
+			closing = append(closing, keptClient)
+			delete(cp.keptClients, key)
+		}
+	}
+	cp.clientLock.Unlock()
+
+	for _, keptClient := range closing {
+		client, err := keptClient.Getter.GetClient(context.TODO()) // should be cached
+		if err == nil {
+			closeClient(client)
+		}
+	}
+}
+
+// closeClient closes a client's session and cleans up what was started
+// alongside, like network proxies.
+func closeClient(client mcpclient.Client) {
+	if closer, ok := client.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logf("  - Error closing client: %s", err)
 		}
+		return
 	}
+	client.Session().Close()
 }
 
 func (cp *clientPool) SetNetworks(networks []string) {
 	cp.networks = networks
 }
 
-func (cp *clientPool) runToolContainer(ctx context.Context, tool catalog.Tool, params *mcp.CallToolParams) (*mcp.CallToolResult, error) {
-	args := cp.baseArgs(tool.Name)
+func (cp *clientPool) SetComposeProject(project string) {
+	cp.composeProject = project
+}
 
-	// Attach the MCP servers to the same network as the gateway.
-	for _, network := range cp.networks {
-		args = append(args, "--network", network)
+func (cp *clientPool) runToolContainer(ctx context.Context, serverName string, tool catalog.Tool, params *mcp.CallToolParams, readOnly bool) (*mcp.CallToolResult, error) {
+	rt, err := cp.runtimeFor(&catalog.Server{})
+	if err != nil {
+		return nil, err
+	}
+	args, err := cp.baseArgs(tool.Name, nil, cp.serverRuntime(&catalog.Server{}))
+	if err != nil {
+		return nil, err
+	}
+
+	if cp.toolEgress(serverName, tool.Name) == egressNone {
+		args = append(args, "--network", "none")
+	} else if cp.onDocker(&catalog.Server{}) {
+		// Attach the MCP servers to the same network as the gateway.
+		for _, network := range cp.networks {
+			args = append(args, "--network", network)
+		}
 	}
 
 	// Convert params.Arguments to map[string]any
@@ -172,13 +320,16 @@ func (cp *clientPool) runToolContainer(ctx context.Context, tool catalog.Tool, p
 	}
 
 	// Volumes
-	for _, mount := range eval.EvaluateList(tool.Container.Volumes, arguments) {
-		if mount == "" {
-			continue
-		}
-
-		args = append(args, "-v", mount)
+	volumes, err := cp.volumes(eval.EvaluateList(tool.Container.Volumes, arguments), readOnly)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{
+				Text: fmt.Sprintf("Can't run tool %s: %s", tool.Name, err),
+			}},
+			IsError: true,
+		}, nil
 	}
+	args = append(args, volumes...)
 
 	// User
 	if tool.Container.User != "" {
@@ -188,24 +339,30 @@ func (cp *clientPool) runToolContainer(ctx context.Context, tool catalog.Tool, p
 		}
 	}
 
-	// Image
-	args = append(args, tool.Container.Image)
-
 	// Command
 	command := eval.EvaluateList(tool.Container.Command, arguments)
-	args = append(args, command...)
 
-	log("  - Running container", tool.Container.Image, "with args", args)
+	log("  - Running container", tool.Container.Image, "with args", args, "and command", command)
 
-	cmd := exec.CommandContext(ctx, "docker", args...)
+	spec := runtime.Spec{
+		Name:    tool.Name,
+		Args:    args[1:], // without `run`
+		Image:   tool.Container.Image,
+		Command: command,
+	}
 	if cp.Verbose {
-		cmd.Stderr = os.Stderr
+		spec.Stderr = os.Stderr
 	}
-	out, err := cmd.Output()
+	out, err := rt.Run(ctx, spec)
 	if err != nil {
+		text := string(out)
+		var exitErr *runtime.ExitError
+		if !errors.As(err, &exitErr) {
+			text += fmt.Sprintf("Can't run tool %s: %s", tool.Name, err)
+		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{&mcp.TextContent{
-				Text: string(out),
+				Text: text,
 			}},
 			IsError: true,
 		}, nil
@@ -219,17 +376,38 @@ func (cp *clientPool) runToolContainer(ctx context.Context, tool catalog.Tool, p
 	}, nil
 }
 
-func (cp *clientPool) baseArgs(name string) []string {
+func (cp *clientPool) baseArgs(name string, spec *catalog.Hardening, runtimeName string) ([]string, error) {
 	args := []string{"run"}
 
 	args = append(args, "--rm", "-i", "--init", "--security-opt", "no-new-privileges")
-	if cp.Cpus > 0 {
+
+	hardening, err := resolveHardening(cp.Hardening, spec)
+	if err != nil {
+		return nil, err
+	}
+	flags, err := hardeningArgs(hardening)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, flags...)
+
+	if hardening.Cpus > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(hardening.Cpus, 'f', -1, 64))
+	} else if cp.Cpus > 0 {
 		args = append(args, "--cpus", fmt.Sprintf("%d", cp.Cpus))
 	}
-	if cp.Memory != "" {
+	if hardening.Memory != "" {
+		args = append(args, "--memory", hardening.Memory)
+	} else if cp.Memory != "" {
 		args = append(args, "--memory", cp.Memory)
 	}
-	args = append(args, "--pull", "never")
+	// Images are pulled by the gateway before they are used, except for
+	// podman that has its own image store.
+	if runtimeName == runtimePodman {
+		args = append(args, "--pull", "missing")
+	} else {
+		args = append(args, "--pull", "never")
+	}
 
 	if os.Getenv("DOCKER_MCP_IN_DIND") == "1" {
 		args = append(args, "--privileged")
@@ -243,28 +421,31 @@ func (cp *clientPool) baseArgs(name string) []string {
 		"-l", "docker-mcp-transport=stdio",
 	)
 
-	return args
+	return args, nil
 }
 
-func (cp *clientPool) argsAndEnv(serverConfig *catalog.ServerConfig, readOnly *bool, targetConfig proxies.TargetConfig) ([]string, []string) {
-	args := cp.baseArgs(serverConfig.Name)
+func (cp *clientPool) argsAndEnv(serverConfig *catalog.ServerConfig, readOnly *bool, targetConfig proxies.TargetConfig) ([]string, []string, error) {
+	args, err := cp.baseArgs(serverConfig.Name, serverConfig.Spec.Hardening, cp.serverRuntime(&serverConfig.Spec))
+	if err != nil {
+		return nil, nil, fmt.Errorf("server %s: %w", serverConfig.Name, err)
+	}
 	var env []string
 
 	// Security options
-	if serverConfig.Spec.DisableNetwork {
+	switch cp.networkEgress(serverConfig.Name, &serverConfig.Spec) {
+	case egressNone:
 		args = append(args, "--network", "none")
-	} else {
+	case egressUnrestricted:
 		// Attach the MCP servers to the same network as the gateway.
-		for _, network := range cp.networks {
-			args = append(args, "--network", network)
+		if cp.onDocker(&serverConfig.Spec) {
+			for _, network := range cp.networks {
+				args = append(args, "--network", network)
+			}
 		}
 	}
 	if targetConfig.NetworkName != "" {
 		args = append(args, "--network", targetConfig.NetworkName)
 	}
-	for _, link := range targetConfig.Links {
-		args = append(args, "--link", link)
-	}
 	for _, env := range targetConfig.Env {
 		args = append(args, "-e", env)
 	}
@@ -272,17 +453,25 @@ func (cp *clientPool) argsAndEnv(serverConfig *catalog.ServerConfig, readOnly *b
 		args = append(args, "--dns", targetConfig.DNS)
 	}
 
-	// Secrets
+	// Secrets, except the ones written to files
 	for _, s := range serverConfig.Spec.Secrets {
+		if s.File != "" {
+			continue
+		}
+		if !cp.secretPolicy.Load().Allows(s.Name, serverConfig.Name) {
+			return nil, nil, fmt.Errorf("server %s: the secret policy doesn't allow it to receive secret %s", serverConfig.Name, s.Name)
+		}
 		args = append(args, "-e", s.Env)
 
 		secretValue, ok := serverConfig.Secrets[s.Name]
-		if ok {
-			env = append(env, fmt.Sprintf("%s=%s", s.Env, secretValue))
-		} else {
-			logf("Warning: Secret '%s' not found for server '%s', setting %s=<UNKNOWN>", s.Name, serverConfig.Name, s.Env)
-			env = append(env, fmt.Sprintf("%s=%s", s.Env, "<UNKNOWN>"))
+		if !ok {
+			if cp.MissingValues != missingValuesElicit {
+				return nil, nil, fmt.Errorf("server %s: secret %s is not set", serverConfig.Name, s.Name)
+			}
+			// Only to list the tools. The client is asked for the value at first use.
+			secretValue = "<UNKNOWN>"
 		}
+		env = append(env, fmt.Sprintf("%s=%s", s.Env, secretValue))
 	}
 
 	// Env
@@ -301,17 +490,11 @@ func (cp *clientPool) argsAndEnv(serverConfig *catalog.ServerConfig, readOnly *b
 	}
 
 	// Volumes
-	for _, mount := range eval.EvaluateList(serverConfig.Spec.Volumes, serverConfig.Config) {
-		if mount == "" {
-			continue
-		}
-
-		if readOnly != nil && *readOnly && !strings.HasSuffix(mount, ":ro") {
-			args = append(args, "-v", mount+":ro")
-		} else {
-			args = append(args, "-v", mount)
-		}
+	volumes, err := cp.volumes(eval.EvaluateList(serverConfig.Spec.Volumes, serverConfig.Config), readOnly != nil && *readOnly)
+	if err != nil {
+		return nil, nil, fmt.Errorf("server %s: %w", serverConfig.Name, err)
 	}
+	args = append(args, volumes...)
 
 	// User
 	if serverConfig.Spec.User != "" {
@@ -324,7 +507,88 @@ func (cp *clientPool) argsAndEnv(serverConfig *catalog.ServerConfig, readOnly *b
 		}
 	}
 
-	return args, env
+	return args, env, nil
+}
+
+// secretFiles are the secrets that are copied into the container as files,
+// instead of being passed as environment variables.
+func (cp *clientPool) secretFiles(serverConfig *catalog.ServerConfig) ([]runtime.File, error) {
+	var files []runtime.File
+	for _, s := range serverConfig.Spec.Secrets {
+		if s.File == "" {
+			continue
+		}
+		mode, err := secretFileMode(s)
+		if err != nil {
+			return nil, fmt.Errorf("server %s: %w", serverConfig.Name, err)
+		}
+		if !cp.secretPolicy.Load().Allows(s.Name, serverConfig.Name) {
+			return nil, fmt.Errorf("server %s: the secret policy doesn't allow it to receive secret %s", serverConfig.Name, s.Name)
+		}
+
+		secretValue, ok := serverConfig.Secrets[s.Name]
+		if !ok {
+			if cp.MissingValues != missingValuesElicit {
+				return nil, fmt.Errorf("server %s: secret %s is not set", serverConfig.Name, s.Name)
+			}
+			// Only to list the tools. The client is asked for the value at first use.
+			continue
+		}
+		files = append(files, runtime.File{
+			Path:    s.File,
+			Content: []byte(secretValue),
+			Mode:    mode,
+			UID:     s.UID,
+		})
+	}
+	return files, nil
+}
+
+// secretFileMode parses the octal mode of a secret file, 0400 by default.
+func secretFileMode(s catalog.Secret) (int64, error) {
+	if !path.IsAbs(s.File) || path.Dir(path.Clean(s.File)) == "/" {
+		return 0, fmt.Errorf("secret %s: file %q must be an absolute path, in a directory", s.Name, s.File)
+	}
+	if s.Mode == "" {
+		return 0o400, nil
+	}
+	mode, err := strconv.ParseInt(strings.TrimPrefix(s.Mode, "0o"), 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("secret %s: invalid file mode %q", s.Name, s.Mode)
+	}
+	return mode, nil
+}
+
+// volumes checks the mounts against the mount policy and returns the matching `-v` flags.
+func (cp *clientPool) volumes(volumes []string, readOnly bool) ([]string, error) {
+	policy := cp.mountPolicy()
+
+	var args []string
+	for _, volume := range volumes {
+		if volume == "" {
+			continue
+		}
+
+		mount, err := policy.Apply(volume)
+		if err != nil {
+			return nil, err
+		}
+		if readOnly || cp.ReadOnly {
+			mount = mounts.ReadOnly(mount)
+		}
+		args = append(args, "-v", mount)
+	}
+
+	return args, nil
+}
+
+func (cp *clientPool) mountPolicy() *mounts.Policy {
+	return &mounts.Policy{
+		Allowed:      cp.MountAllow,
+		Denied:       cp.MountDeny,
+		ReadOnly:     cp.MountReadOnly,
+		AllowSockets: cp.AllowSocketMounts,
+	}
 }
 
 func expandEnv(value string, env []string) string {
@@ -351,6 +615,9 @@ type clientGetter struct {
 	client mcpclient.Client
 	err    error
 
+	// inFlight counts the calls of a long-lived client.
+	inFlight sync.WaitGroup
+
 	serverConfig *catalog.ServerConfig
 	cp           *clientPool
 
@@ -369,6 +636,17 @@ func (cg *clientGetter) IsClient(client mcpclient.Client) bool {
 	return cg.client == client
 }
 
+// instanceName identifies the server instance, for a given client session if
+// it's long-lived.
+func (cg *clientGetter) instanceName() string {
+	if cg.clientConfig != nil && cg.clientConfig.serverSession != nil {
+		if id := cg.clientConfig.serverSession.ID(); id != "" {
+			return cg.serverConfig.Name + "-" + id[:min(len(id), 8)]
+		}
+	}
+	return cg.serverConfig.Name
+}
+
 func (cg *clientGetter) GetClient(ctx context.Context) (mcpclient.Client, error) {
 	cg.once.Do(func() {
 		createClient := func() (mcpclient.Client, error) {
@@ -381,13 +659,24 @@ func (cg *clientGetter) GetClient(ctx context.Context) (mcpclient.Client, error)
 				client = mcpclient.NewRemoteMCPClient(cg.serverConfig)
 			} else if cg.serverConfig.Spec.Remote.URL != "" {
 				client = mcpclient.NewRemoteMCPClient(cg.serverConfig)
-			} else if cg.cp.Static {
-				client = mcpclient.NewStdioCmdClient(cg.serverConfig.Name, "socat", nil, "STDIO", fmt.Sprintf("TCP:mcp-%s:4444", cg.serverConfig.Name))
+			} else if cg.cp.isStatic(&cg.serverConfig.Spec) {
+				var err error
+				if client, err = cg.cp.staticClient(ctx, cg.serverConfig); err != nil {
+					return nil, err
+				}
 			} else {
+				rt, err := cg.cp.runtimeFor(&cg.serverConfig.Spec)
+				if err != nil {
+					return nil, fmt.Errorf("server %s: %w", cg.serverConfig.Name, err)
+				}
+
 				var targetConfig proxies.TargetConfig
-				if cg.cp.BlockNetwork && len(cg.serverConfig.Spec.AllowHosts) > 0 {
-					var err error
-					if targetConfig, cleanup, err = cg.cp.runProxies(ctx, cg.serverConfig.Spec.AllowHosts, cg.serverConfig.Spec.LongLived); err != nil {
+				if cg.cp.networkEgress(cg.serverConfig.Name, &cg.serverConfig.Spec) == egressAllowHosts {
+					// The proxies run on Docker, next to the server.
+					if !cg.cp.onDocker(&cg.serverConfig.Spec) {
+						return nil, fmt.Errorf("server %s: allowHosts can't be enforced with the %s runtime", cg.serverConfig.Name, cg.cp.serverRuntime(&cg.serverConfig.Spec))
+					}
+					if targetConfig, cleanup, err = cg.cp.runProxies(ctx, cg.serverConfig.Name, cg.instanceName(), cg.serverConfig.Spec.AllowHosts, cg.serverConfig.Spec.LongLived); err != nil {
 						return nil, err
 					}
 				}
@@ -397,21 +686,41 @@ func (cg *clientGetter) GetClient(ctx context.Context) (mcpclient.Client, error)
 				if cg.clientConfig != nil {
 					readOnly = cg.clientConfig.readOnly
 				}
-				args, env := cg.cp.argsAndEnv(cg.serverConfig, readOnly, targetConfig)
+				args, env, err := cg.cp.argsAndEnv(cg.serverConfig, readOnly, targetConfig)
+				if err != nil {
+					_ = cleanup(ctx)
+					return nil, err
+				}
 
-				command := expandEnvList(eval.EvaluateList(cg.serverConfig.Spec.Command, cg.serverConfig.Config), env)
-				if len(command) == 0 {
-					log("  - Running", imageBaseName(image), "with", args)
-				} else {
-					log("  - Running", imageBaseName(image), "with", args, "and command", command)
+				files, err := cg.cp.secretFiles(cg.serverConfig)
+				if err != nil {
+					_ = cleanup(ctx)
+					return nil, err
 				}
 
-				var runArgs []string
-				runArgs = append(runArgs, args...)
-				runArgs = append(runArgs, image)
-				runArgs = append(runArgs, command...)
+				spec := runtime.Spec{
+					Name:  cg.serverConfig.Name,
+					Args:  args[1:], // without `run`
+					Image: image,
+					Env:   env,
+					Files: files,
+				}
+				if process := cg.serverConfig.Spec.Process; process != nil {
+					spec.Command = expandEnvList(eval.EvaluateList(process.Command, cg.serverConfig.Config), env)
+					if process.WorkingDir != "" {
+						spec.Dir = expandEnv(fmt.Sprintf("%v", eval.Evaluate(process.WorkingDir, cg.serverConfig.Config)), env)
+					}
+					log("  - Running process", spec.Command, "with", args)
+				} else {
+					spec.Command = expandEnvList(eval.EvaluateList(cg.serverConfig.Spec.Command, cg.serverConfig.Config), env)
+					if len(spec.Command) == 0 {
+						log("  - Running", imageBaseName(image), "with", args)
+					} else {
+						log("  - Running", imageBaseName(image), "with", args, "and command", spec.Command)
+					}
+				}
 
-				client = mcpclient.NewStdioCmdClient(cg.serverConfig.Name, "docker", env, runArgs...)
+				client = mcpclient.NewRuntimeClient(cg.serverConfig.Name, rt, spec)
 			}
 
 			initParams := &mcp.InitializeParams{
@@ -433,6 +742,9 @@ func (cg *clientGetter) GetClient(ctx context.Context) (mcpclient.Client, error)
 
 			// TODO add initial roots
 			if err := client.Initialize(ctx, initParams, cg.cp.Verbose, ss, server); err != nil {
+				if err := cleanup(context.WithoutCancel(ctx)); err != nil {
+					logf("  - Cleanup of %s failed: %s", cg.serverConfig.Name, err)
+				}
 				return nil, err
 			}
 
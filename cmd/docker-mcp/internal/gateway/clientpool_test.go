@@ -3,6 +3,7 @@ package gateway
 import (
 	"context"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,6 +16,9 @@ import (
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/gateway/proxies"
+	mcpclient "github.com/docker/mcp-gateway/cmd/docker-mcp/internal/mcp"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/runtime"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/secretpolicy"
 )
 
 func TestApplyConfigGrafana(t *testing.T) {
@@ -160,6 +164,260 @@ user: "1001:2002"
 	assert.Empty(t, env)
 }
 
+func TestApplyConfigSecretFiles(t *testing.T) {
+	catalogYAML := `
+secrets:
+  - name: postgres.password
+    file: /run/secrets/pgpass
+    mode: 0440
+    uid: 999
+  - name: postgres.url
+    env: DATABASE_URL
+  - name: postgres.cert
+    file: /certs/client.key
+  - name: postgres.missing
+    file: /run/secrets/missing
+  `
+	serverConfig := &catalog.ServerConfig{
+		Name: "postgres",
+		Spec: parseSpec(t, catalogYAML),
+		Secrets: map[string]string{
+			"postgres.password": "s3cr3t",
+			"postgres.url":      "postgres://db",
+			"postgres.cert":     "KEY",
+		},
+	}
+
+	clientPool := &clientPool{}
+	args, env, err := clientPool.argsAndEnv(serverConfig, nil, proxies.TargetConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-e", "DATABASE_URL"}, args[len(args)-2:])
+	assert.Equal(t, []string{"DATABASE_URL=postgres://db"}, env)
+
+	_, err = clientPool.secretFiles(serverConfig)
+	require.EqualError(t, err, "server postgres: secret postgres.missing is not set")
+
+	// Until the client gives the missing values, they are left out.
+	clientPool.MissingValues = missingValuesElicit
+	files, err := clientPool.secretFiles(serverConfig)
+	require.NoError(t, err)
+	assert.Equal(t, []runtime.File{
+		{Path: "/run/secrets/pgpass", Content: []byte("s3cr3t"), Mode: 0o440, UID: 999},
+		{Path: "/certs/client.key", Content: []byte("KEY"), Mode: 0o400},
+	}, files)
+}
+
+func TestApplyConfigMissingSecret(t *testing.T) {
+	serverConfig := &catalog.ServerConfig{
+		Name: "github",
+		Spec: parseSpec(t, `
+secrets:
+  - name: github.token
+    env: GITHUB_TOKEN
+`),
+	}
+
+	clientPool := &clientPool{}
+	_, _, err := clientPool.argsAndEnv(serverConfig, nil, proxies.TargetConfig{})
+	require.EqualError(t, err, "server github: secret github.token is not set")
+
+	clientPool.MissingValues = missingValuesElicit
+	_, env, err := clientPool.argsAndEnv(serverConfig, nil, proxies.TargetConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GITHUB_TOKEN=<UNKNOWN>"}, env)
+}
+
+func TestApplyConfigSecretPolicy(t *testing.T) {
+	serverConfig := &catalog.ServerConfig{
+		Name: "notes",
+		Spec: parseSpec(t, `
+secrets:
+  - name: github.token
+    env: GITHUB_TOKEN
+  - name: notes.key
+    file: /run/secrets/key
+`),
+		Secrets: map[string]string{"github.token": "s3cr3t", "notes.key": "key"},
+	}
+
+	policy, err := secretpolicy.Parse("github.token allows github\nnotes.key allows notes")
+	require.NoError(t, err)
+	clientPool := &clientPool{}
+	clientPool.SetSecretPolicy(policy)
+
+	_, _, err = clientPool.argsAndEnv(serverConfig, nil, proxies.TargetConfig{})
+	require.EqualError(t, err, "server notes: the secret policy doesn't allow it to receive secret github.token")
+	files, err := clientPool.secretFiles(serverConfig)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	policy, err = secretpolicy.Parse("github.token allows notes")
+	require.NoError(t, err)
+	clientPool.SetSecretPolicy(policy)
+	_, _, err = clientPool.argsAndEnv(serverConfig, nil, proxies.TargetConfig{})
+	require.NoError(t, err)
+	_, err = clientPool.secretFiles(serverConfig)
+	require.EqualError(t, err, "server notes: the secret policy doesn't allow it to receive secret notes.key")
+}
+
+func TestSecretFileMode(t *testing.T) {
+	mode, err := secretFileMode(catalog.Secret{Name: "token", File: "/run/secrets/token", Mode: "0o600"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0o600), mode)
+
+	_, err = secretFileMode(catalog.Secret{Name: "token", File: "/run/secrets/token", Mode: "rw"})
+	require.EqualError(t, err, `secret token: invalid file mode "rw"`)
+	_, err = secretFileMode(catalog.Secret{Name: "token", File: "/run/secrets/token", Mode: "1777"})
+	require.EqualError(t, err, `secret token: invalid file mode "1777"`)
+	_, err = secretFileMode(catalog.Secret{Name: "token", File: "secrets/token"})
+	require.EqualError(t, err, `secret token: file "secrets/token" must be an absolute path, in a directory`)
+	_, err = secretFileMode(catalog.Secret{Name: "token", File: "/token"})
+	require.EqualError(t, err, `secret token: file "/token" must be an absolute path, in a directory`)
+}
+
+func TestApplyConfigMountPolicy(t *testing.T) {
+	clientPool := &clientPool{
+		Options: Options{
+			MountDeny:     []string{"/var/run"},
+			MountReadOnly: []string{"/local/shared"},
+		},
+	}
+
+	args, _, err := clientPool.argsAndEnv(&catalog.ServerConfig{
+		Name: "fs",
+		Spec: parseSpec(t, `
+volumes:
+  - /local/shared/docs:/docs
+  - data:/data
+`),
+	}, nil, proxies.TargetConfig{})
+	require.NoError(t, err)
+	assert.Contains(t, args, "/local/shared/docs:/docs:ro")
+	assert.Contains(t, args, "data:/data")
+
+	_, _, err = clientPool.argsAndEnv(&catalog.ServerConfig{
+		Name: "docker",
+		Spec: parseSpec(t, `
+volumes:
+  - /var/run/docker.sock:/var/run/docker.sock
+`),
+	}, nil, proxies.TargetConfig{})
+	require.ErrorContains(t, err, `server docker: mount "/var/run/docker.sock:/var/run/docker.sock" is not allowed`)
+}
+
+func TestNetworkEgress(t *testing.T) {
+	clientPool := &clientPool{
+		Options: Options{
+			BlockNetwork:        true,
+			UnrestrictedNetwork: []string{"trusted"},
+		},
+		networks: []string{"gateway"},
+	}
+
+	assert.Equal(t, egressNone, clientPool.networkEgress("fetch", &catalog.Server{}))
+	assert.Equal(t, egressAllowHosts, clientPool.networkEgress("github", &catalog.Server{AllowHosts: []string{"api.github.com:443"}}))
+	assert.Equal(t, egressUnrestricted, clientPool.networkEgress("trusted", &catalog.Server{}))
+	assert.Equal(t, egressNone, clientPool.networkEgress("trusted", &catalog.Server{DisableNetwork: true}))
+
+	// POCI tools are trusted by the name of their server, or by `server:tool`.
+	clientPool.UnrestrictedNetwork = append(clientPool.UnrestrictedNetwork, "tools:curl")
+	assert.Equal(t, egressUnrestricted, clientPool.toolEgress("trusted", "curl"))
+	assert.Equal(t, egressUnrestricted, clientPool.toolEgress("tools", "curl"))
+	assert.Equal(t, egressNone, clientPool.toolEgress("tools", "wget"))
+	assert.Equal(t, egressNone, clientPool.toolEgress("other", "curl"))
+
+	args, _, err := clientPool.argsAndEnv(&catalog.ServerConfig{Name: "fetch"}, nil, proxies.TargetConfig{})
+	require.NoError(t, err)
+	assert.Contains(t, args, "none")
+	assert.NotContains(t, args, "gateway")
+
+	args, _, err = clientPool.argsAndEnv(&catalog.ServerConfig{Name: "trusted"}, nil, proxies.TargetConfig{})
+	require.NoError(t, err)
+	assert.Contains(t, args, "gateway")
+
+	clientPool.BlockNetwork = false
+	assert.Equal(t, egressUnrestricted, clientPool.networkEgress("fetch", &catalog.Server{}))
+}
+
+// closingClient is a started client that counts how many times it's closed.
+type closingClient struct {
+	mcpclient.Client
+	closed atomic.Int32
+}
+
+func (c *closingClient) Close() error {
+	c.closed.Add(1)
+	return nil
+}
+
+// keepClient adds a long-lived client that's already started.
+func keepClient(cp *clientPool, serverConfig *catalog.ServerConfig, config *clientConfig) *closingClient {
+	client := &closingClient{}
+	getter := newClientGetter(serverConfig, cp, config)
+	getter.once.Do(func() { getter.client = client })
+	cp.keptClients[clientKey{serverName: serverConfig.Name, session: config.serverSession}] = keptClient{
+		Name:         serverConfig.Name,
+		Getter:       getter,
+		Config:       serverConfig,
+		ClientConfig: config,
+	}
+	return client
+}
+
+func TestRestartServers(t *testing.T) {
+	cp := newClientPool(Options{LongLived: true}, nil)
+	config := &clientConfig{serverSession: &mcp.ServerSession{}}
+	github := &catalog.ServerConfig{Name: "github", Spec: catalog.Server{Image: "mcp/github"}}
+	githubClient := keepClient(cp, github, config)
+	fetchClient := keepClient(cp, &catalog.ServerConfig{Name: "fetch", Spec: catalog.Server{Image: "mcp/fetch"}}, config)
+
+	// A call is in flight when the server is restarted.
+	client, err := cp.AcquireClient(t.Context(), github, config)
+	require.NoError(t, err)
+	require.Same(t, githubClient, client)
+	cp.RestartServers([]string{"github"})
+
+	cp.clientLock.RLock()
+	assert.Len(t, cp.keptClients, 1)
+	cp.clientLock.RUnlock()
+	time.Sleep(50 * time.Millisecond)
+	assert.Zero(t, githubClient.closed.Load())
+
+	// It's closed once the call is done.
+	cp.ReleaseClient(client)
+	assert.Eventually(t, func() bool {
+		cp.clientLock.RLock()
+		defer cp.clientLock.RUnlock()
+		return len(cp.draining) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), githubClient.closed.Load())
+	assert.Zero(t, fetchClient.closed.Load())
+}
+
+func TestRestartServersDrainTimeout(t *testing.T) {
+	drainTimeout = 10 * time.Millisecond
+	defer func() { drainTimeout = 30 * time.Second }()
+
+	cp := newClientPool(Options{LongLived: true}, nil)
+	config := &clientConfig{serverSession: &mcp.ServerSession{}}
+	github := &catalog.ServerConfig{Name: "github", Spec: catalog.Server{Image: "mcp/github"}}
+	githubClient := keepClient(cp, github, config)
+
+	client, err := cp.AcquireClient(t.Context(), github, config)
+	require.NoError(t, err)
+	cp.RestartServers([]string{"github"})
+
+	// A call that doesn't complete doesn't keep the server running.
+	assert.Eventually(t, func() bool { return githubClient.closed.Load() == 1 }, time.Second, 10*time.Millisecond)
+	cp.ReleaseClient(client)
+	assert.Eventually(t, func() bool {
+		cp.clientLock.RLock()
+		defer cp.clientLock.RUnlock()
+		return len(cp.draining) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), githubClient.closed.Load())
+}
+
 func argsAndEnv(t *testing.T, name, catalogYAML, configYAML string, secrets map[string]string, readOnly *bool) ([]string, []string) {
 	t.Helper()
 
@@ -169,12 +427,14 @@ func argsAndEnv(t *testing.T, name, catalogYAML, configYAML string, secrets map[
 			Memory: "2Gb",
 		},
 	}
-	return clientPool.argsAndEnv(&catalog.ServerConfig{
+	args, env, err := clientPool.argsAndEnv(&catalog.ServerConfig{
 		Name:    name,
 		Spec:    parseSpec(t, catalogYAML),
 		Config:  parseConfig(t, configYAML),
 		Secrets: secrets,
 	}, readOnly, proxies.TargetConfig{})
+	require.NoError(t, err)
+	return args, env
 }
 
 func parseSpec(t *testing.T, contentYAML string) catalog.Server {
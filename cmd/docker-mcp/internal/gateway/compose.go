@@ -0,0 +1,421 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+)
+
+const (
+	// bridgePath is where docker-mcp-bridge is found in the server containers,
+	// that mount the gateway image.
+	bridgePath = "/docker-mcp/misc/docker-mcp-bridge"
+
+	// bridgeHTTPPort is where docker-mcp-bridge serves streamable HTTP.
+	bridgeHTTPPort = 8080
+
+	// composeCatalogPath is where the gateway service finds its catalog.
+	composeCatalogPath = "/mcp/catalog.yaml"
+)
+
+// ComposeOptions configures the generated compose project.
+type ComposeOptions struct {
+	// GatewayImage is the image of the gateway service. Its docker-mcp-bridge
+	// is mounted in the server containers.
+	GatewayImage string
+}
+
+// Compose writes a compose project that runs the gateway in static mode,
+// with every enabled server pre-started behind docker-mcp-bridge. Secrets are
+// declared as compose secrets, read from environment variables, and never
+// inlined.
+func (g *Gateway) Compose(ctx context.Context, w io.Writer, options ComposeOptions) error {
+	d, err := g.deployment(ctx)
+	if err != nil {
+		return err
+	}
+
+	project, err := g.composeProject(d, options)
+	if err != nil {
+		return err
+	}
+
+	buf, err := marshalYAML(project)
+	if err != nil {
+		return err
+	}
+
+	// Nothing we generate is meant to be interpolated by compose.
+	out := "# Generated by `docker mcp gateway compose`.\n" + strings.ReplaceAll(string(buf), "$", "$$")
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+// marshalYAML marshals with the two spaces indentation of compose files.
+func marshalYAML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type composeProject struct {
+	Services map[string]*composeService `yaml:"services"`
+	Networks map[string]*composeNetwork `yaml:"networks,omitempty"`
+	Volumes  map[string]*composeVolume  `yaml:"volumes,omitempty"`
+	Secrets  map[string]*composeSecret  `yaml:"secrets,omitempty"`
+	Configs  map[string]*composeConfig  `yaml:"configs,omitempty"`
+}
+
+type composeService struct {
+	Image       string                   `yaml:"image"`
+	Entrypoint  []string                 `yaml:"entrypoint,omitempty"`
+	Command     []string                 `yaml:"command,omitempty"`
+	Ports       []string                 `yaml:"ports,omitempty"`
+	Environment map[string]string        `yaml:"environment,omitempty"`
+	User        string                   `yaml:"user,omitempty"`
+	Init        bool                     `yaml:"init,omitempty"`
+	ReadOnly    bool                     `yaml:"read_only,omitempty"`
+	Privileged  bool                     `yaml:"privileged,omitempty"`
+	Runtime     string                   `yaml:"runtime,omitempty"`
+	Cpus        float64                  `yaml:"cpus,omitempty"`
+	MemLimit    int64                    `yaml:"mem_limit,omitempty"`
+	PidsLimit   int64                    `yaml:"pids_limit,omitempty"`
+	CapAdd      []string                 `yaml:"cap_add,omitempty"`
+	CapDrop     []string                 `yaml:"cap_drop,omitempty"`
+	SecurityOpt []string                 `yaml:"security_opt,omitempty"`
+	Tmpfs       []string                 `yaml:"tmpfs,omitempty"`
+	Ulimits     map[string]composeUlimit `yaml:"ulimits,omitempty"`
+	Labels      []string                 `yaml:"labels,omitempty"`
+	Volumes     []any                    `yaml:"volumes,omitempty"`
+	Secrets     []any                    `yaml:"secrets,omitempty"`
+	Configs     []composeFileMount       `yaml:"configs,omitempty"`
+	Networks    composeServiceNetworks   `yaml:"networks,omitempty"`
+	DependsOn   []string                 `yaml:"depends_on,omitempty"`
+}
+
+type composeUlimit struct {
+	Soft int64 `yaml:"soft"`
+	Hard int64 `yaml:"hard"`
+}
+
+type composeImageMount struct {
+	Type   string `yaml:"type"`
+	Source string `yaml:"source"`
+	Target string `yaml:"target"`
+}
+
+type composeFileMount struct {
+	Source string `yaml:"source"`
+	Target string `yaml:"target"`
+}
+
+// composeSecretMount mounts a secret at a given path, for the secrets that
+// are files rather than environment variables.
+type composeSecretMount struct {
+	Source string    `yaml:"source"`
+	Target string    `yaml:"target"`
+	UID    string    `yaml:"uid,omitempty"`
+	Mode   octalMode `yaml:"mode"`
+}
+
+// octalMode is a file mode, written in octal.
+type octalMode int64
+
+func (m octalMode) MarshalYAML() (any, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprintf("0%o", int64(m))}, nil
+}
+
+// composeServiceNetworks are the networks of a service, with their aliases.
+// They are written as a list unless there are aliases.
+type composeServiceNetworks map[string][]string
+
+func (n composeServiceNetworks) MarshalYAML() (any, error) {
+	names := slices.Sorted(maps.Keys(n))
+	for _, aliases := range n {
+		if len(aliases) > 0 {
+			networks := map[string]any{}
+			for _, name := range names {
+				var aliases any
+				if len(n[name]) > 0 {
+					aliases = map[string][]string{"aliases": n[name]}
+				}
+				networks[name] = aliases
+			}
+			return networks, nil
+		}
+	}
+	return names, nil
+}
+
+type composeNetwork struct {
+	Internal bool `yaml:"internal,omitempty"`
+}
+
+type composeVolume struct {
+	External bool `yaml:"external"`
+}
+
+type composeSecret struct {
+	Environment string `yaml:"environment"`
+}
+
+type composeConfig struct {
+	Content string `yaml:"content"`
+}
+
+func (g *Gateway) composeProject(d deployment, options ComposeOptions) (*composeProject, error) {
+	project := &composeProject{
+		Services: map[string]*composeService{},
+		Networks: map[string]*composeNetwork{},
+		Volumes:  map[string]*composeVolume{},
+		Secrets:  map[string]*composeSecret{},
+		Configs:  map[string]*composeConfig{},
+	}
+
+	catalogContent, err := marshalYAML(map[string]map[string]catalog.Server{"registry": d.Catalog})
+	if err != nil {
+		return nil, err
+	}
+	project.Configs["catalog"] = &composeConfig{Content: string(catalogContent)}
+
+	port := g.Port
+	if port == 0 {
+		port = 8811
+	}
+	gateway := &composeService{
+		Image: options.GatewayImage,
+		Command: []string{
+			"--transport=streaming",
+			"--port=" + strconv.Itoa(port),
+			"--static=true",
+			"--servers=" + strings.Join(d.ServerNames, ","),
+			"--catalog=" + composeCatalogPath,
+		},
+		Ports:   []string{fmt.Sprintf("%d:%d", port, port)},
+		Configs: []composeFileMount{{Source: "catalog", Target: composeCatalogPath}},
+	}
+	// The servers are reached by their service name. Only POCI tools need
+	// the Docker API, that gives root access to the host.
+	if slices.ContainsFunc(d.ServerNames, func(serverName string) bool { return len(d.Catalog[serverName].Tools) > 0 }) {
+		gateway.Volumes = []any{"/var/run/docker.sock:/var/run/docker.sock"}
+	}
+	if g.Hardening != "" {
+		gateway.Command = append(gateway.Command, "--hardening="+g.Hardening)
+	}
+	if g.BlockNetwork {
+		gateway.Command = append(gateway.Command, "--block-network")
+	}
+	if g.ReadOnly {
+		gateway.Command = append(gateway.Command, "--read-only")
+	}
+	project.Services["gateway"] = gateway
+
+	var internalNetworks []string
+	for _, server := range d.Servers {
+		serviceName := composeName(server.Name)
+		service, err := composeServerService(server, options)
+		if err != nil {
+			return nil, fmt.Errorf("server %s: %w", server.Name, err)
+		}
+		project.Services[serviceName] = service
+		gateway.DependsOn = append(gateway.DependsOn, serviceName)
+
+		for _, secret := range server.Secrets {
+			project.Secrets[composeSecretName(secret.Name)] = &composeSecret{Environment: secretEnvName(secret.Name)}
+		}
+		for _, volume := range server.HostConfig.Binds {
+			if source, _, _ := strings.Cut(volume, ":"); isNamedVolume(source) {
+				project.Volumes[source] = &composeVolume{External: true}
+			}
+		}
+
+		// Servers with a restricted egress are alone on their own internal
+		// network, with the gateway and their proxies.
+		if server.Egress == egressUnrestricted {
+			continue
+		}
+		networkName := serviceName
+		project.Networks[networkName] = &composeNetwork{Internal: true}
+		service.Networks = composeServiceNetworks{networkName: nil}
+		internalNetworks = append(internalNetworks, networkName)
+
+		if server.Sidecar != nil {
+			proxyName := serviceName + "-proxy"
+			proxy := &composeService{
+				Image:       server.Sidecar.Image,
+				Environment: envMap(server.Sidecar.Env),
+				Networks: composeServiceNetworks{
+					"default":   nil,
+					networkName: server.Sidecar.Hosts,
+				},
+			}
+			project.Services[proxyName] = proxy
+
+			if server.Sidecar.HTTPPort != 0 {
+				httpProxy := proxyName + ":" + strconv.Itoa(server.Sidecar.HTTPPort)
+				service.Environment["http_proxy"] = httpProxy
+				service.Environment["https_proxy"] = httpProxy
+			}
+			service.DependsOn = append(service.DependsOn, proxyName)
+		}
+	}
+
+	if len(internalNetworks) > 0 {
+		gateway.Networks = composeServiceNetworks{"default": nil}
+		for _, networkName := range internalNetworks {
+			gateway.Networks[networkName] = nil
+		}
+	}
+
+	return project, nil
+}
+
+// composeServerService runs a server through docker-mcp-bridge. The gateway
+// reaches it over TCP, by its service name, or over streamable HTTP, with a
+// process per session, when it can find its container from its labels.
+func composeServerService(server deployedServer, options ComposeOptions) (*composeService, error) {
+	config, hostConfig := server.Config, server.HostConfig
+
+	entrypoint := []string{bridgePath, "-http-addr=:" + strconv.Itoa(bridgeHTTPPort)}
+	var secrets []any
+	for _, secret := range server.Secrets {
+		name := composeSecretName(secret.Name)
+		if secret.File != "" {
+			mode, err := secretFileMode(secret)
+			if err != nil {
+				return nil, err
+			}
+			mount := composeSecretMount{Source: name, Target: secret.File, Mode: octalMode(mode)}
+			if secret.UID != 0 {
+				mount.UID = strconv.Itoa(secret.UID)
+			}
+			secrets = append(secrets, mount)
+			continue
+		}
+		entrypoint = append(entrypoint, "-secret", secret.Env+"=/run/secrets/"+name)
+		if !slices.Contains(secrets, any(name)) {
+			secrets = append(secrets, name)
+		}
+	}
+	entrypoint = append(entrypoint, "--")
+	entrypoint = append(entrypoint, server.Command...)
+
+	labels := maps.Clone(config.Labels)
+	labels[labelTransport] = "streamable"
+	labels[labelPort] = strconv.Itoa(bridgeHTTPPort)
+
+	service := &composeService{
+		Image:       server.Image,
+		Entrypoint:  entrypoint,
+		Environment: envMap(config.Env),
+		User:        config.User,
+		Init:        hostConfig.Init != nil && *hostConfig.Init,
+		ReadOnly:    hostConfig.ReadonlyRootfs,
+		Privileged:  hostConfig.Privileged,
+		Runtime:     hostConfig.Runtime,
+		Cpus:        float64(hostConfig.NanoCPUs) / 1e9,
+		MemLimit:    hostConfig.Memory,
+		CapAdd:      hostConfig.CapAdd,
+		CapDrop:     hostConfig.CapDrop,
+		SecurityOpt: hostConfig.SecurityOpt,
+		Labels:      sortedLabels(labels),
+		Volumes: []any{composeImageMount{
+			Type:   "image",
+			Source: options.GatewayImage,
+			Target: "/docker-mcp",
+		}},
+		Secrets: secrets,
+	}
+	if hostConfig.PidsLimit != nil {
+		service.PidsLimit = *hostConfig.PidsLimit
+	}
+	for _, path := range slices.Sorted(maps.Keys(hostConfig.Tmpfs)) {
+		tmpfs := path
+		if options := hostConfig.Tmpfs[path]; options != "" {
+			tmpfs += ":" + options
+		}
+		service.Tmpfs = append(service.Tmpfs, tmpfs)
+	}
+	for _, ulimit := range hostConfig.Ulimits {
+		if service.Ulimits == nil {
+			service.Ulimits = map[string]composeUlimit{}
+		}
+		service.Ulimits[ulimit.Name] = composeUlimit{Soft: ulimit.Soft, Hard: ulimit.Hard}
+	}
+	for _, volume := range hostConfig.Binds {
+		service.Volumes = append(service.Volumes, volume)
+	}
+
+	return service, nil
+}
+
+// composeName turns a server name into a valid service or network name.
+func composeName(serverName string) string {
+	var sb strings.Builder
+	sb.WriteString("mcp-")
+	for _, c := range strings.ToLower(serverName) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteRune('-')
+		}
+	}
+	return sb.String()
+}
+
+// composeSecretName turns a secret name, like github.personal_access_token,
+// into a valid compose secret name.
+func composeSecretName(secretName string) string {
+	var sb strings.Builder
+	for _, c := range strings.ToLower(secretName) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	return sb.String()
+}
+
+// secretEnvName is the environment variable compose reads a secret from, for
+// example GITHUB_PERSONAL_ACCESS_TOKEN.
+func secretEnvName(secretName string) string {
+	return strings.ToUpper(composeSecretName(secretName))
+}
+
+func isNamedVolume(source string) bool {
+	return source != "" && !strings.HasPrefix(source, "/") && !strings.HasPrefix(source, ".") && !strings.HasPrefix(source, "~") && !strings.Contains(source, `\`)
+}
+
+func envMap(env []string) map[string]string {
+	m := map[string]string{}
+	for _, e := range env {
+		name, value, _ := strings.Cut(e, "=")
+		m[name] = value
+	}
+	return m
+}
+
+func sortedLabels(labels map[string]string) []string {
+	var list []string
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		list = append(list, key+"="+labels[key])
+	}
+	return list
+}
@@ -0,0 +1,240 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/docker/docker/api/types/image"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+)
+
+// imagesDocker knows the entrypoint and command of a few images.
+type imagesDocker struct {
+	docker.Client
+	images map[string]ocispec.ImageConfig
+}
+
+func (d *imagesDocker) PullImages(context.Context, ...string) error {
+	return nil
+}
+
+func (d *imagesDocker) InspectImage(_ context.Context, name string) (image.InspectResponse, error) {
+	return image.InspectResponse{Config: &dockerspec.DockerOCIImageConfig{ImageConfig: d.images[name]}}, nil
+}
+
+type staticConfigurator struct {
+	configuration Configuration
+}
+
+func (c *staticConfigurator) Read(context.Context) (Configuration, chan Configuration, func() error, error) {
+	return c.configuration, nil, func() error { return nil }, nil
+}
+
+func composeGateway(t *testing.T, options Options, servers map[string]catalog.Server, config map[string]map[string]any) *Gateway {
+	t.Helper()
+
+	var serverNames []string
+	for name := range servers {
+		serverNames = append(serverNames, name)
+	}
+	slices.Sort(serverNames)
+
+	g := NewGateway(Config{Options: options}, &imagesDocker{
+		images: map[string]ocispec.ImageConfig{
+			"mcp/fetch":    {Entrypoint: []string{"mcp-server-fetch"}},
+			"mcp/github":   {Entrypoint: []string{"/server/github-mcp-server"}, Cmd: []string{"stdio"}},
+			"mcp/notes":    {Cmd: []string{"python", "-m", "notes"}},
+			"mcp/postgres": {Entrypoint: []string{"postgres-mcp"}},
+		},
+	})
+	g.configurator = &staticConfigurator{Configuration{
+		serverNames: serverNames,
+		servers:     servers,
+		config:      config,
+		secrets:     map[string]string{"github.token": "s3cr3t"},
+	}}
+	return g
+}
+
+func TestComposeProject(t *testing.T) {
+	g := composeGateway(t, Options{Cpus: 1, Memory: "2Gb", Hardening: hardeningStrict, BlockNetwork: true, UnrestrictedNetwork: []string{"fetch"}}, map[string]catalog.Server{
+		"fetch": {Image: "mcp/fetch"},
+		"github": {
+			Image:      "mcp/github",
+			Secrets:    []catalog.Secret{{Name: "github.token", Env: "GITHUB_TOKEN"}},
+			Env:        []catalog.Env{{Name: "GITHUB_HOST", Value: "{{github.host}}"}},
+			AllowHosts: []string{"api.github.com:443", "db.internal:5432/tcp"},
+		},
+		"notes": {
+			Image:   "mcp/notes",
+			Command: []string{"--dir", "/notes"},
+			Volumes: []string{"notes-data:/notes"},
+		},
+		"remote": {Remote: catalog.Remote{URL: "https://example.com/mcp"}},
+	}, map[string]map[string]any{
+		"github": {"host": "github.example.com"},
+	})
+
+	d, err := g.deployment(t.Context())
+	require.NoError(t, err)
+	project, err := g.composeProject(d, ComposeOptions{GatewayImage: "docker/mcp-gateway"})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"gateway", "mcp-fetch", "mcp-github", "mcp-github-proxy", "mcp-notes"}, keys(project.Services))
+
+	gateway := project.Services["gateway"]
+	assert.Contains(t, gateway.Command, "--static=true")
+	assert.Contains(t, gateway.Command, "--catalog=/mcp/catalog.yaml")
+	assert.Equal(t, []string{"mcp-fetch", "mcp-github", "mcp-notes"}, gateway.DependsOn)
+	assert.Equal(t, composeServiceNetworks{"default": nil, "mcp-github": nil, "mcp-notes": nil}, gateway.Networks)
+	assert.Contains(t, project.Configs["catalog"].Content, "https://example.com/mcp")
+	assert.Empty(t, gateway.Volumes)
+
+	// Unrestricted servers stay on the default network.
+	fetch := project.Services["mcp-fetch"]
+	assert.Equal(t, []string{bridgePath, "-http-addr=:8080", "--", "mcp-server-fetch"}, fetch.Entrypoint)
+	assert.Empty(t, fetch.Networks)
+	assert.Contains(t, fetch.Labels, "docker-mcp-transport=streamable")
+	assert.Contains(t, fetch.Labels, "docker-mcp-port=8080")
+	assert.True(t, fetch.ReadOnly)
+	assert.Equal(t, []string{"ALL"}, fetch.CapDrop)
+	assert.InDelta(t, 1.0, fetch.Cpus, 0)
+	assert.Equal(t, int64(2*1024*1024*1024), fetch.MemLimit)
+
+	// Secrets are files read by the bridge, never values.
+	github := project.Services["mcp-github"]
+	assert.Equal(t, []string{bridgePath, "-http-addr=:8080", "-secret", "GITHUB_TOKEN=/run/secrets/github_token", "--", "/server/github-mcp-server", "stdio"}, github.Entrypoint)
+	assert.Equal(t, []any{"github_token"}, github.Secrets)
+	assert.Equal(t, &composeSecret{Environment: "GITHUB_TOKEN"}, project.Secrets["github_token"])
+	assert.NotContains(t, github.Environment, "GITHUB_TOKEN")
+	assert.Equal(t, "github.example.com", github.Environment["GITHUB_HOST"])
+
+	// Servers with allowHosts go through their proxy.
+	assert.Equal(t, "mcp-github-proxy:8080", github.Environment["https_proxy"])
+	assert.Equal(t, composeServiceNetworks{"mcp-github": nil}, github.Networks)
+	assert.True(t, project.Networks["mcp-github"].Internal)
+	proxy := project.Services["mcp-github-proxy"]
+	assert.Equal(t, composeServiceNetworks{"default": nil, "mcp-github": {"db.internal"}}, proxy.Networks)
+	assert.Contains(t, proxy.Environment["PROXY_CONFIG"], "api.github.com:443")
+
+	// Servers without allowHosts are cut off the network.
+	notes := project.Services["mcp-notes"]
+	assert.Equal(t, []string{bridgePath, "-http-addr=:8080", "--", "--dir", "/notes"}, notes.Entrypoint)
+	assert.Contains(t, notes.Volumes, "notes-data:/notes")
+	assert.Equal(t, &composeVolume{External: true}, project.Volumes["notes-data"])
+	assert.Equal(t, composeServiceNetworks{"mcp-notes": nil}, notes.Networks)
+}
+
+func TestComposeOutput(t *testing.T) {
+	g := composeGateway(t, Options{}, map[string]catalog.Server{
+		"fetch": {
+			Image: "mcp/fetch",
+			Env:   []catalog.Env{{Name: "PRICE", Value: "{{fetch.price}}"}},
+		},
+	}, map[string]map[string]any{
+		"fetch": {"price": "$5"},
+	})
+
+	var out bytes.Buffer
+	require.NoError(t, g.Compose(t.Context(), &out, ComposeOptions{GatewayImage: "docker/mcp-gateway"}))
+	assert.NotContains(t, out.String(), "s3cr3t")
+
+	var project map[string]any
+	require.NoError(t, yaml.Unmarshal(out.Bytes(), &project))
+	services := project["services"].(map[string]any)
+	fetch := services["mcp-fetch"].(map[string]any)
+
+	// Compose doesn't interpolate values.
+	assert.Equal(t, "$$5", fetch["environment"].(map[string]any)["PRICE"])
+	assert.Equal(t, []any{map[string]any{"type": "image", "source": "docker/mcp-gateway", "target": "/docker-mcp"}}, fetch["volumes"])
+}
+
+func TestComposePOCITools(t *testing.T) {
+	g := composeGateway(t, Options{}, map[string]catalog.Server{
+		"fetch": {Image: "mcp/fetch"},
+		"curl":  {Tools: []catalog.Tool{{Name: "curl", Container: catalog.Container{Image: "alpine/curl"}}}},
+	}, nil)
+
+	d, err := g.deployment(t.Context())
+	require.NoError(t, err)
+	project, err := g.composeProject(d, ComposeOptions{GatewayImage: "docker/mcp-gateway"})
+	require.NoError(t, err)
+
+	// Only POCI tools need the Docker API.
+	assert.Equal(t, []any{"/var/run/docker.sock:/var/run/docker.sock"}, project.Services["gateway"].Volumes)
+	assert.ElementsMatch(t, []string{"gateway", "mcp-fetch"}, keys(project.Services))
+}
+
+func TestComposeProcessServer(t *testing.T) {
+	g := composeGateway(t, Options{}, map[string]catalog.Server{
+		"local": {Process: &catalog.Process{Command: []string{"server"}}},
+	}, nil)
+
+	_, err := g.deployment(t.Context())
+	require.EqualError(t, err, "server local is a local process and can't be pre-started")
+}
+
+func keys[V any](m map[string]V) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestComposeSecretFiles(t *testing.T) {
+	g := composeGateway(t, Options{}, map[string]catalog.Server{
+		"postgres": {
+			Image: "mcp/postgres",
+			Secrets: []catalog.Secret{
+				{Name: "postgres.url", Env: "DATABASE_URL"},
+				{Name: "postgres.password", File: "/run/secrets/pgpass", Mode: "0440", UID: 999},
+			},
+		},
+	}, nil)
+
+	d, err := g.deployment(t.Context())
+	require.NoError(t, err)
+	project, err := g.composeProject(d, ComposeOptions{GatewayImage: "docker/mcp-gateway"})
+	require.NoError(t, err)
+
+	// Secret files are mounted where the server expects them, not read by
+	// the bridge.
+	postgres := project.Services["mcp-postgres"]
+	assert.Equal(t, []string{bridgePath, "-http-addr=:8080", "-secret", "DATABASE_URL=/run/secrets/postgres_url", "--", "postgres-mcp"}, postgres.Entrypoint)
+	assert.Equal(t, []any{"postgres_url", composeSecretMount{Source: "postgres_password", Target: "/run/secrets/pgpass", UID: "999", Mode: 0o440}}, postgres.Secrets)
+	assert.Equal(t, &composeSecret{Environment: "POSTGRES_PASSWORD"}, project.Secrets["postgres_password"])
+
+	out, err := marshalYAML(postgres.Secrets)
+	require.NoError(t, err)
+	assert.Equal(t, "- postgres_url\n- source: postgres_password\n  target: /run/secrets/pgpass\n  uid: \"999\"\n  mode: 0440\n", string(out))
+}
+
+func TestComposeInvalidSecretFile(t *testing.T) {
+	g := composeGateway(t, Options{}, map[string]catalog.Server{
+		"postgres": {
+			Image:   "mcp/postgres",
+			Secrets: []catalog.Secret{{Name: "postgres.password", File: "/run/secrets/pgpass", Mode: "999"}},
+		},
+	}, nil)
+
+	_, err := g.deployment(t.Context())
+	require.EqualError(t, err, `server postgres: secret postgres.password: invalid file mode "999"`)
+}
+
+func TestComposeServerServiceInvalidSecretFile(t *testing.T) {
+	_, err := composeServerService(deployedServer{
+		Name:    "postgres",
+		Secrets: []catalog.Secret{{Name: "postgres.password", File: "/run/secrets/pgpass", Mode: "rw"}},
+	}, ComposeOptions{})
+	require.EqualError(t, err, `secret postgres.password: invalid file mode "rw"`)
+}
@@ -1,5 +1,7 @@
 package gateway
 
+import "time"
+
 type Config struct {
 	Options
 	ServerNames  []string
@@ -8,6 +10,7 @@ type Config struct {
 	RegistryPath []string
 	ToolsPath    []string
 	SecretsPath  string
+	PolicyPath   string
 }
 
 type Options struct {
@@ -24,9 +27,27 @@ type Options struct {
 	VerifySignatures        bool
 	DryRun                  bool
 	Watch                   bool
+	SecretsRefresh          time.Duration
 	Cpus                    int
 	Memory                  string
 	Static                  bool
 	Central                 bool
 	OAuthInterceptorEnabled bool
+	ToolScan                string
+	ScanToolResults         bool
+	PinTools                string
+	DataFlowPolicy          string
+	DataFlowLabels          string
+	AuditLog                string
+	ReadOnly                bool
+	ReadOnlyTools           []string
+	ReadOnlyTool            bool
+	MountAllow              []string
+	MountDeny               []string
+	MountReadOnly           []string
+	AllowSocketMounts       bool
+	Hardening               string
+	UnrestrictedNetwork     []string
+	Runtime                 string
+	MissingValues           string
 }
@@ -1,11 +1,12 @@
 package gateway
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -15,6 +16,8 @@ import (
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/config"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/secretpolicy"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/secrets"
 )
 
 type Configurator interface {
@@ -27,13 +30,18 @@ type Configuration struct {
 	config      map[string]map[string]any
 	tools       config.ToolsConfig
 	secrets     map[string]string
+	// policy is the secret policy the gateway enforces, if any.
+	policy *secretpolicy.Policy
 }
 
 func (c *Configuration) ServerNames() []string {
 	return c.serverNames
 }
 
-func (c *Configuration) DockerImages() []string {
+// DockerImages lists the images of the servers and tools. If filter is not
+// nil, only the images of the servers it accepts are listed. Tools are
+// filtered as a server with an empty spec.
+func (c *Configuration) DockerImages(filter func(server *catalog.Server) bool) []string {
 	uniqueDockerImages := map[string]bool{}
 
 	for _, serverName := range c.serverNames {
@@ -43,8 +51,10 @@ func (c *Configuration) DockerImages() []string {
 		case !found:
 			log("MCP server not found:", serverName)
 		case serverConfig != nil && serverConfig.Spec.Image != "":
-			uniqueDockerImages[serverConfig.Spec.Image] = true
-		case tools != nil:
+			if filter == nil || filter(&serverConfig.Spec) {
+				uniqueDockerImages[serverConfig.Spec.Image] = true
+			}
+		case tools != nil && (filter == nil || filter(&catalog.Server{})):
 			for _, tool := range *tools {
 				uniqueDockerImages[tool.Container.Image] = true
 			}
@@ -69,14 +79,25 @@ func (c *Configuration) Find(serverName string) (*catalog.ServerConfig, *map[str
 	}
 
 	// Is it an MCP Server?
-	if server.Image != "" || server.SSEEndpoint != "" || server.Remote.URL != "" {
+	if server.Image != "" || server.Process != nil || server.SSEEndpoint != "" || server.Remote.URL != "" {
+		// With a secret policy, the server only gets the secrets it's allowed.
+		serverSecrets := c.secrets
+		if c.policy != nil {
+			serverSecrets = map[string]string{}
+			for _, s := range server.Secrets {
+				if value, found := c.secrets[s.Name]; found && c.policy.Allows(s.Name, serverName) {
+					serverSecrets[s.Name] = value
+				}
+			}
+		}
+
 		return &catalog.ServerConfig{
 			Name: serverName,
 			Spec: server,
 			Config: map[string]any{
 				serverName: c.config[serverName],
 			},
-			Secrets: c.secrets, // TODO: we could keep just the secrets for this server
+			Secrets: serverSecrets, // TODO: we could keep just the secrets for this server
 		}, nil, true
 	}
 
@@ -88,6 +109,45 @@ func (c *Configuration) Find(serverName string) (*catalog.ServerConfig, *map[str
 	return nil, &byName, true
 }
 
+// rotatedSecrets are the sorted names of the secrets whose value changed,
+// appeared or disappeared since a previous configuration.
+func (c *Configuration) rotatedSecrets(previous Configuration) []string {
+	var names []string
+	for name, value := range c.secrets {
+		if previousValue, found := previous.secrets[name]; !found || previousValue != value {
+			names = append(names, name)
+		}
+	}
+	for name := range previous.secrets {
+		if _, found := c.secrets[name]; !found {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// policyChanged tells whether the secret policy was set, changed or removed
+// since a previous configuration.
+func (c *Configuration) policyChanged(previous Configuration) bool {
+	return (c.policy == nil) != (previous.policy == nil) || c.policy.String() != previous.policy.String()
+}
+
+// serversUsingSecrets are the enabled servers that use any of the secrets.
+func (c *Configuration) serversUsingSecrets(secretNames []string) []string {
+	var serverNames []string
+	for _, serverName := range c.serverNames {
+		server, found := c.servers[serverName]
+		if !found {
+			continue
+		}
+		if slices.ContainsFunc(server.Secrets, func(s catalog.Secret) bool { return slices.Contains(secretNames, s.Name) }) {
+			serverNames = append(serverNames, serverName)
+		}
+	}
+	return serverNames
+}
+
 type FileBasedConfiguration struct {
 	CatalogPath  []string
 	ServerNames  []string // Takes precedence over the RegistryPath
@@ -95,9 +155,14 @@ type FileBasedConfiguration struct {
 	ConfigPath   []string
 	ToolsPath    []string
 	SecretsPath  string // Optional, if not set, use Docker Desktop's secrets API
+	PolicyPath   string // Optional, the secret policy to enforce where Docker Desktop doesn't
 	Watch        bool
 	Central      bool
 
+	// SecretsRefresh is how often, when watching, the secrets are read again
+	// to notice the rotations of the providers that aren't files.
+	SecretsRefresh time.Duration
+
 	docker docker.Client
 }
 
@@ -134,6 +199,15 @@ func (c *FileBasedConfiguration) Read(ctx context.Context) (Configuration, chan
 		}
 	}
 
+	var policyPath string
+	if c.PolicyPath != "" {
+		policyPath, err = config.FilePath(c.PolicyPath)
+		if err != nil {
+			return Configuration{}, nil, nil, err
+		}
+		policyPath = filepath.Clean(policyPath)
+	}
+
 	var toolsPaths []string
 	for _, path := range c.ToolsPath {
 		if path != "" {
@@ -145,6 +219,31 @@ func (c *FileBasedConfiguration) Read(ctx context.Context) (Configuration, chan
 		}
 	}
 
+	// Secrets files are watched through their directory, to notice the files
+	// that are replaced rather than written, like Kubernetes Secrets.
+	var secretFiles, secretDirs []string
+	chain, err := secrets.Parse(c.SecretsPath, c.docker)
+	if err != nil {
+		return Configuration{}, nil, nil, err
+	}
+	for _, path := range chain.Paths() {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return Configuration{}, nil, nil, err
+		}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			secretDirs = append(secretDirs, path)
+		} else {
+			secretFiles = append(secretFiles, path)
+		}
+	}
+
+	// Only the events of those files matter, not the rest of the directories.
+	relevant := func(name string) bool {
+		return slices.Contains(registryPaths, name) || slices.Contains(configPaths, name) || slices.Contains(toolsPaths, name) || name == policyPath ||
+			slices.Contains(secretFiles, name) || slices.Contains(secretDirs, filepath.Dir(name)) || slices.Contains(secretDirs, name)
+	}
+
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return Configuration{}, nil, nil, err
@@ -152,12 +251,23 @@ func (c *FileBasedConfiguration) Read(ctx context.Context) (Configuration, chan
 
 	updates := make(chan Configuration)
 	go func() {
+		var refresh <-chan time.Time
+		if c.SecretsRefresh > 0 {
+			ticker := time.NewTicker(c.SecretsRefresh)
+			defer ticker.Stop()
+			refresh = ticker.C
+		}
+
+		last := configuration
 		for {
 			select {
-			case _, ok := <-watcher.Events:
+			case event, ok := <-watcher.Events:
 				if !ok {
 					return
 				}
+				if !relevant(event.Name) {
+					continue
+				}
 
 				// Debounce: drain any additional events to avoid rapid reloads
 			debounce:
@@ -175,8 +285,22 @@ func (c *FileBasedConfiguration) Read(ctx context.Context) (Configuration, chan
 					continue
 				}
 
+				last = configuration
 				updates <- configuration
 
+			case <-refresh:
+				secrets, err := c.readSecrets(ctx, secretNames(last.servers, last.serverNames, last.policy))
+				if err != nil {
+					log("Error reading secrets:", err)
+					continue
+				}
+				if maps.Equal(secrets, last.secrets) {
+					continue
+				}
+
+				last.secrets = secrets
+				updates <- last
+
 			case <-ctx.Done():
 				return
 			}
@@ -197,6 +321,13 @@ func (c *FileBasedConfiguration) Read(ctx context.Context) (Configuration, chan
 		}
 	}
 
+	// Add the secret policy's directory to watcher, to notice a policy that's created or replaced
+	if policyPath != "" {
+		if err := watcher.Add(filepath.Dir(policyPath)); err != nil && !os.IsNotExist(err) {
+			return Configuration{}, nil, nil, err
+		}
+	}
+
 	// Add all tools paths to watcher
 	for _, path := range toolsPaths {
 		if err := watcher.Add(path); err != nil && !os.IsNotExist(err) {
@@ -204,6 +335,18 @@ func (c *FileBasedConfiguration) Read(ctx context.Context) (Configuration, chan
 		}
 	}
 
+	// Add the secrets files' directories, and the secrets directories, to watcher
+	for _, path := range secretFiles {
+		if err := watcher.Add(filepath.Dir(path)); err != nil && !os.IsNotExist(err) {
+			return Configuration{}, nil, nil, err
+		}
+	}
+	for _, path := range secretDirs {
+		if err := watcher.Add(path); err != nil && !os.IsNotExist(err) {
+			return Configuration{}, nil, nil, err
+		}
+	}
+
 	return configuration, updates, watcher.Close, nil
 }
 
@@ -242,29 +385,22 @@ func (c *FileBasedConfiguration) readOnce(ctx context.Context) (Configuration, e
 		return Configuration{}, fmt.Errorf("reading tools: %w", err)
 	}
 
-	// TODO(dga): How do we know which secrets to read, in Central mode?
-	var secrets map[string]string
-	if c.SecretsPath == "docker-desktop" {
-		secrets, err = c.readDockerDesktopSecrets(ctx, servers, serverNames)
-		if err != nil {
-			return Configuration{}, fmt.Errorf("reading MCP Toolkit's secrets: %w", err)
-		}
-	} else {
-		// Unless SecretsPath is only `docker-desktop`, we don't fail if secrets can't be read.
-		// It's ok for the MCP tookit's to not be available (in Cloud Run, for example).
-		// It's ok for secrets .env file to not exist.
-		var err error
-		for secretPath := range strings.SplitSeq(c.SecretsPath, ":") {
-			if secretPath == "docker-desktop" {
-				secrets, err = c.readDockerDesktopSecrets(ctx, servers, serverNames)
-			} else {
-				secrets, err = c.readSecretsFromFile(ctx, secretPath)
-			}
+	policy, err := c.readPolicy()
+	if err != nil {
+		return Configuration{}, fmt.Errorf("reading secret policy: %w", err)
+	}
+	for _, denied := range deniedSecrets(servers, serverNames, policy) {
+		log("  - Secret policy doesn't allow", denied.Server, "to receive", denied.Secret)
+	}
 
-			if err == nil {
-				break
-			}
-		}
+	// TODO(dga): How do we know which secrets to read, in Central mode?
+	names := secretNames(servers, serverNames, policy)
+	if len(names) > 0 {
+		log("  - Reading secrets", names)
+	}
+	secrets, err := c.readSecrets(ctx, names)
+	if err != nil {
+		return Configuration{}, err
 	}
 
 	log("- Configuration read in", time.Since(start))
@@ -274,6 +410,7 @@ func (c *FileBasedConfiguration) readOnce(ctx context.Context) (Configuration, e
 		config:      serversConfig,
 		tools:       serverToolsConfig,
 		secrets:     secrets,
+		policy:      policy,
 	}, nil
 }
 
@@ -391,74 +528,81 @@ func (c *FileBasedConfiguration) readToolsConfig(ctx context.Context) (config.To
 	return mergedToolsConfig, nil
 }
 
-func (c *FileBasedConfiguration) readDockerDesktopSecrets(ctx context.Context, servers map[string]catalog.Server, serverNames []string) (map[string]string, error) {
-	// Use a map to deduplicate secret names
-	uniqueSecretNames := make(map[string]struct{})
-
-	for _, serverName := range serverNames {
-		serverName := strings.TrimSpace(serverName)
-
-		serverSpec, ok := servers[serverName]
-		if !ok {
-			continue
-		}
-
-		for _, s := range serverSpec.Secrets {
-			uniqueSecretNames[s.Name] = struct{}{}
-		}
-	}
-
-	if len(uniqueSecretNames) == 0 {
-		return map[string]string{}, nil
-	}
-
-	// Convert map keys to slice
-	var secretNames []string
-	for name := range uniqueSecretNames {
-		secretNames = append(secretNames, name)
+// readSecrets reads secrets from the chain of providers of SecretsPath.
+// Unless there's only one provider, a provider that can't be read is skipped.
+// It's ok for the MCP Toolkit to not be available (in Cloud Run, for example).
+func (c *FileBasedConfiguration) readSecrets(ctx context.Context, secretNames []string) (map[string]string, error) {
+	chain, err := secrets.Parse(c.SecretsPath, c.docker)
+	if err != nil {
+		return nil, err
 	}
 
-	log("  - Reading secrets", secretNames)
-	secretsByName, err := c.docker.ReadSecrets(ctx, secretNames, true)
+	secretsByName, err := chain.GetSecrets(ctx, secretNames)
 	if err != nil {
-		return nil, fmt.Errorf("finding secrets %s: %w", secretNames, err)
+		if len(chain) == 1 {
+			return nil, fmt.Errorf("reading secrets: %w", err)
+		}
+		logf("  - Some secrets couldn't be read: %s", err)
 	}
 
 	return secretsByName, nil
 }
 
-func (c *FileBasedConfiguration) readSecretsFromFile(ctx context.Context, path string) (map[string]string, error) {
-	secrets := map[string]string{}
+// readPolicy reads the secret policy. Without a policy file, there's no
+// policy to enforce.
+func (c *FileBasedConfiguration) readPolicy() (*secretpolicy.Policy, error) {
+	if c.PolicyPath == "" {
+		return nil, nil
+	}
 
+	path, err := config.FilePath(c.PolicyPath)
+	if err != nil {
+		return nil, err
+	}
 	buf, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("reading secrets from %s: %w", path, err)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
 	}
 
-	scanner := bufio.NewScanner(bytes.NewReader(buf))
-	for scanner.Scan() {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
+	log("  - Reading secret policy from", path)
+	return secretpolicy.Parse(string(buf))
+}
 
-		line := scanner.Text()
-		if strings.HasPrefix(line, "#") {
-			continue
+// deniedSecrets are the secrets that the enabled servers ask for, but that
+// the policy doesn't allow them to receive.
+func deniedSecrets(servers map[string]catalog.Server, serverNames []string, policy *secretpolicy.Policy) []secretpolicy.Rule {
+	var denied []secretpolicy.Rule
+	for _, serverName := range serverNames {
+		for _, s := range servers[serverName].Secrets {
+			if !policy.Allows(s.Name, serverName) {
+				denied = append(denied, secretpolicy.Rule{Secret: s.Name, Server: serverName})
+			}
 		}
+	}
+	return denied
+}
 
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
+// secretNames are the sorted names of the secrets of the enabled servers,
+// that the policy allows them to receive.
+func secretNames(servers map[string]catalog.Server, serverNames []string, policy *secretpolicy.Policy) []string {
+	// Use a map to deduplicate secret names
+	uniqueSecretNames := make(map[string]struct{})
+	for _, serverName := range serverNames {
+		serverName := strings.TrimSpace(serverName)
 
-		var key, value string
-		key, value, ok := strings.Cut(line, "=")
+		serverSpec, ok := servers[serverName]
 		if !ok {
-			return nil, fmt.Errorf("invalid line in secrets file: %s", line)
+			continue
 		}
 
-		secrets[key] = value
+		for _, s := range serverSpec.Secrets {
+			if policy.Allows(s.Name, serverName) {
+				uniqueSecretNames[s.Name] = struct{}{}
+			}
+		}
 	}
-
-	return secrets, nil
+	return slices.Sorted(maps.Keys(uniqueSecretNames))
 }
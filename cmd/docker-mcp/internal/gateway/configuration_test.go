@@ -0,0 +1,222 @@
+package gateway
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/secretpolicy"
+)
+
+func TestRotatedSecrets(t *testing.T) {
+	previous := Configuration{secrets: map[string]string{"github.token": "old", "db.password": "same", "removed": "x"}}
+	current := Configuration{
+		serverNames: []string{"github", "postgres", "fetch", "unknown"},
+		servers: map[string]catalog.Server{
+			"github":   {Secrets: []catalog.Secret{{Name: "github.token", Env: "GITHUB_TOKEN"}}},
+			"postgres": {Secrets: []catalog.Secret{{Name: "db.password", File: "/run/secrets/db"}, {Name: "added", Env: "ADDED"}}},
+			"fetch":    {},
+			"disabled": {Secrets: []catalog.Secret{{Name: "github.token", Env: "GITHUB_TOKEN"}}},
+		},
+		secrets: map[string]string{"github.token": "new", "db.password": "same", "added": "y"},
+	}
+
+	rotated := current.rotatedSecrets(previous)
+	assert.Equal(t, []string{"added", "github.token", "removed"}, rotated)
+	assert.Equal(t, []string{"github", "postgres"}, current.serversUsingSecrets(rotated))
+	assert.Empty(t, current.rotatedSecrets(current))
+}
+
+func TestWatchSecretsFile(t *testing.T) {
+	dir := t.TempDir()
+	catalogPath := filepath.Join(dir, "catalog.yaml")
+	require.NoError(t, os.WriteFile(catalogPath, []byte(`
+registry:
+  github:
+    image: mcp/github
+    secrets:
+      - name: github.token
+        env: GITHUB_TOKEN
+`), 0o644))
+	secretsPath := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(secretsPath, []byte("github.token=old\n"), 0o600))
+
+	c := &FileBasedConfiguration{
+		ServerNames: []string{"github"},
+		CatalogPath: []string{catalogPath},
+		SecretsPath: secretsPath,
+		Watch:       true,
+	}
+	configuration, updates, stop, err := c.Read(t.Context())
+	require.NoError(t, err)
+	defer stop()
+	assert.Equal(t, map[string]string{"github.token": "old"}, configuration.secrets)
+
+	// Unrelated files next to the secrets are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o644))
+	select {
+	case <-updates:
+		t.Fatal("unexpected update")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	// The secrets file is replaced, like editors and `docker mcp secret edit` do.
+	tmp := filepath.Join(dir, ".env.tmp")
+	require.NoError(t, os.WriteFile(tmp, []byte("github.token=new\n"), 0o600))
+	require.NoError(t, os.Rename(tmp, secretsPath))
+	select {
+	case configuration := <-updates:
+		assert.Equal(t, map[string]string{"github.token": "new"}, configuration.secrets)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update")
+	}
+}
+
+func TestRefreshSecrets(t *testing.T) {
+	dir := t.TempDir()
+	catalogPath := filepath.Join(dir, "catalog.yaml")
+	require.NoError(t, os.WriteFile(catalogPath, []byte(`
+registry:
+  github:
+    image: mcp/github
+    secrets:
+      - name: github.token
+        env: GITHUB_TOKEN
+`), 0o644))
+	t.Setenv("TEST_GITHUB_TOKEN", "old")
+
+	c := &FileBasedConfiguration{
+		ServerNames:    []string{"github"},
+		CatalogPath:    []string{catalogPath},
+		SecretsPath:    "env:TEST_",
+		Watch:          true,
+		SecretsRefresh: 50 * time.Millisecond,
+	}
+	configuration, updates, stop, err := c.Read(t.Context())
+	require.NoError(t, err)
+	defer stop()
+	assert.Equal(t, map[string]string{"github.token": "old"}, configuration.secrets)
+
+	t.Setenv("TEST_GITHUB_TOKEN", "new")
+	select {
+	case configuration := <-updates:
+		assert.Equal(t, map[string]string{"github.token": "new"}, configuration.secrets)
+		assert.Equal(t, []string{"github"}, configuration.serverNames)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update")
+	}
+}
+
+func TestSecretPolicy(t *testing.T) {
+	dir := t.TempDir()
+	catalogPath := filepath.Join(dir, "catalog.yaml")
+	require.NoError(t, os.WriteFile(catalogPath, []byte(`
+registry:
+  github:
+    image: mcp/github
+    secrets:
+      - name: github.token
+        env: GITHUB_TOKEN
+  notes:
+    image: mcp/notes
+    secrets:
+      - name: github.token
+        env: GITHUB_TOKEN
+      - name: notes.key
+        env: NOTES_KEY
+`), 0o644))
+	secretsPath := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(secretsPath, []byte("github.token=s3cr3t\nnotes.key=key\n"), 0o600))
+	policyPath := filepath.Join(dir, "policy.conf")
+
+	c := &FileBasedConfiguration{
+		ServerNames: []string{"github", "notes"},
+		CatalogPath: []string{catalogPath},
+		SecretsPath: secretsPath,
+		PolicyPath:  policyPath,
+	}
+
+	// Without a policy, nothing is enforced.
+	configuration, _, _, err := c.Read(t.Context())
+	require.NoError(t, err)
+	notes, _, _ := configuration.Find("notes")
+	assert.Equal(t, map[string]string{"github.token": "s3cr3t", "notes.key": "key"}, notes.Secrets)
+
+	// With a policy, servers only receive the secrets they are allowed.
+	require.NoError(t, os.WriteFile(policyPath, []byte("github.token allows github\nnotes.key allows notes\n"), 0o644))
+	configuration, _, _, err = c.Read(t.Context())
+	require.NoError(t, err)
+	github, _, _ := configuration.Find("github")
+	assert.Equal(t, map[string]string{"github.token": "s3cr3t"}, github.Secrets)
+	notes, _, _ = configuration.Find("notes")
+	assert.Equal(t, map[string]string{"notes.key": "key"}, notes.Secrets)
+
+	require.NoError(t, os.WriteFile(policyPath, []byte("github.token allows github\n"), 0o644))
+	configuration, _, _, err = c.Read(t.Context())
+	require.NoError(t, err)
+	notes, _, _ = configuration.Find("notes")
+	assert.Empty(t, notes.Secrets)
+
+	require.NoError(t, os.WriteFile(policyPath, []byte("github.token grants github\n"), 0o644))
+	_, _, _, err = c.Read(t.Context())
+	require.ErrorContains(t, err, "reading secret policy: line 1: expected")
+}
+
+func TestWatchPolicyCreated(t *testing.T) {
+	dir := t.TempDir()
+	catalogPath := filepath.Join(dir, "catalog.yaml")
+	require.NoError(t, os.WriteFile(catalogPath, []byte(`
+registry:
+  github:
+    image: mcp/github
+    secrets:
+      - name: github.token
+        env: GITHUB_TOKEN
+`), 0o644))
+	secretsPath := filepath.Join(dir, "secrets", ".env")
+	require.NoError(t, os.MkdirAll(filepath.Dir(secretsPath), 0o700))
+	require.NoError(t, os.WriteFile(secretsPath, []byte("github.token=s3cr3t\n"), 0o600))
+	policyPath := filepath.Join(dir, "policy", "policy.conf")
+	require.NoError(t, os.MkdirAll(filepath.Dir(policyPath), 0o755))
+
+	c := &FileBasedConfiguration{
+		ServerNames: []string{"github"},
+		CatalogPath: []string{catalogPath},
+		SecretsPath: secretsPath,
+		PolicyPath:  policyPath,
+		Watch:       true,
+	}
+	configuration, updates, stop, err := c.Read(t.Context())
+	require.NoError(t, err)
+	defer stop()
+	assert.Nil(t, configuration.policy)
+
+	// The policy is created after the gateway has started.
+	require.NoError(t, os.WriteFile(policyPath, []byte("github.token allows notes\n"), 0o644))
+	select {
+	case configuration := <-updates:
+		require.NotNil(t, configuration.policy)
+		github, _, _ := configuration.Find("github")
+		assert.Empty(t, github.Secrets)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update")
+	}
+}
+
+func TestPolicyChanged(t *testing.T) {
+	policy, err := secretpolicy.Parse("github.token allows github")
+	require.NoError(t, err)
+	empty, err := secretpolicy.Parse("")
+	require.NoError(t, err)
+
+	assert.False(t, (&Configuration{}).policyChanged(Configuration{}))
+	assert.False(t, (&Configuration{policy: policy}).policyChanged(Configuration{policy: policy}))
+	assert.True(t, (&Configuration{policy: policy}).policyChanged(Configuration{}))
+	assert.True(t, (&Configuration{policy: empty}).policyChanged(Configuration{}))
+	assert.True(t, (&Configuration{policy: empty}).policyChanged(Configuration{policy: policy}))
+}
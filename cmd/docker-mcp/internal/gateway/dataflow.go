@@ -0,0 +1,145 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/audit"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/config"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/dataflow"
+)
+
+// readDataFlowOverrides reads the user's data flow labels. A missing file is fine.
+func readDataFlowOverrides(name string) (dataflow.Overrides, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	path, err := config.FilePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	overrides, err := dataflow.ParseOverrides(buf)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return overrides, nil
+}
+
+// sessionTaint returns the taint of a client session, creating it if needed.
+func (g *Gateway) sessionTaint(ss *mcp.ServerSession) *dataflow.Taint {
+	if ss == nil {
+		return nil
+	}
+
+	g.sessionCacheMu.Lock()
+	defer g.sessionCacheMu.Unlock()
+
+	cache, exists := g.sessionCache[ss]
+	if !exists {
+		cache = &ServerSessionCache{}
+		g.sessionCache[ss] = cache
+	}
+	if cache.Taint == nil {
+		cache.Taint = &dataflow.Taint{}
+	}
+
+	return cache.Taint
+}
+
+// checkDataFlow decides whether a tool call can proceed given the private
+// data the session has already received.
+func (g *Gateway) checkDataFlow(ctx context.Context, ss *mcp.ServerSession, serverConfig *catalog.ServerConfig, toolName string) error {
+	if g.dataFlow == "" || g.dataFlow == dataflow.ModeOff || ss == nil {
+		return nil
+	}
+
+	labels := dataflow.Labels(serverConfig.Name, serverConfig.Spec, toolName, g.dataFlowOverrides)
+	sources := dataflow.ToxicSources(g.sessionTaint(ss), serverConfig.Name, labels)
+	if len(sources) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, source := range sources {
+		names = append(names, source.String())
+	}
+
+	event := audit.Event{
+		Kind:    "dataflow",
+		Session: ss.ID(),
+		Server:  serverConfig.Name,
+		Tool:    toolName,
+		Reason:  "public egress after private data",
+		Sources: names,
+	}
+
+	switch g.dataFlow {
+	case dataflow.ModeWarn:
+		logf("  ! Tool %s:%s can send private data from %s outside", serverConfig.Name, toolName, strings.Join(names, ", "))
+		event.Decision = audit.Warned
+		g.audit.Record(ctx, event)
+		return nil
+
+	case dataflow.ModeApprove:
+		result, err := ss.Elicit(ctx, &mcp.ElicitParams{
+			Message: fmt.Sprintf("The tool %s of the %s MCP server can send data outside, and this session received private data from %s. Allow this call?", toolName, serverConfig.Name, strings.Join(names, ", ")),
+			RequestedSchema: &jsonschema.Schema{
+				Type:       "object",
+				Properties: map[string]*jsonschema.Schema{},
+			},
+		})
+		if err == nil && result.Action == "accept" {
+			logf("  > Call to %s:%s approved by the user", serverConfig.Name, toolName)
+			event.Decision = audit.Approved
+			g.audit.Record(ctx, event)
+			return nil
+		}
+
+		if err != nil {
+			event.Reason += ", approval failed: " + err.Error()
+		}
+		logf("  ! Call to %s:%s denied", serverConfig.Name, toolName)
+		event.Decision = audit.Denied
+		g.audit.Record(ctx, event)
+		return fmt.Errorf("call to %s was not approved: this session received private data from %s", toolName, strings.Join(names, ", "))
+
+	default:
+		logf("  ! Blocking call to %s:%s, it could send private data from %s outside", serverConfig.Name, toolName, strings.Join(names, ", "))
+		event.Decision = audit.Blocked
+		g.audit.Record(ctx, event)
+		return fmt.Errorf("call to %s blocked: this session received private data from %s", toolName, strings.Join(names, ", "))
+	}
+}
+
+// markDataFlow records that the session received data from a tool or a resource
+// if it's labeled as private.
+func (g *Gateway) markDataFlow(ss *mcp.ServerSession, serverConfig *catalog.ServerConfig, toolName, name string) {
+	if g.dataFlow == "" || g.dataFlow == dataflow.ModeOff || ss == nil {
+		return
+	}
+
+	labels := dataflow.Labels(serverConfig.Name, serverConfig.Spec, toolName, g.dataFlowOverrides)
+	if labels.Sensitivity != dataflow.SensitivityPrivate {
+		return
+	}
+
+	g.sessionTaint(ss).Mark(dataflow.Source{Server: serverConfig.Name, Name: name})
+}
@@ -0,0 +1,181 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/eval"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/gateway/proxies"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/runtime"
+)
+
+// deployment is the configuration of the gateway resolved for a deployment
+// where the MCP servers are pre-started, as in static mode.
+type deployment struct {
+	// ServerNames are all the enabled servers, including remote servers and
+	// POCI tools that aren't deployed.
+	ServerNames []string
+
+	// Catalog has the catalog entries of the enabled servers.
+	Catalog map[string]catalog.Server
+
+	// Servers are the servers to deploy, sorted by name.
+	Servers []deployedServer
+}
+
+// deployedServer is an MCP server as the gateway would run it.
+type deployedServer struct {
+	Name       string
+	Image      string
+	Config     *container.Config
+	HostConfig *container.HostConfig
+
+	// Command is the full command line of the server: the image's entrypoint
+	// followed by the server's command or the image's.
+	Command []string
+
+	// Secrets are never part of Config.Env. They have to be provided by the
+	// deployment.
+	Secrets []catalog.Secret
+
+	// Egress is egressNone, egressAllowHosts or egressUnrestricted. With
+	// egressAllowHosts, Proxies are the parsed allowHosts and Sidecar runs
+	// them.
+	Egress  string
+	Proxies []proxies.Proxy
+	Sidecar *proxies.Sidecar
+}
+
+// deployment reads the configuration and resolves every enabled server.
+// Secrets values are not read.
+func (g *Gateway) deployment(ctx context.Context) (deployment, error) {
+	configuration, _, stopConfigWatcher, err := g.configurator.Read(ctx)
+	if err != nil {
+		return deployment{}, err
+	}
+	defer func() { _ = stopConfigWatcher() }()
+
+	d := deployment{
+		ServerNames: configuration.ServerNames(),
+		Catalog:     map[string]catalog.Server{},
+	}
+
+	var serverConfigs []*catalog.ServerConfig
+	for _, serverName := range d.ServerNames {
+		serverConfig, _, found := configuration.Find(serverName)
+		if !found {
+			return deployment{}, fmt.Errorf("MCP server not found: %s", serverName)
+		}
+		d.Catalog[serverName] = configuration.servers[serverName]
+
+		switch {
+		case serverConfig == nil:
+			// POCI tools are run by the gateway.
+		case serverConfig.Spec.Remote.URL != "" || serverConfig.Spec.SSEEndpoint != "":
+			// Remote servers are reached by the gateway.
+		case serverConfig.Spec.Process != nil:
+			return deployment{}, fmt.Errorf("server %s is a local process and can't be pre-started", serverName)
+		default:
+			serverConfigs = append(serverConfigs, serverConfig)
+		}
+	}
+
+	var images []string
+	for _, serverConfig := range serverConfigs {
+		images = append(images, serverConfig.Spec.Image)
+	}
+	if err := g.pullImages(ctx, images); err != nil {
+		return deployment{}, err
+	}
+
+	for _, serverConfig := range serverConfigs {
+		server, err := g.deployedServer(ctx, serverConfig)
+		if err != nil {
+			return deployment{}, fmt.Errorf("server %s: %w", serverConfig.Name, err)
+		}
+		d.Servers = append(d.Servers, server)
+	}
+	slices.SortFunc(d.Servers, func(a, b deployedServer) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return d, nil
+}
+
+func (g *Gateway) deployedServer(ctx context.Context, serverConfig *catalog.ServerConfig) (deployedServer, error) {
+	server := deployedServer{
+		Name:    serverConfig.Name,
+		Image:   serverConfig.Spec.Image,
+		Secrets: serverConfig.Spec.Secrets,
+		Egress:  g.clientPool.networkEgress(serverConfig.Name, &serverConfig.Spec),
+	}
+
+	for _, secret := range server.Secrets {
+		if secret.File == "" {
+			continue
+		}
+		if _, err := secretFileMode(secret); err != nil {
+			return deployedServer{}, err
+		}
+	}
+
+	if server.Egress == egressAllowHosts {
+		allowedHosts, err := parseAllowHosts(serverConfig.Spec.AllowHosts)
+		if err != nil {
+			return deployedServer{}, err
+		}
+		sidecar, err := proxies.NewSidecar(allowedHosts)
+		if err != nil {
+			return deployedServer{}, err
+		}
+		server.Proxies = allowedHosts
+		server.Sidecar = &sidecar
+	}
+
+	// Secrets get an empty value so that they can be told apart from the
+	// rest of the environment.
+	withoutSecrets := *serverConfig
+	withoutSecrets.Secrets = map[string]string{}
+	for _, secret := range serverConfig.Spec.Secrets {
+		withoutSecrets.Secrets[secret.Name] = ""
+	}
+
+	args, env, err := g.clientPool.argsAndEnv(&withoutSecrets, nil, proxies.TargetConfig{})
+	if err != nil {
+		return deployedServer{}, err
+	}
+	server.Config, server.HostConfig, _, err = runtime.ParseRunArgs(args, env)
+	if err != nil {
+		return deployedServer{}, err
+	}
+	server.Config.Env = slices.DeleteFunc(server.Config.Env, func(e string) bool {
+		name, _, _ := strings.Cut(e, "=")
+		return slices.ContainsFunc(serverConfig.Spec.Secrets, func(secret catalog.Secret) bool {
+			return secret.Env == name
+		})
+	})
+
+	// The image's entrypoint is replaced by docker-mcp-bridge.
+	inspect, err := g.docker.InspectImage(ctx, server.Image)
+	if err != nil {
+		return deployedServer{}, fmt.Errorf("inspecting image %s: %w", server.Image, err)
+	}
+	command := expandEnvList(eval.EvaluateList(serverConfig.Spec.Command, serverConfig.Config), env)
+	if inspect.Config != nil {
+		server.Command = append(server.Command, inspect.Config.Entrypoint...)
+		if len(command) == 0 {
+			command = inspect.Config.Cmd
+		}
+	}
+	server.Command = append(server.Command, command...)
+	if len(server.Command) == 0 {
+		return deployedServer{}, fmt.Errorf("image %s has no command", server.Image)
+	}
+
+	return server, nil
+}
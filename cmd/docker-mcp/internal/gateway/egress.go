@@ -0,0 +1,42 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/audit"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/gateway/proxies"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/telemetry"
+)
+
+// recordEgress surfaces an egress event of the proxies of a server in the
+// logs, the audit trail and telemetry.
+func (g *Gateway) recordEgress(serverName string, event proxies.EgressEvent) {
+	ctx := context.Background()
+	destination := net.JoinHostPort(event.Host, strconv.Itoa(event.Port))
+
+	decision := audit.Allowed
+	if !event.Allowed {
+		decision = audit.Blocked
+	}
+
+	request := destination
+	if event.Method != "" {
+		request = event.Method + " " + destination + event.Path
+	}
+	if g.Verbose || !event.Allowed {
+		logf("  > Egress %s: %s -> %s", decision, serverName, request)
+	}
+
+	g.audit.Record(ctx, audit.Event{
+		Kind:          "egress",
+		Decision:      decision,
+		Server:        serverName,
+		Destination:   destination,
+		Method:        event.Method,
+		BytesSent:     event.BytesSent,
+		BytesReceived: event.BytesReceived,
+	})
+	telemetry.RecordEgress(ctx, serverName, event.Host, event.Allowed, event.BytesSent, event.BytesReceived)
+}
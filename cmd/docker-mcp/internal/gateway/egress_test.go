@@ -0,0 +1,40 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/audit"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/gateway/proxies"
+)
+
+func TestRecordEgress(t *testing.T) {
+	var buf bytes.Buffer
+	g := &Gateway{audit: audit.New(&buf)}
+
+	g.recordEgress("github", proxies.EgressEvent{Proxy: "l7", Host: "api.github.com", Port: 443, Method: "GET", Path: "/user", BytesSent: 10, BytesReceived: 20, Allowed: true})
+	g.recordEgress("github", proxies.EgressEvent{Proxy: "l7", Host: "evil.com", Port: 443, Method: "CONNECT"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var allowed, blocked audit.Event
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &allowed))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &blocked))
+
+	assert.Equal(t, "egress", allowed.Kind)
+	assert.Equal(t, audit.Allowed, allowed.Decision)
+	assert.Equal(t, "github", allowed.Server)
+	assert.Equal(t, "api.github.com:443", allowed.Destination)
+	assert.Equal(t, "GET", allowed.Method)
+	assert.Equal(t, int64(10), allowed.BytesSent)
+	assert.Equal(t, int64(20), allowed.BytesReceived)
+
+	assert.Equal(t, audit.Blocked, blocked.Decision)
+	assert.Equal(t, "evil.com:443", blocked.Destination)
+}
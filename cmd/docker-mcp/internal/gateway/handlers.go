@@ -29,6 +29,10 @@ func inferServerType(serverConfig *catalog.ServerConfig) string {
 		return "sse"
 	}
 
+	if serverConfig.Spec.Process != nil {
+		return "process"
+	}
+
 	// Check for Docker image
 	if serverConfig.Spec.Image != "" {
 		return "docker"
@@ -38,15 +42,15 @@ func inferServerType(serverConfig *catalog.ServerConfig) string {
 	return "unknown"
 }
 
-func (g *Gateway) mcpToolHandler(tool catalog.Tool) mcp.ToolHandler {
-	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
+func (g *Gateway) mcpToolHandler(serverName string, tool catalog.Tool) mcp.ToolHandler {
+	return func(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
 		// Convert to the generic version for our internal methods
 		genericParams := &mcp.CallToolParams{
 			Meta:      params.Meta,
 			Name:      params.Name,
 			Arguments: params.Arguments,
 		}
-		return g.clientPool.runToolContainer(ctx, tool, genericParams)
+		return g.clientPool.runToolContainer(ctx, serverName, tool, genericParams, g.isSessionReadOnly(ss))
 	}
 }
 
@@ -93,6 +97,10 @@ func (g *Gateway) mcpServerToolHandler(serverConfig *catalog.ServerConfig, serve
 		if annotations != nil && annotations.ReadOnlyHint {
 			readOnlyHint = &annotations.ReadOnlyHint
 		}
+		if g.isSessionReadOnly(ss) {
+			readOnly := true
+			readOnlyHint = &readOnly
+		}
 
 		// Convert to the generic version for our internal methods
 		genericParams := &mcp.CallToolParams{
@@ -101,7 +109,19 @@ func (g *Gateway) mcpServerToolHandler(serverConfig *catalog.ServerConfig, serve
 			Arguments: params.Arguments,
 		}
 
-		client, err := g.clientPool.AcquireClient(ctx, serverConfig, getClientConfig(readOnlyHint, ss, server))
+		if err := g.checkDataFlow(ctx, ss, serverConfig, params.Name); err != nil {
+			span.SetStatus(codes.Error, "Blocked by data flow policy")
+			return nil, err
+		}
+
+		completedConfig, err := g.completeServerConfig(ctx, ss, serverConfig)
+		if err != nil {
+			telemetry.RecordToolError(ctx, span, serverConfig.Name, serverType, params.Name)
+			span.SetStatus(codes.Error, "Missing values")
+			return nil, err
+		}
+
+		client, err := g.clientPool.AcquireClient(ctx, completedConfig, getClientConfig(readOnlyHint, ss, server))
 		if err != nil {
 			// Record error in telemetry
 			telemetry.RecordToolError(ctx, span, serverConfig.Name, serverType, params.Name)
@@ -130,6 +150,10 @@ func (g *Gateway) mcpServerToolHandler(serverConfig *catalog.ServerConfig, serve
 			return nil, err
 		}
 
+		if !result.IsError {
+			g.markDataFlow(ss, serverConfig, params.Name, params.Name)
+		}
+
 		span.SetStatus(codes.Ok, "")
 		return result, nil
 	}
@@ -168,7 +192,15 @@ func (g *Gateway) mcpServerPromptHandler(serverConfig *catalog.ServerConfig, ser
 		// Record prompt get counter
 		telemetry.RecordPromptGet(ctx, params.Name, serverConfig.Name)
 
-		client, err := g.clientPool.AcquireClient(ctx, serverConfig, getClientConfig(nil, ss, server))
+		completedConfig, err := g.completeServerConfig(ctx, ss, serverConfig)
+		if err != nil {
+			span.RecordError(err)
+			telemetry.RecordPromptError(ctx, params.Name, serverConfig.Name, "missing_values")
+			span.SetStatus(codes.Error, "Missing values")
+			return nil, err
+		}
+
+		client, err := g.clientPool.AcquireClient(ctx, completedConfig, getClientConfig(nil, ss, server))
 		if err != nil {
 			span.RecordError(err)
 			telemetry.RecordPromptError(ctx, params.Name, serverConfig.Name, "acquire_failed")
@@ -229,7 +261,15 @@ func (g *Gateway) mcpServerResourceHandler(serverConfig *catalog.ServerConfig, s
 		// Record counter with server attribution
 		telemetry.RecordResourceRead(ctx, params.URI, serverConfig.Name)
 
-		client, err := g.clientPool.AcquireClient(ctx, serverConfig, getClientConfig(nil, ss, server))
+		completedConfig, err := g.completeServerConfig(ctx, ss, serverConfig)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Missing values")
+			telemetry.RecordResourceError(ctx, params.URI, serverConfig.Name, "missing_values")
+			return nil, err
+		}
+
+		client, err := g.clientPool.AcquireClient(ctx, completedConfig, getClientConfig(nil, ss, server))
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "Failed to acquire client")
@@ -251,6 +291,8 @@ func (g *Gateway) mcpServerResourceHandler(serverConfig *catalog.ServerConfig, s
 			return nil, err
 		}
 
+		g.markDataFlow(ss, serverConfig, "", params.URI)
+
 		// Success
 		span.SetStatus(codes.Ok, "")
 		return result, nil
@@ -0,0 +1,136 @@
+package gateway
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+)
+
+const (
+	// hardeningDefault only applies the flags every MCP server runs with.
+	hardeningDefault = "default"
+
+	// hardeningStrict also makes the root filesystem read-only, drops all
+	// capabilities and limits the number of processes.
+	hardeningStrict = "strict"
+)
+
+// hardeningProfiles are the starting points for each server's hardening.
+var hardeningProfiles = map[string]catalog.Hardening{
+	hardeningDefault: {},
+	hardeningStrict: {
+		ReadOnlyRootfs: &readOnlyRootfs,
+		Tmpfs:          []string{"/tmp:rw,noexec,nosuid,size=64m"},
+		CapDrop:        []string{"ALL"},
+		PidsLimit:      256,
+	},
+}
+
+var readOnlyRootfs = true
+
+func validateHardeningProfile(profile string) error {
+	if profile == "" {
+		return nil
+	}
+	if _, found := hardeningProfiles[profile]; !found {
+		return fmt.Errorf("unknown hardening profile %q, expected %s or %s", profile, hardeningDefault, hardeningStrict)
+	}
+	return nil
+}
+
+// resolveHardening merges the server's hardening on top of its profile.
+// The server picks its profile explicitly or gets the gateway's.
+func resolveHardening(gatewayProfile string, spec *catalog.Hardening) (catalog.Hardening, error) {
+	profile := gatewayProfile
+	if spec != nil && spec.Profile != "" {
+		profile = spec.Profile
+	}
+	if profile == "" {
+		profile = hardeningDefault
+	}
+
+	base, found := hardeningProfiles[profile]
+	if !found {
+		return catalog.Hardening{}, fmt.Errorf("unknown hardening profile %q", profile)
+	}
+	hardening := base
+	hardening.Profile = profile
+	if spec == nil {
+		return hardening, nil
+	}
+
+	if spec.ReadOnlyRootfs != nil {
+		hardening.ReadOnlyRootfs = spec.ReadOnlyRootfs
+	}
+	if len(spec.Tmpfs) > 0 {
+		hardening.Tmpfs = spec.Tmpfs
+	}
+	if len(spec.CapDrop) > 0 {
+		hardening.CapDrop = spec.CapDrop
+	}
+	hardening.CapAdd = append(append([]string{}, base.CapAdd...), spec.CapAdd...)
+	if spec.Seccomp != "" {
+		hardening.Seccomp = spec.Seccomp
+	}
+	if spec.AppArmor != "" {
+		hardening.AppArmor = spec.AppArmor
+	}
+	if spec.PidsLimit != 0 {
+		hardening.PidsLimit = spec.PidsLimit
+	}
+	hardening.Ulimits = append(append([]string{}, base.Ulimits...), spec.Ulimits...)
+	if spec.Cpus != 0 {
+		hardening.Cpus = spec.Cpus
+	}
+	if spec.Memory != "" {
+		hardening.Memory = spec.Memory
+	}
+	if spec.Runtime != "" {
+		hardening.Runtime = spec.Runtime
+	}
+
+	return hardening, nil
+}
+
+// hardeningArgs turns a resolved hardening into `docker run` flags.
+func hardeningArgs(hardening catalog.Hardening) ([]string, error) {
+	var args []string
+
+	if hardening.ReadOnlyRootfs != nil && *hardening.ReadOnlyRootfs {
+		args = append(args, "--read-only")
+		for _, tmpfs := range hardening.Tmpfs {
+			args = append(args, "--tmpfs", tmpfs)
+		}
+	}
+	for _, capability := range hardening.CapDrop {
+		args = append(args, "--cap-drop", capability)
+	}
+	for _, capability := range hardening.CapAdd {
+		args = append(args, "--cap-add", capability)
+	}
+	if hardening.Seccomp != "" {
+		args = append(args, "--security-opt", "seccomp="+hardening.Seccomp)
+	}
+	if hardening.AppArmor != "" {
+		args = append(args, "--security-opt", "apparmor="+hardening.AppArmor)
+	}
+	if hardening.PidsLimit < 0 {
+		return nil, fmt.Errorf("invalid pids limit %d", hardening.PidsLimit)
+	}
+	if hardening.PidsLimit > 0 {
+		args = append(args, "--pids-limit", strconv.Itoa(hardening.PidsLimit))
+	}
+	for _, ulimit := range hardening.Ulimits {
+		if name, _, found := strings.Cut(ulimit, "="); !found || name == "" {
+			return nil, fmt.Errorf("invalid ulimit %q, expected name=soft[:hard]", ulimit)
+		}
+		args = append(args, "--ulimit", ulimit)
+	}
+	if hardening.Runtime != "" {
+		args = append(args, "--runtime", hardening.Runtime)
+	}
+
+	return args, nil
+}
@@ -0,0 +1,86 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/gateway/proxies"
+)
+
+func TestApplyConfigStrictHardening(t *testing.T) {
+	clientPool := &clientPool{
+		Options: Options{
+			Cpus:      1,
+			Memory:    "2Gb",
+			Hardening: hardeningStrict,
+		},
+	}
+
+	args, _, err := clientPool.argsAndEnv(&catalog.ServerConfig{
+		Name: "fetch",
+		Spec: parseSpec(t, `
+hardening:
+  capAdd: [NET_BIND_SERVICE]
+  ulimits: [nofile=1024:2048]
+  cpus: 0.5
+`),
+	}, nil, proxies.TargetConfig{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"run", "--rm", "-i", "--init", "--security-opt", "no-new-privileges",
+		"--read-only", "--tmpfs", "/tmp:rw,noexec,nosuid,size=64m", "--cap-drop", "ALL", "--cap-add", "NET_BIND_SERVICE", "--pids-limit", "256", "--ulimit", "nofile=1024:2048",
+		"--cpus", "0.5", "--memory", "2Gb", "--pull", "never",
+		"-l", "docker-mcp=true", "-l", "docker-mcp-tool-type=mcp", "-l", "docker-mcp-name=fetch", "-l", "docker-mcp-transport=stdio",
+	}, args)
+}
+
+func TestApplyConfigHardeningOptOut(t *testing.T) {
+	clientPool := &clientPool{
+		Options: Options{
+			Hardening: hardeningStrict,
+		},
+	}
+
+	args, _, err := clientPool.argsAndEnv(&catalog.ServerConfig{
+		Name: "legacy",
+		Spec: parseSpec(t, `
+hardening:
+  profile: default
+  seccomp: /profiles/legacy.json
+  apparmor: docker-default
+  memory: 512m
+  runtime: runsc
+`),
+	}, nil, proxies.TargetConfig{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"run", "--rm", "-i", "--init", "--security-opt", "no-new-privileges",
+		"--security-opt", "seccomp=/profiles/legacy.json", "--security-opt", "apparmor=docker-default", "--runtime", "runsc",
+		"--memory", "512m", "--pull", "never",
+		"-l", "docker-mcp=true", "-l", "docker-mcp-tool-type=mcp", "-l", "docker-mcp-name=legacy", "-l", "docker-mcp-transport=stdio",
+	}, args)
+}
+
+func TestResolveHardeningWritableRootfs(t *testing.T) {
+	hardening, err := resolveHardening(hardeningStrict, &catalog.Hardening{ReadOnlyRootfs: boolPtr(false)})
+	require.NoError(t, err)
+
+	args, err := hardeningArgs(hardening)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--cap-drop", "ALL", "--pids-limit", "256"}, args)
+}
+
+func TestHardeningErrors(t *testing.T) {
+	require.ErrorContains(t, validateHardeningProfile("paranoid"), `unknown hardening profile "paranoid"`)
+
+	_, err := resolveHardening(hardeningDefault, &catalog.Hardening{Profile: "paranoid"})
+	require.ErrorContains(t, err, `unknown hardening profile "paranoid"`)
+
+	_, err = hardeningArgs(catalog.Hardening{Ulimits: []string{"1024"}})
+	require.ErrorContains(t, err, `invalid ulimit "1024"`)
+}
@@ -0,0 +1,786 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/gateway/proxies"
+)
+
+const (
+	// KubernetesStatic runs every server as a Deployment behind
+	// docker-mcp-bridge, that the gateway reaches as a remote server.
+	KubernetesStatic = "static"
+
+	// KubernetesPerCall runs the gateway with its own Docker Engine, that
+	// starts a container per call. Every server gets a suspended Job, as a
+	// template to run it in the cluster.
+	KubernetesPerCall = "per-call"
+
+	// kubernetesSecretsPath is where the gateway finds the secrets, one file
+	// per secret.
+	kubernetesSecretsPath = "/run/secrets/mcp"
+
+	// kubernetesBridgeDir is where the bridge is copied for the servers.
+	kubernetesBridgeDir = "/docker-mcp/misc"
+)
+
+// KubernetesOptions configures the generated manifests.
+type KubernetesOptions struct {
+	// GatewayImage is the image of the gateway. Its docker-mcp-bridge is
+	// copied in the server pods. Defaults to docker/mcp-gateway, or to
+	// docker/mcp-gateway:dind in per-call mode.
+	GatewayImage string
+
+	// Mode is KubernetesStatic or KubernetesPerCall.
+	Mode string
+
+	// SecretName is the Secret that holds a key per secret, such as
+	// github.personal_access_token. It's referenced but never generated.
+	SecretName string
+}
+
+// Kubernetes writes the manifests that run the gateway and its servers in a
+// cluster, as multiple YAML documents. Nothing is namespaced so that the
+// output can be used as a kustomize base.
+func (g *Gateway) Kubernetes(ctx context.Context, w io.Writer, options KubernetesOptions) error {
+	d, err := g.deployment(ctx)
+	if err != nil {
+		return err
+	}
+
+	objects, err := g.kubernetesObjects(d, options)
+	if err != nil {
+		return err
+	}
+
+	out := "# Generated by `docker mcp gateway kubernetes`.\n"
+	for i, object := range objects {
+		buf, err := marshalYAML(object)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			out += "---\n"
+		}
+		out += string(buf)
+	}
+
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+type k8sMeta struct {
+	Name   string            `yaml:"name,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type k8sObject struct {
+	APIVersion string  `yaml:"apiVersion"`
+	Kind       string  `yaml:"kind"`
+	Metadata   k8sMeta `yaml:"metadata"`
+	Spec       any     `yaml:"spec,omitempty"`
+	Data       any     `yaml:"data,omitempty"`
+}
+
+type k8sSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+type k8sDeploymentSpec struct {
+	Replicas int            `yaml:"replicas"`
+	Selector k8sSelector    `yaml:"selector"`
+	Template k8sPodTemplate `yaml:"template"`
+}
+
+type k8sJobSpec struct {
+	Suspend      bool           `yaml:"suspend"`
+	BackoffLimit int            `yaml:"backoffLimit"`
+	Template     k8sPodTemplate `yaml:"template"`
+}
+
+type k8sPodTemplate struct {
+	Metadata k8sMeta    `yaml:"metadata"`
+	Spec     k8sPodSpec `yaml:"spec"`
+}
+
+type k8sPodSpec struct {
+	AutomountServiceAccountToken *bool          `yaml:"automountServiceAccountToken,omitempty"`
+	ShareProcessNamespace        bool           `yaml:"shareProcessNamespace,omitempty"`
+	RuntimeClassName             string         `yaml:"runtimeClassName,omitempty"`
+	RestartPolicy                string         `yaml:"restartPolicy,omitempty"`
+	InitContainers               []k8sContainer `yaml:"initContainers,omitempty"`
+	Containers                   []k8sContainer `yaml:"containers"`
+	Volumes                      []k8sVolume    `yaml:"volumes,omitempty"`
+	SecurityContext              *k8sPodContext `yaml:"securityContext,omitempty"`
+}
+
+type k8sPodContext struct {
+	FSGroup int64 `yaml:"fsGroup"`
+}
+
+type k8sContainer struct {
+	Name            string              `yaml:"name"`
+	Image           string              `yaml:"image"`
+	Command         []string            `yaml:"command,omitempty"`
+	Args            []string            `yaml:"args,omitempty"`
+	Env             []k8sEnv            `yaml:"env,omitempty"`
+	Ports           []k8sContainerPort  `yaml:"ports,omitempty"`
+	Stdin           bool                `yaml:"stdin,omitempty"`
+	StdinOnce       bool                `yaml:"stdinOnce,omitempty"`
+	Resources       *k8sResources       `yaml:"resources,omitempty"`
+	SecurityContext *k8sSecurityContext `yaml:"securityContext,omitempty"`
+	VolumeMounts    []k8sVolumeMount    `yaml:"volumeMounts,omitempty"`
+}
+
+type k8sEnv struct {
+	Name      string        `yaml:"name"`
+	Value     string        `yaml:"value,omitempty"`
+	ValueFrom *k8sEnvSource `yaml:"valueFrom,omitempty"`
+}
+
+type k8sEnvSource struct {
+	SecretKeyRef k8sKeyRef `yaml:"secretKeyRef"`
+}
+
+type k8sKeyRef struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+}
+
+type k8sContainerPort struct {
+	Name          string `yaml:"name,omitempty"`
+	ContainerPort int    `yaml:"containerPort"`
+}
+
+type k8sResources struct {
+	Limits map[string]string `yaml:"limits"`
+}
+
+type k8sSecurityContext struct {
+	Privileged               bool             `yaml:"privileged,omitempty"`
+	AllowPrivilegeEscalation *bool            `yaml:"allowPrivilegeEscalation,omitempty"`
+	ReadOnlyRootFilesystem   bool             `yaml:"readOnlyRootFilesystem,omitempty"`
+	RunAsUser                *int64           `yaml:"runAsUser,omitempty"`
+	RunAsGroup               *int64           `yaml:"runAsGroup,omitempty"`
+	Capabilities             *k8sCapabilities `yaml:"capabilities,omitempty"`
+	SeccompProfile           *k8sProfile      `yaml:"seccompProfile,omitempty"`
+	AppArmorProfile          *k8sProfile      `yaml:"appArmorProfile,omitempty"`
+}
+
+type k8sCapabilities struct {
+	Add  []string `yaml:"add,omitempty"`
+	Drop []string `yaml:"drop,omitempty"`
+}
+
+type k8sProfile struct {
+	Type             string `yaml:"type"`
+	LocalhostProfile string `yaml:"localhostProfile,omitempty"`
+}
+
+type k8sVolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	SubPath   string `yaml:"subPath,omitempty"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+type k8sVolume struct {
+	Name                  string           `yaml:"name"`
+	EmptyDir              *k8sEmptyDir     `yaml:"emptyDir,omitempty"`
+	HostPath              *k8sPath         `yaml:"hostPath,omitempty"`
+	PersistentVolumeClaim *k8sClaim        `yaml:"persistentVolumeClaim,omitempty"`
+	ConfigMap             *k8sConfigMapRef `yaml:"configMap,omitempty"`
+	Secret                *k8sSecretRef    `yaml:"secret,omitempty"`
+}
+
+type k8sEmptyDir struct {
+	Medium    string `yaml:"medium,omitempty"`
+	SizeLimit string `yaml:"sizeLimit,omitempty"`
+}
+
+type k8sPath struct {
+	Path string `yaml:"path"`
+}
+
+type k8sClaim struct {
+	ClaimName string `yaml:"claimName"`
+}
+
+type k8sConfigMapRef struct {
+	Name string `yaml:"name"`
+}
+
+type k8sSecretRef struct {
+	SecretName string         `yaml:"secretName"`
+	Items      []k8sKeyToPath `yaml:"items,omitempty"`
+	Optional   bool           `yaml:"optional,omitempty"`
+}
+
+type k8sKeyToPath struct {
+	Key  string    `yaml:"key"`
+	Path string    `yaml:"path"`
+	Mode octalMode `yaml:"mode"`
+}
+
+type k8sServiceSpec struct {
+	Selector map[string]string `yaml:"selector"`
+	Ports    []k8sServicePort  `yaml:"ports"`
+}
+
+type k8sServicePort struct {
+	Name       string `yaml:"name,omitempty"`
+	Port       int    `yaml:"port"`
+	TargetPort int    `yaml:"targetPort"`
+}
+
+type k8sNetworkPolicySpec struct {
+	PodSelector k8sSelector      `yaml:"podSelector"`
+	PolicyTypes []string         `yaml:"policyTypes"`
+	Ingress     []k8sNetworkRule `yaml:"ingress,omitempty"`
+	Egress      []k8sNetworkRule `yaml:"egress,omitempty"`
+}
+
+// k8sNetworkRule is an ingress rule, with From, or an egress rule, with To.
+type k8sNetworkRule struct {
+	From  []k8sNetworkPeer `yaml:"from,omitempty"`
+	To    []k8sNetworkPeer `yaml:"to,omitempty"`
+	Ports []k8sNetworkPort `yaml:"ports,omitempty"`
+}
+
+type k8sNetworkPeer struct {
+	NamespaceSelector *k8sSelector `yaml:"namespaceSelector,omitempty"`
+	PodSelector       *k8sSelector `yaml:"podSelector,omitempty"`
+	IPBlock           *k8sIPBlock  `yaml:"ipBlock,omitempty"`
+}
+
+type k8sIPBlock struct {
+	CIDR string `yaml:"cidr"`
+}
+
+type k8sNetworkPort struct {
+	Protocol string `yaml:"protocol"`
+	Port     int    `yaml:"port"`
+}
+
+func (g *Gateway) kubernetesObjects(d deployment, options KubernetesOptions) ([]k8sObject, error) {
+	if options.Mode == "" {
+		options.Mode = KubernetesStatic
+	}
+	if options.Mode != KubernetesStatic && options.Mode != KubernetesPerCall {
+		return nil, fmt.Errorf("unknown mode %q, expected %s or %s", options.Mode, KubernetesStatic, KubernetesPerCall)
+	}
+	if options.GatewayImage == "" {
+		options.GatewayImage = "docker/mcp-gateway"
+		if options.Mode == KubernetesPerCall {
+			options.GatewayImage += ":dind"
+		}
+	}
+	if options.SecretName == "" {
+		options.SecretName = "mcp-secrets"
+	}
+
+	// In static mode, the gateway reaches the servers as remote servers and
+	// doesn't need a Docker Engine, which POCI tools do.
+	serversCatalog := maps.Clone(d.Catalog)
+	if options.Mode == KubernetesStatic {
+		for _, serverName := range d.ServerNames {
+			if len(d.Catalog[serverName].Tools) > 0 {
+				return nil, fmt.Errorf("server %s runs POCI tools that need a Docker Engine, use the %s mode", serverName, KubernetesPerCall)
+			}
+		}
+		for _, server := range d.Servers {
+			serversCatalog[server.Name] = catalog.Server{
+				LongLived: d.Catalog[server.Name].LongLived,
+				DataFlow:  d.Catalog[server.Name].DataFlow,
+				Remote: catalog.Remote{
+					URL:       fmt.Sprintf("http://%s:%d/mcp", kubernetesName(server.Name), bridgeHTTPPort),
+					Transport: "streamable-http",
+				},
+			}
+		}
+	}
+	catalogContent, err := marshalYAML(map[string]map[string]catalog.Server{"registry": serversCatalog})
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []k8sObject{{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   k8sMeta{Name: "mcp-gateway-catalog", Labels: kubernetesLabels("mcp-gateway", "gateway")},
+		Data:       map[string]string{"catalog.yaml": string(catalogContent)},
+	}}
+	objects = append(objects, g.kubernetesGateway(d, options)...)
+
+	for _, server := range d.Servers {
+		name := kubernetesName(server.Name)
+
+		// Only the HTTP allowHosts go through a proxy. TCP and UDP allowHosts
+		// are reached directly, since their hostnames can't be made to
+		// resolve to the proxy, and must be IP addresses.
+		var proxy *k8sServiceRef
+		httpProxies := slices.DeleteFunc(slices.Clone(server.Proxies), func(p proxies.Proxy) bool {
+			return p.Protocol != proxies.HTTP
+		})
+		if len(httpProxies) > 0 {
+			sidecar, err := proxies.NewSidecar(httpProxies)
+			if err != nil {
+				return nil, fmt.Errorf("server %s: %w", server.Name, err)
+			}
+			proxy = &k8sServiceRef{Name: name + "-proxy", Port: sidecar.HTTPPort}
+			objects = append(objects, kubernetesProxy(name, sidecar)...)
+		}
+
+		pod, err := kubernetesServerPod(server, options, proxy)
+		if err != nil {
+			return nil, fmt.Errorf("server %s: %w", server.Name, err)
+		}
+
+		labels := kubernetesLabels(name, "mcp-server")
+		if options.Mode == KubernetesStatic {
+			objects = append(objects, kubernetesDeployment(name, labels, pod), kubernetesService(name, labels, bridgeHTTPPort))
+		} else {
+			pod.RestartPolicy = "Never"
+			objects = append(objects, k8sObject{
+				APIVersion: "batch/v1",
+				Kind:       "Job",
+				Metadata:   k8sMeta{Name: name, Labels: labels},
+				Spec: k8sJobSpec{
+					Suspend:  true,
+					Template: k8sPodTemplate{Metadata: k8sMeta{Labels: labels}, Spec: pod},
+				},
+			})
+		}
+
+		policy, err := kubernetesServerPolicy(server, options, proxy)
+		if err != nil {
+			return nil, fmt.Errorf("server %s: %w", server.Name, err)
+		}
+		objects = append(objects, policy)
+	}
+
+	return objects, nil
+}
+
+// k8sServiceRef is the HTTP proxy of a server.
+type k8sServiceRef struct {
+	Name string
+	Port int
+}
+
+func (g *Gateway) kubernetesGateway(d deployment, options KubernetesOptions) []k8sObject {
+	port := g.Port
+	if port == 0 {
+		port = 8811
+	}
+
+	args := []string{
+		"--transport=streaming",
+		"--port=" + strconv.Itoa(port),
+		"--servers=" + strings.Join(d.ServerNames, ","),
+		"--catalog=" + composeCatalogPath,
+		"--secrets=" + kubernetesSecretsPath,
+	}
+	if g.ReadOnly {
+		args = append(args, "--read-only")
+	}
+
+	gateway := k8sContainer{
+		Name:  "gateway",
+		Image: options.GatewayImage,
+		Args:  args,
+		Ports: []k8sContainerPort{{Name: "mcp", ContainerPort: port}},
+		VolumeMounts: []k8sVolumeMount{
+			{Name: "catalog", MountPath: "/mcp", ReadOnly: true},
+			{Name: "secrets", MountPath: kubernetesSecretsPath, ReadOnly: true},
+		},
+	}
+	volumes := []k8sVolume{
+		{Name: "catalog", ConfigMap: &k8sConfigMapRef{Name: "mcp-gateway-catalog"}},
+		{Name: "secrets", Secret: &k8sSecretRef{SecretName: options.SecretName, Optional: true}},
+	}
+
+	if options.Mode == KubernetesStatic {
+		// There's no Docker Engine to find which network the gateway is on.
+		gateway.Env = []k8sEnv{{Name: "DOCKER_MCP_IN_CONTAINER", Value: "0"}}
+	} else {
+		// The servers run in the gateway's own Docker Engine, that needs a
+		// privileged container.
+		if g.Hardening != "" {
+			gateway.Args = append(gateway.Args, "--hardening="+g.Hardening)
+		}
+		if g.BlockNetwork {
+			gateway.Args = append(gateway.Args, "--block-network")
+		}
+		for _, trusted := range g.UnrestrictedNetwork {
+			gateway.Args = append(gateway.Args, "--unrestricted-network="+trusted)
+		}
+		if g.Cpus > 0 {
+			gateway.Args = append(gateway.Args, "--cpus="+strconv.Itoa(g.Cpus))
+		}
+		if g.Memory != "" {
+			gateway.Args = append(gateway.Args, "--memory="+g.Memory)
+		}
+		gateway.SecurityContext = &k8sSecurityContext{Privileged: true}
+		gateway.VolumeMounts = append(gateway.VolumeMounts, k8sVolumeMount{Name: "docker", MountPath: "/var/lib/docker"})
+		volumes = append(volumes, k8sVolume{Name: "docker", EmptyDir: &k8sEmptyDir{}})
+	}
+
+	labels := kubernetesLabels("mcp-gateway", "gateway")
+	pod := k8sPodSpec{
+		Containers: []k8sContainer{gateway},
+		Volumes:    volumes,
+	}
+	return []k8sObject{
+		kubernetesDeployment("mcp-gateway", labels, pod),
+		kubernetesService("mcp-gateway", labels, port),
+	}
+}
+
+// kubernetesServerPod runs a server. In static mode, it's served over
+// streamable HTTP by docker-mcp-bridge, copied from the gateway image. In
+// per-call mode, it's a stdio server.
+func kubernetesServerPod(server deployedServer, options KubernetesOptions, proxy *k8sServiceRef) (k8sPodSpec, error) {
+	config, hostConfig := server.Config, server.HostConfig
+
+	serverContainer := k8sContainer{
+		Name:  "server",
+		Image: server.Image,
+	}
+	pod := k8sPodSpec{
+		AutomountServiceAccountToken: new(bool),
+		ShareProcessNamespace:        hostConfig.Init != nil && *hostConfig.Init,
+		RuntimeClassName:             hostConfig.Runtime,
+	}
+
+	if options.Mode == KubernetesStatic {
+		serverContainer.Command = append([]string{bridgePath, "-tcp-addr=", "-http-addr=:" + strconv.Itoa(bridgeHTTPPort), "--"}, server.Command...)
+		serverContainer.Ports = []k8sContainerPort{{Name: "mcp", ContainerPort: bridgeHTTPPort}}
+		serverContainer.VolumeMounts = append(serverContainer.VolumeMounts, k8sVolumeMount{Name: "docker-mcp", MountPath: kubernetesBridgeDir, ReadOnly: true})
+		pod.InitContainers = []k8sContainer{{
+			Name:         "docker-mcp-bridge",
+			Image:        options.GatewayImage,
+			Command:      []string{"cp", "/misc/docker-mcp-bridge", kubernetesBridgeDir + "/docker-mcp-bridge"},
+			VolumeMounts: []k8sVolumeMount{{Name: "docker-mcp", MountPath: kubernetesBridgeDir}},
+		}}
+		pod.Volumes = append(pod.Volumes, k8sVolume{Name: "docker-mcp", EmptyDir: &k8sEmptyDir{}})
+	} else {
+		serverContainer.Command = server.Command
+		serverContainer.Stdin = true
+		serverContainer.StdinOnce = true
+	}
+
+	env := envMap(config.Env)
+	if proxy != nil {
+		httpProxy := proxy.Name + ":" + strconv.Itoa(proxy.Port)
+		env["http_proxy"] = httpProxy
+		env["https_proxy"] = httpProxy
+	}
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		serverContainer.Env = append(serverContainer.Env, k8sEnv{Name: name, Value: env[name]})
+	}
+	var secretFiles []k8sKeyToPath
+	for _, secret := range server.Secrets {
+		if secret.File == "" {
+			serverContainer.Env = append(serverContainer.Env, k8sEnv{
+				Name:      secret.Env,
+				ValueFrom: &k8sEnvSource{SecretKeyRef: k8sKeyRef{Name: options.SecretName, Key: secret.Name}},
+			})
+			continue
+		}
+
+		mode, err := secretFileMode(secret)
+		if err != nil {
+			return k8sPodSpec{}, err
+		}
+		if secret.UID != 0 {
+			// Files of a Secret volume can't be given an owner. They belong
+			// to the pod's fsGroup instead, that gets the owner's permissions.
+			pod.SecurityContext = &k8sPodContext{FSGroup: int64(secret.UID)}
+			mode |= (mode & 0o700) >> 3
+		}
+		path := "file-" + strconv.Itoa(len(secretFiles))
+		secretFiles = append(secretFiles, k8sKeyToPath{Key: secret.Name, Path: path, Mode: octalMode(mode)})
+		serverContainer.VolumeMounts = append(serverContainer.VolumeMounts, k8sVolumeMount{Name: "secret-files", MountPath: secret.File, SubPath: path, ReadOnly: true})
+	}
+	if len(secretFiles) > 0 {
+		pod.Volumes = append(pod.Volumes, k8sVolume{Name: "secret-files", Secret: &k8sSecretRef{SecretName: options.SecretName, Items: secretFiles}})
+	}
+
+	limits := map[string]string{}
+	if hostConfig.NanoCPUs > 0 {
+		limits["cpu"] = strconv.FormatInt(hostConfig.NanoCPUs/1e6, 10) + "m"
+	}
+	if hostConfig.Memory > 0 {
+		limits["memory"] = strconv.FormatInt(hostConfig.Memory, 10)
+	}
+	if len(limits) > 0 {
+		serverContainer.Resources = &k8sResources{Limits: limits}
+	}
+
+	securityContext, err := kubernetesSecurityContext(config, hostConfig)
+	if err != nil {
+		return k8sPodSpec{}, err
+	}
+	serverContainer.SecurityContext = securityContext
+
+	for i, path := range slices.Sorted(maps.Keys(hostConfig.Tmpfs)) {
+		name := "tmpfs-" + strconv.Itoa(i)
+		pod.Volumes = append(pod.Volumes, k8sVolume{Name: name, EmptyDir: &k8sEmptyDir{Medium: "Memory", SizeLimit: tmpfsSize(hostConfig.Tmpfs[path])}})
+		serverContainer.VolumeMounts = append(serverContainer.VolumeMounts, k8sVolumeMount{Name: name, MountPath: path})
+	}
+	for i, bind := range hostConfig.Binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) < 2 {
+			return k8sPodSpec{}, fmt.Errorf("invalid volume %q", bind)
+		}
+		name := "volume-" + strconv.Itoa(i)
+		volume := k8sVolume{Name: name}
+		if isNamedVolume(parts[0]) {
+			volume.PersistentVolumeClaim = &k8sClaim{ClaimName: parts[0]}
+		} else {
+			volume.HostPath = &k8sPath{Path: parts[0]}
+		}
+		pod.Volumes = append(pod.Volumes, volume)
+		serverContainer.VolumeMounts = append(serverContainer.VolumeMounts, k8sVolumeMount{
+			Name:      name,
+			MountPath: parts[1],
+			ReadOnly:  len(parts) > 2 && slices.Contains(strings.Split(parts[2], ","), "ro"),
+		})
+	}
+
+	pod.Containers = []k8sContainer{serverContainer}
+	return pod, nil
+}
+
+// kubernetesSecurityContext translates the hardening of a container. Pids
+// and ulimits can't be set on a container and are left to the cluster.
+func kubernetesSecurityContext(config *container.Config, hostConfig *container.HostConfig) (*k8sSecurityContext, error) {
+	securityContext := &k8sSecurityContext{
+		AllowPrivilegeEscalation: new(bool),
+		Privileged:               hostConfig.Privileged,
+		ReadOnlyRootFilesystem:   hostConfig.ReadonlyRootfs,
+	}
+	if hostConfig.Privileged {
+		*securityContext.AllowPrivilegeEscalation = true
+	}
+
+	if config.User != "" {
+		user, group, hasGroup := strings.Cut(config.User, ":")
+		uid, err := strconv.ParseInt(user, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("user %q must be numeric", config.User)
+		}
+		securityContext.RunAsUser = &uid
+		if hasGroup {
+			gid, err := strconv.ParseInt(group, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("group %q must be numeric", config.User)
+			}
+			securityContext.RunAsGroup = &gid
+		}
+	}
+
+	if len(hostConfig.CapAdd) > 0 || len(hostConfig.CapDrop) > 0 {
+		securityContext.Capabilities = &k8sCapabilities{Add: hostConfig.CapAdd, Drop: hostConfig.CapDrop}
+	}
+
+	securityContext.SeccompProfile = &k8sProfile{Type: "RuntimeDefault"}
+	for _, opt := range hostConfig.SecurityOpt {
+		name, value, _ := strings.Cut(opt, "=")
+		switch name {
+		case "seccomp":
+			securityContext.SeccompProfile = kubernetesProfile(value)
+		case "apparmor":
+			securityContext.AppArmorProfile = kubernetesProfile(value)
+		}
+	}
+
+	return securityContext, nil
+}
+
+// kubernetesProfile turns a seccomp or AppArmor profile into its Kubernetes
+// equivalent. Custom profiles must be installed on the nodes.
+func kubernetesProfile(profile string) *k8sProfile {
+	switch profile {
+	case "unconfined":
+		return &k8sProfile{Type: "Unconfined"}
+	case "", "default", "builtin", "docker-default", "runtime/default":
+		return &k8sProfile{Type: "RuntimeDefault"}
+	default:
+		return &k8sProfile{Type: "Localhost", LocalhostProfile: profile}
+	}
+}
+
+// tmpfsSize turns the size option of a tmpfs, such as 64m, into a quantity.
+func tmpfsSize(options string) string {
+	for option := range strings.SplitSeq(options, ",") {
+		size, found := strings.CutPrefix(option, "size=")
+		if !found || size == "" {
+			continue
+		}
+		switch suffix := strings.ToLower(size[len(size)-1:]); suffix {
+		case "k", "m", "g":
+			return size[:len(size)-1] + strings.ToUpper(suffix) + "i"
+		default:
+			return size
+		}
+	}
+	return ""
+}
+
+// kubernetesProxy runs the HTTP proxy of a server, that only the server can
+// reach.
+func kubernetesProxy(name string, sidecar proxies.Sidecar) []k8sObject {
+	proxyName := name + "-proxy"
+	labels := kubernetesLabels(proxyName, "proxy")
+
+	proxyContainer := k8sContainer{
+		Name:  "proxy",
+		Image: sidecar.Image,
+		Ports: []k8sContainerPort{{Name: "http", ContainerPort: sidecar.HTTPPort}},
+	}
+	env := envMap(sidecar.Env)
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		proxyContainer.Env = append(proxyContainer.Env, k8sEnv{Name: name, Value: env[name]})
+	}
+
+	return []k8sObject{
+		kubernetesDeployment(proxyName, labels, k8sPodSpec{
+			AutomountServiceAccountToken: new(bool),
+			Containers:                   []k8sContainer{proxyContainer},
+		}),
+		kubernetesService(proxyName, labels, sidecar.HTTPPort),
+		{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "NetworkPolicy",
+			Metadata:   k8sMeta{Name: proxyName, Labels: labels},
+			Spec: k8sNetworkPolicySpec{
+				PodSelector: k8sSelector{MatchLabels: kubernetesSelector(proxyName)},
+				PolicyTypes: []string{"Ingress"},
+				Ingress: []k8sNetworkRule{{
+					From:  []k8sNetworkPeer{{PodSelector: &k8sSelector{MatchLabels: kubernetesSelector(name)}}},
+					Ports: []k8sNetworkPort{{Protocol: "TCP", Port: sidecar.HTTPPort}},
+				}},
+			},
+		},
+	}
+}
+
+// kubernetesDNS is the cluster's DNS, that servers with allowHosts can reach.
+var kubernetesDNS = k8sNetworkPeer{
+	NamespaceSelector: &k8sSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "kube-system"}},
+	PodSelector:       &k8sSelector{MatchLabels: map[string]string{"k8s-app": "kube-dns"}},
+}
+
+// kubernetesServerPolicy only lets the gateway reach a server, and restricts
+// where the server can connect to, depending on its egress. A NetworkPolicy
+// can't match hostnames, so TCP and UDP allowHosts must be IP addresses
+// rather than letting their port through to anywhere.
+func kubernetesServerPolicy(server deployedServer, options KubernetesOptions, proxy *k8sServiceRef) (k8sObject, error) {
+	name := kubernetesName(server.Name)
+
+	spec := k8sNetworkPolicySpec{
+		PodSelector: k8sSelector{MatchLabels: kubernetesSelector(name)},
+		PolicyTypes: []string{"Ingress"},
+	}
+	if options.Mode == KubernetesStatic {
+		spec.Ingress = []k8sNetworkRule{{
+			From:  []k8sNetworkPeer{{PodSelector: &k8sSelector{MatchLabels: kubernetesSelector("mcp-gateway")}}},
+			Ports: []k8sNetworkPort{{Protocol: "TCP", Port: bridgeHTTPPort}},
+		}}
+	}
+
+	switch server.Egress {
+	case egressNone:
+		spec.PolicyTypes = append(spec.PolicyTypes, "Egress")
+	case egressAllowHosts:
+		spec.PolicyTypes = append(spec.PolicyTypes, "Egress")
+		spec.Egress = []k8sNetworkRule{{
+			To:    []k8sNetworkPeer{kubernetesDNS},
+			Ports: []k8sNetworkPort{{Protocol: "UDP", Port: 53}, {Protocol: "TCP", Port: 53}},
+		}}
+		if proxy != nil {
+			spec.Egress = append(spec.Egress, k8sNetworkRule{
+				To:    []k8sNetworkPeer{{PodSelector: &k8sSelector{MatchLabels: kubernetesSelector(proxy.Name)}}},
+				Ports: []k8sNetworkPort{{Protocol: "TCP", Port: proxy.Port}},
+			})
+		}
+
+		for _, allowedHost := range server.Proxies {
+			if allowedHost.Protocol == proxies.HTTP {
+				continue
+			}
+			ip := net.ParseIP(allowedHost.Hostname)
+			if ip == nil {
+				return k8sObject{}, fmt.Errorf("allowHosts %s:%d/%s can't be enforced by a NetworkPolicy, use an IP address", allowedHost.Hostname, allowedHost.Port, allowedHost.Protocol)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			spec.Egress = append(spec.Egress, k8sNetworkRule{
+				To:    []k8sNetworkPeer{{IPBlock: &k8sIPBlock{CIDR: ip.String() + "/" + strconv.Itoa(bits)}}},
+				Ports: []k8sNetworkPort{{Protocol: strings.ToUpper(allowedHost.Protocol.String()), Port: int(allowedHost.Port)}},
+			})
+		}
+	}
+
+	return k8sObject{
+		APIVersion: "networking.k8s.io/v1",
+		Kind:       "NetworkPolicy",
+		Metadata:   k8sMeta{Name: name, Labels: kubernetesLabels(name, "mcp-server")},
+		Spec:       spec,
+	}, nil
+}
+
+func kubernetesDeployment(name string, labels map[string]string, pod k8sPodSpec) k8sObject {
+	return k8sObject{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Metadata:   k8sMeta{Name: name, Labels: labels},
+		Spec: k8sDeploymentSpec{
+			Replicas: 1,
+			Selector: k8sSelector{MatchLabels: kubernetesSelector(name)},
+			Template: k8sPodTemplate{Metadata: k8sMeta{Labels: labels}, Spec: pod},
+		},
+	}
+}
+
+func kubernetesService(name string, labels map[string]string, port int) k8sObject {
+	return k8sObject{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Metadata:   k8sMeta{Name: name, Labels: labels},
+		Spec: k8sServiceSpec{
+			Selector: kubernetesSelector(name),
+			Ports:    []k8sServicePort{{Name: "mcp", Port: port, TargetPort: port}},
+		},
+	}
+}
+
+func kubernetesLabels(name, component string) map[string]string {
+	labels := kubernetesSelector(name)
+	labels["app.kubernetes.io/component"] = component
+	labels["app.kubernetes.io/part-of"] = "docker-mcp-gateway"
+	return labels
+}
+
+func kubernetesSelector(name string) map[string]string {
+	return map[string]string{"app.kubernetes.io/name": name}
+}
+
+// kubernetesName turns a server name into a valid object name.
+func kubernetesName(serverName string) string {
+	return strings.ReplaceAll(composeName(serverName), "_", "-")
+}
@@ -0,0 +1,224 @@
+package gateway
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+)
+
+func kubernetesTestGateway(t *testing.T) *Gateway {
+	t.Helper()
+
+	return composeGateway(t, Options{Cpus: 1, Memory: "2Gb", Hardening: hardeningStrict, BlockNetwork: true, UnrestrictedNetwork: []string{"fetch"}}, map[string]catalog.Server{
+		"fetch": {Image: "mcp/fetch"},
+		"github": {
+			Image:      "mcp/github",
+			Secrets:    []catalog.Secret{{Name: "github.token", Env: "GITHUB_TOKEN"}},
+			AllowHosts: []string{"api.github.com:443", "10.0.0.5:5432/tcp"},
+		},
+		"notes": {
+			Image:   "mcp/notes",
+			Command: []string{"--dir", "/notes"},
+			Volumes: []string{"notes-data:/notes"},
+		},
+		"remote": {Remote: catalog.Remote{URL: "https://example.com/mcp"}},
+	}, nil)
+}
+
+func kubernetesObjectsByName(objects []k8sObject) map[string]k8sObject {
+	byName := map[string]k8sObject{}
+	for _, object := range objects {
+		byName[object.Kind+"/"+object.Metadata.Name] = object
+	}
+	return byName
+}
+
+func TestKubernetesStatic(t *testing.T) {
+	g := kubernetesTestGateway(t)
+
+	d, err := g.deployment(t.Context())
+	require.NoError(t, err)
+	objects, err := g.kubernetesObjects(d, KubernetesOptions{})
+	require.NoError(t, err)
+	byName := kubernetesObjectsByName(objects)
+
+	assert.ElementsMatch(t, []string{
+		"ConfigMap/mcp-gateway-catalog",
+		"Deployment/mcp-gateway", "Service/mcp-gateway",
+		"Deployment/mcp-fetch", "Service/mcp-fetch", "NetworkPolicy/mcp-fetch",
+		"Deployment/mcp-github", "Service/mcp-github", "NetworkPolicy/mcp-github",
+		"Deployment/mcp-github-proxy", "Service/mcp-github-proxy", "NetworkPolicy/mcp-github-proxy",
+		"Deployment/mcp-notes", "Service/mcp-notes", "NetworkPolicy/mcp-notes",
+	}, keys(byName))
+
+	// The gateway reaches the servers as remote servers.
+	catalogContent := byName["ConfigMap/mcp-gateway-catalog"].Data.(map[string]string)["catalog.yaml"]
+	assert.Contains(t, catalogContent, "url: http://mcp-github:8080/mcp")
+	assert.Contains(t, catalogContent, "url: https://example.com/mcp")
+	assert.NotContains(t, catalogContent, "mcp/github")
+
+	gateway := byName["Deployment/mcp-gateway"].Spec.(k8sDeploymentSpec).Template.Spec.Containers[0]
+	assert.Equal(t, "docker/mcp-gateway", gateway.Image)
+	assert.Contains(t, gateway.Args, "--secrets=/run/secrets/mcp")
+	assert.Contains(t, gateway.Args, "--servers=fetch,github,notes,remote")
+
+	// Servers run behind the bridge, with their secrets referenced by name.
+	github := byName["Deployment/mcp-github"].Spec.(k8sDeploymentSpec).Template.Spec
+	assert.Equal(t, []string{"cp", "/misc/docker-mcp-bridge", "/docker-mcp/misc/docker-mcp-bridge"}, github.InitContainers[0].Command)
+	server := github.Containers[0]
+	assert.Equal(t, []string{bridgePath, "-tcp-addr=", "-http-addr=:8080", "--", "/server/github-mcp-server", "stdio"}, server.Command)
+	assert.Contains(t, server.Env, k8sEnv{Name: "GITHUB_TOKEN", ValueFrom: &k8sEnvSource{SecretKeyRef: k8sKeyRef{Name: "mcp-secrets", Key: "github.token"}}})
+	assert.Contains(t, server.Env, k8sEnv{Name: "https_proxy", Value: "mcp-github-proxy:8080"})
+	assert.Equal(t, map[string]string{"cpu": "1000m", "memory": "2147483648"}, server.Resources.Limits)
+	assert.True(t, server.SecurityContext.ReadOnlyRootFilesystem)
+	assert.False(t, *server.SecurityContext.AllowPrivilegeEscalation)
+	assert.Equal(t, []string{"ALL"}, server.SecurityContext.Capabilities.Drop)
+	assert.False(t, *github.AutomountServiceAccountToken)
+
+	// Unrestricted servers keep their egress.
+	fetch := byName["NetworkPolicy/mcp-fetch"].Spec.(k8sNetworkPolicySpec)
+	assert.Equal(t, []string{"Ingress"}, fetch.PolicyTypes)
+	assert.Equal(t, kubernetesSelector("mcp-gateway"), fetch.Ingress[0].From[0].PodSelector.MatchLabels)
+
+	// Servers with allowHosts reach DNS, their proxy and their TCP hosts.
+	githubPolicy := byName["NetworkPolicy/mcp-github"].Spec.(k8sNetworkPolicySpec)
+	assert.Equal(t, []string{"Ingress", "Egress"}, githubPolicy.PolicyTypes)
+	assert.Equal(t, []k8sNetworkRule{
+		{To: []k8sNetworkPeer{kubernetesDNS}, Ports: []k8sNetworkPort{{Protocol: "UDP", Port: 53}, {Protocol: "TCP", Port: 53}}},
+		{To: []k8sNetworkPeer{{PodSelector: &k8sSelector{MatchLabels: kubernetesSelector("mcp-github-proxy")}}}, Ports: []k8sNetworkPort{{Protocol: "TCP", Port: 8080}}},
+		{To: []k8sNetworkPeer{{IPBlock: &k8sIPBlock{CIDR: "10.0.0.5/32"}}}, Ports: []k8sNetworkPort{{Protocol: "TCP", Port: 5432}}},
+	}, githubPolicy.Egress)
+	proxy := byName["Deployment/mcp-github-proxy"].Spec.(k8sDeploymentSpec).Template.Spec.Containers[0]
+	assert.Contains(t, proxy.Env[0].Value, "api.github.com:443")
+	assert.NotContains(t, proxy.Env[0].Value, "10.0.0.5")
+
+	// Servers without allowHosts get no egress.
+	notesPolicy := byName["NetworkPolicy/mcp-notes"].Spec.(k8sNetworkPolicySpec)
+	assert.Equal(t, []string{"Ingress", "Egress"}, notesPolicy.PolicyTypes)
+	assert.Empty(t, notesPolicy.Egress)
+	notes := byName["Deployment/mcp-notes"].Spec.(k8sDeploymentSpec).Template.Spec
+	assert.Contains(t, notes.Volumes, k8sVolume{Name: "volume-0", PersistentVolumeClaim: &k8sClaim{ClaimName: "notes-data"}})
+	assert.Contains(t, notes.Containers[0].VolumeMounts, k8sVolumeMount{Name: "volume-0", MountPath: "/notes"})
+}
+
+func TestKubernetesTCPHostname(t *testing.T) {
+	g := composeGateway(t, Options{BlockNetwork: true}, map[string]catalog.Server{
+		"redis": {Image: "mcp/notes", AllowHosts: []string{"db.internal:6379/tcp"}},
+	}, nil)
+
+	d, err := g.deployment(t.Context())
+	require.NoError(t, err)
+	_, err = g.kubernetesObjects(d, KubernetesOptions{})
+	require.EqualError(t, err, "server redis: allowHosts db.internal:6379/tcp can't be enforced by a NetworkPolicy, use an IP address")
+}
+
+func TestKubernetesPerCall(t *testing.T) {
+	g := kubernetesTestGateway(t)
+
+	d, err := g.deployment(t.Context())
+	require.NoError(t, err)
+	objects, err := g.kubernetesObjects(d, KubernetesOptions{Mode: KubernetesPerCall})
+	require.NoError(t, err)
+	byName := kubernetesObjectsByName(objects)
+
+	assert.NotContains(t, byName, "Deployment/mcp-fetch")
+	assert.NotContains(t, byName, "Service/mcp-fetch")
+
+	gateway := byName["Deployment/mcp-gateway"].Spec.(k8sDeploymentSpec).Template.Spec.Containers[0]
+	assert.Equal(t, "docker/mcp-gateway:dind", gateway.Image)
+	assert.True(t, gateway.SecurityContext.Privileged)
+	assert.Contains(t, gateway.Args, "--block-network")
+	assert.Contains(t, gateway.Args, "--unrestricted-network=fetch")
+
+	// The gateway runs the servers from the original catalog.
+	catalogContent := byName["ConfigMap/mcp-gateway-catalog"].Data.(map[string]string)["catalog.yaml"]
+	assert.Contains(t, catalogContent, "image: mcp/github")
+
+	job := byName["Job/mcp-github"].Spec.(k8sJobSpec)
+	assert.True(t, job.Suspend)
+	assert.Equal(t, "Never", job.Template.Spec.RestartPolicy)
+	server := job.Template.Spec.Containers[0]
+	assert.Equal(t, []string{"/server/github-mcp-server", "stdio"}, server.Command)
+	assert.True(t, server.Stdin)
+	assert.Empty(t, job.Template.Spec.InitContainers)
+
+	policy := byName["NetworkPolicy/mcp-github"].Spec.(k8sNetworkPolicySpec)
+	assert.Empty(t, policy.Ingress)
+}
+
+func TestKubernetesOutput(t *testing.T) {
+	g := kubernetesTestGateway(t)
+
+	var out bytes.Buffer
+	require.NoError(t, g.Kubernetes(t.Context(), &out, KubernetesOptions{}))
+	assert.NotContains(t, out.String(), "s3cr3t")
+
+	decoder := yaml.NewDecoder(strings.NewReader(out.String()))
+	count := 0
+	for {
+		var object map[string]any
+		if err := decoder.Decode(&object); err != nil {
+			break
+		}
+		assert.NotContains(t, object["metadata"], "namespace")
+		count++
+	}
+	assert.Equal(t, 15, count)
+}
+
+func TestKubernetesPOCITools(t *testing.T) {
+	g := composeGateway(t, Options{}, map[string]catalog.Server{
+		"tools": {Tools: []catalog.Tool{{Name: "curl"}}},
+	}, nil)
+
+	d, err := g.deployment(t.Context())
+	require.NoError(t, err)
+
+	_, err = g.kubernetesObjects(d, KubernetesOptions{})
+	require.EqualError(t, err, "server tools runs POCI tools that need a Docker Engine, use the per-call mode")
+	_, err = g.kubernetesObjects(d, KubernetesOptions{Mode: KubernetesPerCall})
+	require.NoError(t, err)
+}
+
+func TestTmpfsSize(t *testing.T) {
+	assert.Equal(t, "64Mi", tmpfsSize("rw,size=64m,mode=1777"))
+	assert.Equal(t, "1024", tmpfsSize("size=1024"))
+	assert.Empty(t, tmpfsSize("rw,noexec"))
+}
+
+func TestKubernetesSecretFiles(t *testing.T) {
+	g := composeGateway(t, Options{}, map[string]catalog.Server{
+		"postgres": {
+			Image: "mcp/postgres",
+			Secrets: []catalog.Secret{
+				{Name: "postgres.url", Env: "DATABASE_URL"},
+				{Name: "postgres.password", File: "/run/secrets/pgpass", UID: 999},
+				{Name: "postgres.cert", File: "/certs/client.key", Mode: "0600"},
+			},
+		},
+	}, nil)
+
+	d, err := g.deployment(t.Context())
+	require.NoError(t, err)
+	objects, err := g.kubernetesObjects(d, KubernetesOptions{})
+	require.NoError(t, err)
+
+	pod := kubernetesObjectsByName(objects)["Deployment/mcp-postgres"].Spec.(k8sDeploymentSpec).Template.Spec
+	server := pod.Containers[0]
+	assert.Equal(t, []k8sEnv{{Name: "DATABASE_URL", ValueFrom: &k8sEnvSource{SecretKeyRef: k8sKeyRef{Name: "mcp-secrets", Key: "postgres.url"}}}}, server.Env)
+	assert.Contains(t, server.VolumeMounts, k8sVolumeMount{Name: "secret-files", MountPath: "/run/secrets/pgpass", SubPath: "file-0", ReadOnly: true})
+	assert.Contains(t, server.VolumeMounts, k8sVolumeMount{Name: "secret-files", MountPath: "/certs/client.key", SubPath: "file-1", ReadOnly: true})
+	assert.Contains(t, pod.Volumes, k8sVolume{Name: "secret-files", Secret: &k8sSecretRef{SecretName: "mcp-secrets", Items: []k8sKeyToPath{
+		{Key: "postgres.password", Path: "file-0", Mode: 0o440},
+		{Key: "postgres.cert", Path: "file-1", Mode: 0o600},
+	}}})
+
+	// Files can't be given an owner, the pod's fsGroup can read them instead.
+	assert.Equal(t, &k8sPodContext{FSGroup: 999}, pod.SecurityContext)
+}
@@ -0,0 +1,40 @@
+package gateway
+
+import (
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/eval"
+)
+
+// mountViolations checks the volumes of every configured server against the mount policy.
+// Unlike when a server is started, it doesn't stop at the first violation.
+func (g *Gateway) mountViolations(configuration Configuration) []string {
+	policy := g.clientPool.mountPolicy()
+
+	var violations []string
+	check := func(name string, volumes []string) {
+		for _, volume := range volumes {
+			if volume == "" {
+				continue
+			}
+
+			if _, err := policy.Apply(volume); err != nil {
+				violations = append(violations, name+": "+err.Error())
+			}
+		}
+	}
+
+	for _, serverName := range configuration.ServerNames() {
+		serverConfig, tools, found := configuration.Find(serverName)
+		switch {
+		case !found:
+		case serverConfig != nil:
+			check(serverName, eval.EvaluateList(serverConfig.Spec.Volumes, serverConfig.Config))
+		case tools != nil:
+			// Tool volumes depend on the arguments of each call, only check the static parts.
+			for _, tool := range *tools {
+				check(serverName+":"+tool.Name, eval.EvaluateList(tool.Container.Volumes, map[string]any{}))
+			}
+		}
+	}
+
+	return violations
+}
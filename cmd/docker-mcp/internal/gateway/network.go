@@ -3,21 +3,27 @@ package gateway
 import (
 	"context"
 	"os"
+	"slices"
 	"sort"
+	"strings"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
 )
 
-func (g *Gateway) guessNetworks(ctx context.Context) ([]string, error) {
+// guessNetworks finds the networks of the gateway's container, and the
+// compose project it belongs to, if any.
+func (g *Gateway) guessNetworks(ctx context.Context) ([]string, string, error) {
 	hostname, err := os.Hostname()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	found, response, err := g.docker.ContainerExists(ctx, hostname)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if !found {
-		return nil, nil
+		return nil, "", nil
 	}
 
 	var networks []string
@@ -26,5 +32,72 @@ func (g *Gateway) guessNetworks(ctx context.Context) ([]string, error) {
 	}
 	sort.Strings(networks)
 
-	return networks, nil
+	var project string
+	if response.Config != nil {
+		project = response.Config.Labels[labelComposeProject]
+	}
+
+	return networks, project, nil
+}
+
+const (
+	// egressNone means the server has no network at all.
+	egressNone = "none"
+
+	// egressAllowHosts means the server can only reach its allowHosts, through proxies.
+	egressAllowHosts = "allowHosts"
+
+	// egressUnrestricted means the server can reach anything.
+	egressUnrestricted = "unrestricted"
+)
+
+// networkEgress decides what network access a server gets. With --block-network,
+// servers without allowHosts get no network unless they are explicitly trusted.
+func (cp *clientPool) networkEgress(serverName string, spec *catalog.Server) string {
+	switch {
+	case spec.DisableNetwork:
+		return egressNone
+	case !cp.BlockNetwork || slices.Contains(cp.UnrestrictedNetwork, serverName):
+		return egressUnrestricted
+	case len(spec.AllowHosts) > 0:
+		return egressAllowHosts
+	default:
+		return egressNone
+	}
+}
+
+// toolEgress decides what network access a POCI tool gets. A tool is trusted
+// with --unrestricted-network by the name of its server, or by `server:tool`.
+func (cp *clientPool) toolEgress(serverName, toolName string) string {
+	if slices.Contains(cp.UnrestrictedNetwork, serverName+":"+toolName) {
+		return egressUnrestricted
+	}
+	return cp.networkEgress(serverName, &catalog.Server{})
+}
+
+// egressReport lists the network access of every configured server.
+func (g *Gateway) egressReport(configuration Configuration) []string {
+	var report []string
+
+	for _, serverName := range configuration.ServerNames() {
+		serverConfig, tools, found := configuration.Find(serverName)
+		switch {
+		case !found:
+		case serverConfig != nil && (serverConfig.Spec.SSEEndpoint != "" || serverConfig.Spec.Remote.URL != ""):
+			report = append(report, serverName+": remote server, not sandboxed")
+		case serverConfig != nil:
+			egress := g.clientPool.networkEgress(serverName, &serverConfig.Spec)
+			if egress == egressAllowHosts {
+				egress += " " + strings.Join(serverConfig.Spec.AllowHosts, ", ")
+			}
+			report = append(report, serverName+": "+egress)
+		case tools != nil:
+			for _, tool := range *tools {
+				report = append(report, serverName+":"+tool.Name+": "+g.clientPool.toolEgress(serverName, tool.Name)+" (POCI tool, trusted as "+serverName+" or "+serverName+":"+tool.Name+")")
+			}
+		}
+	}
+
+	sort.Strings(report)
+	return report
 }
@@ -0,0 +1,393 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/eval"
+)
+
+const (
+	// missingValuesRefuse doesn't start the servers that miss values.
+	missingValuesRefuse = "refuse"
+
+	// missingValuesUnhealthy doesn't start them either, and reports the
+	// gateway as unhealthy until they are configured.
+	missingValuesUnhealthy = "unhealthy"
+
+	// missingValuesElicit lists their tools anyway and asks the client for
+	// the missing values the first time a session uses them.
+	missingValuesElicit = "elicit"
+)
+
+func validateMissingValues(mode string) error {
+	switch mode {
+	case "", missingValuesRefuse, missingValuesUnhealthy, missingValuesElicit:
+		return nil
+	default:
+		return fmt.Errorf("unknown missing values mode %q, expected %s, %s or %s", mode, missingValuesRefuse, missingValuesUnhealthy, missingValuesElicit)
+	}
+}
+
+// missingValue is a secret or a config value that a server needs, but that's
+// not set.
+type missingValue struct {
+	// Secret is the name of a missing secret.
+	Secret string
+	// Config is the dotted path of a missing config value.
+	Config      string
+	Description string
+	// Denied is a secret that the secret policy doesn't allow the server to
+	// receive. It can't be asked for either.
+	Denied bool
+}
+
+func (m missingValue) name() string {
+	if m.Secret != "" {
+		return m.Secret
+	}
+	return m.Config
+}
+
+func (m missingValue) String() string {
+	if m.Secret != "" {
+		return "secret " + m.Secret
+	}
+	return "config " + m.Config
+}
+
+func joinMissing(missing []missingValue) string {
+	var names []string
+	for _, m := range missing {
+		if m.Denied {
+			names = append(names, fmt.Sprintf("%s (%s)", m, m.Description))
+		} else {
+			names = append(names, m.String())
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+var templateReference = regexp.MustCompile(`{{(.*?)}}`)
+
+// preflight resolves every secret, config value and template a server needs
+// to start, and returns all the ones that are missing.
+//
+// Config values are required when the server's config schema says so. The
+// ones that templates reference are required too, unless they have an `or:`
+// default or the schema declares them as optional.
+func preflight(serverConfig *catalog.ServerConfig) []missingValue {
+	var missing []missingValue
+
+	for _, s := range serverConfig.Spec.Secrets {
+		if _, found := serverConfig.Secrets[s.Name]; found {
+			continue
+		}
+		description := "env " + s.Env
+		if s.File != "" {
+			description = "file " + s.File
+		}
+		missing = append(missing, missingValue{Secret: s.Name, Description: description})
+	}
+
+	seen := map[string]bool{}
+	addConfig := func(path, description string) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		if !isConfigSet(path, serverConfig.Config) {
+			missing = append(missing, missingValue{Config: path, Description: description})
+		}
+	}
+
+	var declared []string
+	for _, schema := range serverConfig.Spec.Config {
+		for _, property := range schema.Required {
+			addConfig(schema.Name+"."+property, schema.Properties[property].Description)
+		}
+		for property := range schema.Properties {
+			declared = append(declared, schema.Name+"."+property)
+		}
+	}
+
+	for _, template := range serverTemplates(serverConfig.Spec) {
+		for _, match := range templateReference.FindAllStringSubmatch(template, -1) {
+			path, functions, _ := strings.Cut(match[1], "|")
+			path = strings.TrimSpace(path)
+			if hasDefault(functions) || slices.ContainsFunc(declared, func(property string) bool {
+				return path == property || strings.HasPrefix(path, property+".")
+			}) {
+				continue
+			}
+			addConfig(path, "")
+		}
+	}
+
+	return missing
+}
+
+// serverTemplates are all the values of a server's spec that are evaluated
+// against its config.
+func serverTemplates(spec catalog.Server) []string {
+	var templates []string
+	for _, env := range spec.Env {
+		templates = append(templates, env.Value)
+	}
+	templates = append(templates, spec.Command...)
+	templates = append(templates, spec.Volumes...)
+	templates = append(templates, spec.User)
+	if spec.Process != nil {
+		templates = append(templates, spec.Process.Command...)
+		templates = append(templates, spec.Process.WorkingDir)
+	}
+	return templates
+}
+
+func hasDefault(functions string) bool {
+	for f := range strings.SplitSeq(functions, "|") {
+		if strings.HasPrefix(strings.TrimSpace(f), "or:") {
+			return true
+		}
+	}
+	return false
+}
+
+// missingValues is the preflight of a server, where the secrets that the
+// policy doesn't allow are told apart.
+func (c *Configuration) missingValues(serverConfig *catalog.ServerConfig) []missingValue {
+	missing := preflight(serverConfig)
+	for i, m := range missing {
+		if m.Secret != "" && !c.policy.Allows(m.Secret, serverConfig.Name) {
+			missing[i].Description = "not allowed by the secret policy"
+			missing[i].Denied = true
+		}
+	}
+	return missing
+}
+
+func isConfigSet(path string, config map[string]any) bool {
+	value := eval.Evaluate("{{"+path+"}}", config)
+	if s, ok := value.(string); ok {
+		return s != ""
+	}
+	return value != nil
+}
+
+// preflightServers reports every value that every server is missing at once,
+// and returns the servers that can be started.
+func (g *Gateway) preflightServers(configuration Configuration, serverNames []string) []string {
+	var ready, problems []string
+	for _, serverName := range serverNames {
+		serverConfig, _, found := configuration.Find(serverName)
+		if found && serverConfig != nil {
+			if missing := configuration.missingValues(serverConfig); len(missing) > 0 {
+				problems = append(problems, fmt.Sprintf("%s is missing %s", serverName, joinMissing(missing)))
+				if g.MissingValues != missingValuesElicit || slices.ContainsFunc(missing, func(m missingValue) bool { return m.Denied }) {
+					continue
+				}
+			}
+		}
+		ready = append(ready, serverName)
+	}
+
+	if len(problems) > 0 {
+		log("- Some servers are missing values:")
+		for _, problem := range problems {
+			log("  -", problem)
+		}
+		if g.MissingValues == missingValuesElicit {
+			log("  > Their tools are listed and the clients are asked for the values at first use")
+		} else {
+			log("  > They are not started, see `docker mcp server check <name>`")
+		}
+	}
+	if g.MissingValues == missingValuesUnhealthy {
+		g.health.SetProblems(problems)
+	}
+
+	return ready
+}
+
+// Check reports the secrets and config values that servers are missing, and
+// fails if any is.
+func (g *Gateway) Check(ctx context.Context, w io.Writer, serverNames []string) error {
+	configuration, _, stopConfigWatcher, err := g.configurator.Read(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stopConfigWatcher() }()
+
+	var failed []string
+	for _, serverName := range serverNames {
+		serverConfig, _, found := configuration.Find(serverName)
+		if !found {
+			return fmt.Errorf("MCP server not found: %s", serverName)
+		}
+
+		var missing []missingValue
+		if serverConfig != nil {
+			missing = configuration.missingValues(serverConfig)
+		}
+		if len(missing) == 0 {
+			fmt.Fprintf(w, "%s: ok\n", serverName)
+			continue
+		}
+
+		failed = append(failed, serverName)
+		fmt.Fprintf(w, "%s: missing values\n", serverName)
+		for _, m := range missing {
+			if m.Description != "" {
+				fmt.Fprintf(w, "  - %s (%s)\n", m, m.Description)
+			} else {
+				fmt.Fprintf(w, "  - %s\n", m)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%s can't start until the missing values are set", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// elicitedValues are the missing values that a client gave for a server.
+type elicitedValues struct {
+	secrets map[string]string
+	config  map[string]string
+}
+
+// completeServerConfig asks the client for the values that a server is
+// missing, the first time a session uses it. The values are only kept for
+// the session.
+func (g *Gateway) completeServerConfig(ctx context.Context, ss *mcp.ServerSession, serverConfig *catalog.ServerConfig) (*catalog.ServerConfig, error) {
+	if g.MissingValues != missingValuesElicit || ss == nil {
+		return serverConfig, nil
+	}
+
+	values := g.sessionElicitedValues(ss, serverConfig.Name)
+	completed := withValues(serverConfig, values)
+	missing := preflight(completed)
+	if len(missing) == 0 {
+		return completed, nil
+	}
+
+	schema := &jsonschema.Schema{
+		Type:       "object",
+		Properties: map[string]*jsonschema.Schema{},
+	}
+	for _, m := range missing {
+		schema.Properties[m.name()] = &jsonschema.Schema{
+			Type:        "string",
+			Title:       m.String(),
+			Description: m.Description,
+		}
+		schema.Required = append(schema.Required, m.name())
+	}
+
+	result, err := ss.Elicit(ctx, &mcp.ElicitParams{
+		Message:         fmt.Sprintf("The %s MCP server is missing %s. The values are only kept for this session.", serverConfig.Name, joinMissing(missing)),
+		RequestedSchema: schema,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("server %s is missing %s, and the client couldn't be asked for them: %w", serverConfig.Name, joinMissing(missing), err)
+	}
+	if result.Action != "accept" {
+		return nil, fmt.Errorf("server %s is missing %s", serverConfig.Name, joinMissing(missing))
+	}
+
+	values = elicitedValues{
+		secrets: maps.Clone(values.secrets),
+		config:  maps.Clone(values.config),
+	}
+	for _, m := range missing {
+		value, found := result.Content[m.name()]
+		if !found || value == nil || value == "" {
+			return nil, fmt.Errorf("server %s is missing %s", serverConfig.Name, m)
+		}
+		if m.Secret != "" {
+			values.secrets = setValue(values.secrets, m.Secret, fmt.Sprintf("%v", value))
+		} else {
+			values.config = setValue(values.config, m.Config, fmt.Sprintf("%v", value))
+		}
+	}
+	logf("  > Client gave the missing values of %s", serverConfig.Name)
+	g.setSessionElicitedValues(ss, serverConfig.Name, values)
+
+	return withValues(serverConfig, values), nil
+}
+
+func setValue(values map[string]string, key, value string) map[string]string {
+	if values == nil {
+		values = map[string]string{}
+	}
+	values[key] = value
+	return values
+}
+
+// withValues is a copy of the server config, completed with elicited values.
+func withValues(serverConfig *catalog.ServerConfig, values elicitedValues) *catalog.ServerConfig {
+	if len(values.secrets) == 0 && len(values.config) == 0 {
+		return serverConfig
+	}
+
+	completed := *serverConfig
+	completed.Secrets = map[string]string{}
+	maps.Copy(completed.Secrets, serverConfig.Secrets)
+	maps.Copy(completed.Secrets, values.secrets)
+	for path, value := range values.config {
+		completed.Config = setConfig(completed.Config, path, value)
+	}
+
+	return &completed
+}
+
+// setConfig sets a dotted path in a copy of the config.
+func setConfig(config map[string]any, path string, value any) map[string]any {
+	updated := map[string]any{}
+	maps.Copy(updated, config)
+
+	key, rest, found := strings.Cut(path, ".")
+	if !found {
+		updated[key] = value
+		return updated
+	}
+
+	child, _ := updated[key].(map[string]any)
+	updated[key] = setConfig(child, rest, value)
+	return updated
+}
+
+func (g *Gateway) sessionElicitedValues(ss *mcp.ServerSession, serverName string) elicitedValues {
+	g.sessionCacheMu.RLock()
+	defer g.sessionCacheMu.RUnlock()
+
+	if cache, exists := g.sessionCache[ss]; exists {
+		return cache.Elicited[serverName]
+	}
+	return elicitedValues{}
+}
+
+func (g *Gateway) setSessionElicitedValues(ss *mcp.ServerSession, serverName string, values elicitedValues) {
+	g.sessionCacheMu.Lock()
+	defer g.sessionCacheMu.Unlock()
+
+	cache, exists := g.sessionCache[ss]
+	if !exists {
+		cache = &ServerSessionCache{}
+		g.sessionCache[ss] = cache
+	}
+	if cache.Elicited == nil {
+		cache.Elicited = map[string]elicitedValues{}
+	}
+	cache.Elicited[serverName] = values
+}
@@ -0,0 +1,254 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/secretpolicy"
+)
+
+func TestPreflight(t *testing.T) {
+	catalogYAML := `
+image: mcp/db
+secrets:
+  - name: db.password
+    env: DB_PASSWORD
+  - name: db.cert
+    file: /run/secrets/cert
+  - name: db.user
+    env: DB_USER
+env:
+  - name: DB_HOST
+    value: '{{db.host}}'
+  - name: DB_PORT
+    value: '{{db.port|or:5432}}'
+  - name: DB_NAME
+    value: '{{db.name}}'
+command:
+  - --log={{db.log_level}}
+volumes:
+  - '{{db.data_path}}:/data'
+  - '{{db.log_path|mount_as:/logs}}'
+config:
+  - name: db
+    properties:
+      host:
+        type: string
+        description: Host of the database
+      name:
+        type: string
+      log_path:
+        type: string
+    required: [host, name]
+`
+	serverConfig := &catalog.ServerConfig{
+		Name:    "db",
+		Spec:    parseSpec(t, catalogYAML),
+		Config:  map[string]any{"db": map[string]any{"name": "test"}},
+		Secrets: map[string]string{"db.user": "admin"},
+	}
+
+	assert.Equal(t, []missingValue{
+		{Secret: "db.password", Description: "env DB_PASSWORD"},
+		{Secret: "db.cert", Description: "file /run/secrets/cert"},
+		{Config: "db.host", Description: "Host of the database"},
+		{Config: "db.log_level"},
+		{Config: "db.data_path"},
+	}, preflight(serverConfig))
+}
+
+func TestPreflightNothingMissing(t *testing.T) {
+	serverConfig := &catalog.ServerConfig{
+		Name: "hub",
+		Spec: parseSpec(t, `
+image: mcp/hub
+volumes:
+  - '{{hub.log_path|mount_as:/logs:ro}}'
+config:
+  - name: hub
+    properties:
+      log_path:
+        type: string
+`),
+	}
+
+	assert.Empty(t, preflight(serverConfig))
+}
+
+func TestPreflightServers(t *testing.T) {
+	configuration := Configuration{
+		serverNames: []string{"fetch", "github"},
+		servers: map[string]catalog.Server{
+			"fetch":  {Image: "mcp/fetch"},
+			"github": {Image: "mcp/github", Secrets: []catalog.Secret{{Name: "github.token", Env: "GITHUB_TOKEN"}}},
+		},
+	}
+
+	g := &Gateway{}
+	g.health.SetHealthy()
+	assert.Equal(t, []string{"fetch"}, g.preflightServers(configuration, configuration.serverNames))
+	assert.True(t, g.health.IsHealthy())
+
+	g.MissingValues = missingValuesUnhealthy
+	assert.Equal(t, []string{"fetch"}, g.preflightServers(configuration, configuration.serverNames))
+	assert.False(t, g.health.IsHealthy())
+	assert.Equal(t, []string{"github is missing secret github.token"}, g.health.Problems())
+
+	// Healthy again, once configured.
+	configuration.secrets = map[string]string{"github.token": "s3cr3t"}
+	assert.Equal(t, []string{"fetch", "github"}, g.preflightServers(configuration, configuration.serverNames))
+	assert.True(t, g.health.IsHealthy())
+
+	g.MissingValues = missingValuesElicit
+	configuration.secrets = nil
+	assert.Equal(t, []string{"fetch", "github"}, g.preflightServers(configuration, configuration.serverNames))
+}
+
+func TestPreflightServersSecretPolicy(t *testing.T) {
+	policy, err := secretpolicy.Parse("github.token allows github")
+	require.NoError(t, err)
+	configuration := Configuration{
+		serverNames: []string{"github", "notes"},
+		servers: map[string]catalog.Server{
+			"github": {Image: "mcp/github", Secrets: []catalog.Secret{{Name: "github.token", Env: "GITHUB_TOKEN"}}},
+			"notes":  {Image: "mcp/notes", Secrets: []catalog.Secret{{Name: "github.token", Env: "GITHUB_TOKEN"}}},
+		},
+		secrets: map[string]string{"github.token": "s3cr3t"},
+		policy:  policy,
+	}
+
+	// Secrets that the policy doesn't allow can't be asked for either.
+	g := &Gateway{Options: Options{MissingValues: missingValuesElicit}}
+	assert.Equal(t, []string{"github"}, g.preflightServers(configuration, configuration.serverNames))
+
+	g.configurator = &staticConfigurator{configuration}
+	var out bytes.Buffer
+	require.Error(t, g.Check(t.Context(), &out, []string{"github", "notes"}))
+	assert.Equal(t, `github: ok
+notes: missing values
+  - secret github.token (not allowed by the secret policy)
+`, out.String())
+}
+
+func TestCheck(t *testing.T) {
+	g := composeGateway(t, Options{}, map[string]catalog.Server{
+		"fetch": {Image: "mcp/fetch"},
+		"github": {
+			Image:   "mcp/github",
+			Secrets: []catalog.Secret{{Name: "github.token", Env: "GITHUB_TOKEN"}},
+			Env:     []catalog.Env{{Name: "GITHUB_HOST", Value: "{{github.host}}"}},
+		},
+		"notes": {
+			Image:   "mcp/notes",
+			Secrets: []catalog.Secret{{Name: "notes.key", Env: "NOTES_KEY"}},
+			Volumes: []string{"{{notes.dir}}:/notes"},
+		},
+	}, nil)
+
+	var out bytes.Buffer
+	err := g.Check(t.Context(), &out, []string{"fetch", "github", "notes"})
+	require.EqualError(t, err, "github, notes can't start until the missing values are set")
+	assert.Equal(t, `fetch: ok
+github: missing values
+  - config github.host
+notes: missing values
+  - secret notes.key (env NOTES_KEY)
+  - config notes.dir
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, g.Check(t.Context(), &out, []string{"fetch"}))
+	assert.Equal(t, "fetch: ok\n", out.String())
+
+	require.EqualError(t, g.Check(t.Context(), &out, []string{"unknown"}), "MCP server not found: unknown")
+}
+
+func TestWithValues(t *testing.T) {
+	serverConfig := &catalog.ServerConfig{
+		Name:    "atlassian",
+		Config:  map[string]any{"atlassian": map[string]any{"jira": map[string]any{"username": "me"}}},
+		Secrets: map[string]string{"other": "value"},
+	}
+
+	completed := withValues(serverConfig, elicitedValues{
+		secrets: map[string]string{"atlassian.token": "s3cr3t"},
+		config:  map[string]string{"atlassian.jira.url": "https://jira"},
+	})
+	assert.Equal(t, map[string]string{"other": "value", "atlassian.token": "s3cr3t"}, completed.Secrets)
+	assert.Equal(t, map[string]any{"atlassian": map[string]any{"jira": map[string]any{"username": "me", "url": "https://jira"}}}, completed.Config)
+
+	// The original is left untouched.
+	assert.Equal(t, map[string]string{"other": "value"}, serverConfig.Secrets)
+	assert.Equal(t, map[string]any{"atlassian": map[string]any{"jira": map[string]any{"username": "me"}}}, serverConfig.Config)
+}
+
+func TestCompleteServerConfig(t *testing.T) {
+	g := &Gateway{
+		Options:      Options{MissingValues: missingValuesElicit},
+		sessionCache: map[*mcp.ServerSession]*ServerSessionCache{},
+	}
+	serverConfig := &catalog.ServerConfig{
+		Name: "github",
+		Spec: catalog.Server{
+			Image:   "mcp/github",
+			Secrets: []catalog.Secret{{Name: "github.token", Env: "GITHUB_TOKEN"}},
+			Env:     []catalog.Env{{Name: "GITHUB_HOST", Value: "{{github.host}}"}},
+		},
+		Config: map[string]any{"github": nil},
+	}
+
+	var elicited []*mcp.ElicitParams
+	action := "accept"
+	ss := connectSession(t, func(_ context.Context, _ *mcp.ClientSession, params *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+		elicited = append(elicited, params)
+		return &mcp.ElicitResult{
+			Action:  action,
+			Content: map[string]any{"github.token": "s3cr3t", "github.host": "github.example.com"},
+		}, nil
+	})
+
+	completed, err := g.completeServerConfig(t.Context(), ss, serverConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", completed.Secrets["github.token"])
+	assert.Equal(t, map[string]any{"github": map[string]any{"host": "github.example.com"}}, completed.Config)
+	require.Len(t, elicited, 1)
+	assert.Equal(t, []string{"github.token", "github.host"}, elicited[0].RequestedSchema.Required)
+
+	// The client is only asked once per session.
+	completed, err = g.completeServerConfig(t.Context(), ss, serverConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", completed.Secrets["github.token"])
+	assert.Len(t, elicited, 1)
+
+	// Other sessions are asked again.
+	action = "decline"
+	other := connectSession(t, func(_ context.Context, _ *mcp.ClientSession, params *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+		elicited = append(elicited, params)
+		return &mcp.ElicitResult{Action: action}, nil
+	})
+	_, err = g.completeServerConfig(t.Context(), other, serverConfig)
+	require.EqualError(t, err, "server github is missing secret github.token, config github.host")
+	assert.Len(t, elicited, 2)
+}
+
+func connectSession(t *testing.T, elicit func(context.Context, *mcp.ClientSession, *mcp.ElicitParams) (*mcp.ElicitResult, error)) *mcp.ServerSession {
+	t.Helper()
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	server := mcp.NewServer(&mcp.Implementation{Name: "gateway"}, nil)
+	ss, err := server.Connect(t.Context(), serverTransport)
+	require.NoError(t, err)
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "client"}, &mcp.ClientOptions{ElicitationHandler: elicit})
+	cs, err := client.Connect(t.Context(), clientTransport)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cs.Close() })
+
+	return ss
+}
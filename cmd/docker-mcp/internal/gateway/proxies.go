@@ -9,17 +9,35 @@ import (
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/mcp"
 )
 
-func (cp *clientPool) runProxies(ctx context.Context, allowedHosts []string, longRunning bool) (proxies.TargetConfig, func(context.Context) error, error) {
+// runProxies starts the proxies of a server instance. instance is used to name
+// the proxies and their network.
+func (cp *clientPool) runProxies(ctx context.Context, serverName, instance string, allowedHosts []string, longRunning bool) (proxies.TargetConfig, func(context.Context) error, error) {
+	nwProxies, err := parseAllowHosts(allowedHosts)
+	if err != nil {
+		return proxies.TargetConfig{}, nil, err
+	}
+
+	var onEvent func(proxies.EgressEvent)
+	if cp.onEgress != nil {
+		onEvent = func(event proxies.EgressEvent) {
+			cp.onEgress(serverName, event)
+		}
+	}
+
+	return proxies.RunNetworkProxies(ctx, cp.docker, instance, nwProxies, cp.LongLived || longRunning, cp.DebugDNS, onEvent)
+}
+
+// parseAllowHosts parses the allowHosts of a server.
+func parseAllowHosts(allowedHosts []string) ([]proxies.Proxy, error) {
 	var nwProxies []proxies.Proxy
 	for _, spec := range allowedHosts {
 		proxy, err := proxies.ParseProxySpec(spec)
 		if err != nil {
-			return proxies.TargetConfig{}, nil, fmt.Errorf("invalid proxy spec %q: %w", spec, err)
+			return nil, fmt.Errorf("invalid proxy spec %q: %w", spec, err)
 		}
 		nwProxies = append(nwProxies, proxy)
 	}
-
-	return proxies.RunNetworkProxies(ctx, cp.docker, nwProxies, cp.LongLived || longRunning, cp.DebugDNS)
+	return nwProxies, nil
 }
 
 func newClientWithCleanup(client mcp.Client, cleanup func(context.Context) error) mcp.Client {
@@ -0,0 +1,75 @@
+package proxies
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+)
+
+// EgressEvent is a connection, or an HTTP request, seen by a proxy sidecar.
+type EgressEvent struct {
+	Proxy         string `json:"proxy"`
+	Host          string `json:"host"`
+	Port          int    `json:"port"`
+	Method        string `json:"method,omitempty"`
+	Path          string `json:"path,omitempty"`
+	BytesSent     int64  `json:"bytes_sent"`
+	BytesReceived int64  `json:"bytes_received"`
+	Allowed       bool   `json:"allowed"`
+}
+
+// ParseEgressEvent parses a log line of a proxy sidecar. Lines that are not
+// egress events are ignored.
+func ParseEgressEvent(line string) (EgressEvent, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return EgressEvent{}, false
+	}
+
+	var event struct {
+		Type string `json:"type"`
+		EgressEvent
+	}
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.Type != "egress" {
+		return EgressEvent{}, false
+	}
+
+	return event.EgressEvent, true
+}
+
+// followLogs reads the logs of a proxy sidecar until the returned reader is
+// closed or the sidecar stops. Egress events are passed to onEvent, if not nil,
+// and DNS queries are logged if logDNS is set.
+func followLogs(ctx context.Context, cli docker.Client, proxyName string, onEvent func(EgressEvent), logDNS bool) (io.Closer, error) {
+	// Read logs with an uncancellable context otherwise events might be lost.
+	logReader, err := cli.ReadLogs(context.WithoutCancel(ctx), proxyName, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: logDNS,
+		Follow:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(logReader)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if event, ok := ParseEgressEvent(line); ok {
+				if onEvent != nil {
+					onEvent(event)
+				}
+			} else if query, ok := strings.CutPrefix(line, "dns: "); ok && logDNS {
+				logf("> dns: %s", query)
+			}
+		}
+	}()
+
+	return logReader, nil
+}
@@ -0,0 +1,74 @@
+package proxies
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	cerrdefs "github.com/containerd/errdefs"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+)
+
+// extNwName is the network proxies use to reach external services. It's
+// shared by all the proxies of the gateway.
+const extNwName = "docker-mcp-proxies-ext"
+
+// extNetwork counts the server instances using the external network.
+var extNetwork struct {
+	sync.Mutex
+	refs int
+}
+
+// acquireExtNetwork creates the external network if it's not used yet.
+func acquireExtNetwork(ctx context.Context, cli docker.Client) (string, error) {
+	extNetwork.Lock()
+	defer extNetwork.Unlock()
+
+	if extNetwork.refs == 0 {
+		if err := createExtNetwork(ctx, cli); err != nil {
+			return "", err
+		}
+	}
+	extNetwork.refs++
+
+	return extNwName, nil
+}
+
+// createExtNetwork creates the external network, with IPv6 so that proxies can
+// reach IPv6 destinations. Not every engine can allocate an IPv6 subnet, so it
+// falls back to an IPv4 only network.
+func createExtNetwork(ctx context.Context, cli docker.Client) error {
+	labels := map[string]string{"docker-mcp": "true"}
+
+	err := cli.CreateNetwork(ctx, extNwName, false, true, labels)
+	if err == nil || cerrdefs.IsConflict(err) {
+		return nil
+	}
+	logf("    > IPv6 is not available for proxies: %v", err)
+
+	if err := cli.CreateNetwork(ctx, extNwName, false, false, labels); err != nil && !cerrdefs.IsConflict(err) {
+		return fmt.Errorf("creating external network: %w", err)
+	}
+	return nil
+}
+
+// releaseExtNetwork removes the external network once it's not used anymore.
+func releaseExtNetwork(ctx context.Context, cli docker.Client) error {
+	extNetwork.Lock()
+	defer extNetwork.Unlock()
+
+	if extNetwork.refs == 0 {
+		return nil
+	}
+	extNetwork.refs--
+	if extNetwork.refs > 0 {
+		return nil
+	}
+
+	// Another gateway might still be using it.
+	if err := cli.RemoveNetwork(ctx, extNwName); err != nil && !cerrdefs.IsNotFound(err) && !cerrdefs.IsConflict(err) && !cerrdefs.IsPermissionDenied(err) {
+		return fmt.Errorf("failed to remove network %s: %w", extNwName, err)
+	}
+	return nil
+}
@@ -7,12 +7,10 @@ import (
 	"io"
 	"math/rand"
 	"strings"
+	"sync"
 	"time"
 
-	"golang.org/x/sync/errgroup"
-
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
-	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/sliceutil"
 
 	cerrdefs "github.com/containerd/errdefs"
 )
@@ -21,153 +19,148 @@ import (
 // to get all its traffic proxied through L4/L7 proxies.
 type TargetConfig struct {
 	NetworkName string
-	Links       []string
 	Env         []string
 	DNS         string
+
+	// Instance names the internal network and the proxies of a server instance.
+	Instance string
 }
 
-// RunNetworkProxies starts a set of Proxy and returns a TargetConfig that
-// should be applied to a target container to get all its traffic proxied, a
-// cleanup function to remove the network and proxies, and an error if any.
-func RunNetworkProxies(ctx context.Context, cli docker.Client, proxies []Proxy, keepCtrs, debugDNS bool) (_ TargetConfig, _ func(context.Context) error, retErr error) {
+// RunNetworkProxies starts a sidecar that runs a set of Proxy and returns a
+// TargetConfig that should be applied to a target container to get all its
+// traffic proxied, a cleanup function to remove the network and sidecar, and an
+// error if any.
+//
+// Each call gets its own internal network and sidecar, named after instance
+// (usually the server name and client session), so that a server can't reach
+// the proxies of other servers. The external network is shared and removed
+// once no proxies use it anymore.
+//
+// onEvent, if not nil, is called for every egress event the sidecar logs.
+func RunNetworkProxies(ctx context.Context, cli docker.Client, instance string, proxies []Proxy, keepCtrs, debugDNS bool, onEvent func(EgressEvent)) (_ TargetConfig, _ func(context.Context) error, retErr error) {
 	if len(proxies) == 0 {
 		return TargetConfig{}, nil, nil
 	}
 
-	logf("  - Running proxy sidecars for hosts %+v\n", proxies)
+	logf("  - Running proxy sidecar for hosts %+v\n", proxies)
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	// Create a network for connecting the MCP tool with proxies.
 	target := TargetConfig{
-		NetworkName: "docker-mcp-proxies-int",
+		Instance: instanceName(instance),
 	}
-	if err := cli.CreateNetwork(ctx, target.NetworkName, true, map[string]string{"docker-mcp": "true"}); err != nil {
-		if !cerrdefs.IsConflict(err) {
-			return TargetConfig{}, nil, fmt.Errorf("creating internal network: %w", err)
-		}
+	target.NetworkName = "docker-mcp-proxies-int-" + target.Instance
+	if err := cli.CreateNetwork(ctx, target.NetworkName, true, false, map[string]string{
+		"docker-mcp":                "true",
+		"docker-mcp-proxy-instance": target.Instance,
+	}); err != nil {
+		return TargetConfig{}, nil, fmt.Errorf("creating internal network: %w", err)
 	}
-	defer func() {
-		if retErr != nil && !keepCtrs {
-			if err := cli.RemoveNetwork(ctx, target.NetworkName); err != nil {
-				logf("failed to remove network %s: %v", target.NetworkName, err)
-			}
-		}
-	}()
 
-	// Create a network for proxies to connect to external services.
-	extNwName := "docker-mcp-proxies-ext"
-	if err := cli.CreateNetwork(ctx, extNwName, false, map[string]string{"docker-mcp": "true"}); err != nil {
-		if !cerrdefs.IsConflict(err) {
-			return TargetConfig{}, nil, fmt.Errorf("creating external network: %w", err)
-		}
-	}
-	defer func() {
-		if retErr != nil && !keepCtrs {
-			if err := cli.RemoveNetwork(ctx, extNwName); err != nil {
-				logf("failed to remove network %s: %v", extNwName, err)
-			}
-		}
-	}()
-
-	var proxyNames []string
-	var err error
-
-	// Start L4 proxies.
-	l4Proxies := sliceutil.Filter(proxies, func(p Proxy) bool { return p.Protocol == TCP })
-	proxyNames, err = runL4Proxies(ctx, cli, &target, extNwName, l4Proxies, keepCtrs)
+	// Create, or reuse, the network for proxies to connect to external services.
+	extNwName, err := acquireExtNetwork(ctx, cli)
 	if err != nil {
-		return TargetConfig{}, nil, fmt.Errorf("running l4 proxies: %w", err)
+		_ = cli.RemoveNetwork(context.WithoutCancel(ctx), target.NetworkName)
+		return TargetConfig{}, nil, err
 	}
 
-	// Cleanup running proxies if anything goes wrong beyond that point. (Note
-	// that there's no dedicated defer for l7proxy since l7ProxyName is appended
-	// to proxyNames once it's started -- so it'll be cleaned up automatically
-	// by this defer.)
+	// Cleanup the proxies and networks if anything goes wrong beyond that point.
+	var proxyNames []string
 	defer func() {
-		if retErr != nil && !keepCtrs {
-			for _, name := range proxyNames {
-				if err := cli.RemoveContainer(ctx, name, true); err != nil {
-					logf("failed to remove proxy container %s: %v", name, err)
-				}
+		if retErr != nil {
+			if err := removeProxies(context.WithoutCancel(ctx), cli, target.NetworkName, proxyNames); err != nil {
+				logf("failed to cleanup proxies: %v", err)
 			}
 		}
 	}()
 
-	// Start L7 proxy.
-	l7Proxies := sliceutil.Filter(proxies, func(p Proxy) bool { return p.Protocol == HTTP })
-	l7ProxyName, err := runL7Proxy(ctx, cli, &target, extNwName, l7Proxies, keepCtrs)
+	// Start the sidecar that runs all the proxies.
+	proxyName, err := runSidecar(ctx, cli, &target, extNwName, proxies, keepCtrs, debugDNS)
 	if err != nil {
-		return TargetConfig{}, nil, fmt.Errorf("running l7 proxy: %w", err)
+		return TargetConfig{}, nil, fmt.Errorf("running proxy: %w", err)
 	}
+	proxyNames = append(proxyNames, proxyName)
 
-	if l7ProxyName != "" {
-		proxyNames = append(proxyNames, l7ProxyName)
+	// We need to wait for the sidecar to start before we can fetch its IP
+	// address. It's the DNS server of the MCP server.
+	if err := waitForContainer(ctx, cli, proxyName); err != nil {
+		return TargetConfig{}, nil, fmt.Errorf("waiting for proxy to start: %w", err)
 	}
-
-	// Make sure all proxies are running.
-	g, groupCtx := errgroup.WithContext(ctx)
-	for _, name := range proxyNames {
-		g.Go(func() error {
-			return waitForContainer(groupCtx, cli, name)
-		})
+	inspect, err := cli.InspectContainer(ctx, proxyName)
+	if err != nil {
+		return TargetConfig{}, nil, fmt.Errorf("inspecting proxy %s: %w", proxyName, err)
 	}
-
-	if err := g.Wait(); err != nil {
-		return TargetConfig{}, nil, fmt.Errorf("waiting for proxies to start: %w", err)
+	if endpoint := inspect.NetworkSettings.Networks[target.NetworkName]; endpoint != nil {
+		target.DNS = endpoint.IPAddress
 	}
 
-	var dnsLogsReader io.ReadCloser
-	if debugDNS {
-		var dnsName string
-		dnsName, dnsLogsReader, err = runDNSForwarder(ctx, cli, &target, extNwName, keepCtrs)
+	// Collect the egress events, and DNS queries, of the proxy.
+	var logReaders []io.Closer
+	if onEvent != nil || debugDNS {
+		logReader, err := followLogs(ctx, cli, proxyName, onEvent, debugDNS)
 		if err != nil {
-			return TargetConfig{}, nil, fmt.Errorf("running dns forwarder: %w", err)
+			return TargetConfig{}, nil, fmt.Errorf("reading logs of proxy %s: %w", proxyName, err)
 		}
-		proxyNames = append(proxyNames, dnsName)
+		logReaders = append(logReaders, logReader)
 	}
 
-	// Cleanup function to remove the network and proxies.
+	// Cleanup function to remove the network and proxies. It's safe to call
+	// it more than once.
+	var once sync.Once
+	var cleanupErr error
 	cleanup := func(ctx context.Context) error {
-		if dnsLogsReader != nil {
-			_ = dnsLogsReader.Close()
-		}
-		if keepCtrs {
-			return shutdownProxies(ctx, cli, proxyNames)
-		}
-		return removeProxies(ctx, cli, []string{extNwName, target.NetworkName}, proxyNames)
+		once.Do(func() {
+			for _, logReader := range logReaders {
+				_ = logReader.Close()
+			}
+			cleanupErr = removeProxies(ctx, cli, target.NetworkName, proxyNames)
+		})
+		return cleanupErr
 	}
 
 	return target, cleanup, nil
 }
 
-func shutdownProxies(ctx context.Context, cli docker.Client, proxyNames []string) error {
-	logf("    > Shutting down proxies (%s)", strings.Join(proxyNames, ", "))
-
-	var errs []error
-	for _, name := range proxyNames {
-		if err := cli.StopContainer(ctx, name, 1); err != nil {
-			errs = append(errs, fmt.Errorf("failed to stop proxy container %s: %w", name, err))
+// instanceName makes a unique, valid docker resource name out of a server
+// instance name.
+func instanceName(instance string) string {
+	var sb strings.Builder
+	for _, c := range strings.ToLower(instance) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteRune('-')
 		}
 	}
 
-	return errors.Join(errs...)
+	name := strings.Trim(sb.String(), "-_.")
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	if name == "" {
+		return strings.ToLower(randString())
+	}
+	return name + "-" + strings.ToLower(randString())
 }
 
-func removeProxies(ctx context.Context, cli docker.Client, nwNames []string, proxyNames []string) error {
-	logf("    > Removing proxies (%s) and networks (%s)", strings.Join(proxyNames, ", "), strings.Join(nwNames, ", "))
+// removeProxies removes the proxy containers, the internal network of a
+// server instance and releases the shared external network.
+func removeProxies(ctx context.Context, cli docker.Client, intNwName string, proxyNames []string) error {
+	logf("    > Removing proxies (%s) and network %s", strings.Join(proxyNames, ", "), intNwName)
 
 	var errs []error
 	for _, name := range proxyNames {
-		if err := cli.RemoveContainer(ctx, name, true); err != nil {
+		if err := cli.RemoveContainer(ctx, name, true); err != nil && !cerrdefs.IsNotFound(err) {
 			errs = append(errs, fmt.Errorf("failed to remove proxy container %s: %w", name, err))
 		}
 	}
-	for _, nwName := range nwNames {
-		if err := cli.RemoveNetwork(ctx, nwName); err != nil {
-			errs = append(errs, fmt.Errorf("failed to remove network %s: %w", nwName, err))
-		}
+	if err := cli.RemoveNetwork(ctx, intNwName); err != nil && !cerrdefs.IsNotFound(err) {
+		errs = append(errs, fmt.Errorf("failed to remove network %s: %w", intNwName, err))
+	}
+	if err := releaseExtNetwork(ctx, cli); err != nil {
+		errs = append(errs, err)
 	}
 
 	return errors.Join(errs...)
@@ -1,6 +1,7 @@
 package proxies
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"net/netip"
@@ -16,6 +17,8 @@ func (p Protocol) String() string {
 		return "http"
 	case TCP:
 		return "tcp"
+	case UDP:
+		return "udp"
 	default:
 		return "unknown"
 	}
@@ -24,30 +27,80 @@ func (p Protocol) String() string {
 const (
 	HTTP Protocol = iota
 	TCP
+	UDP
 )
 
 // Proxy represents either a L4 (TCP/UDP), or an L7 (HTTP) proxy for a
 // particular hostname:port.
 type Proxy struct {
-	Protocol Protocol // Protocol is either HTTP or TCP
+	Protocol Protocol // Protocol is either HTTP, TCP or UDP
+	// Hostname is a DNS hostname, a wildcard (*.example.com) matching any
+	// subdomain, an IP address or a CIDR range.
 	Hostname string
 	Port     uint16
+	// Methods and PathPrefix restrict plain HTTP requests. Empty means any.
+	Methods    []string
+	PathPrefix string
+}
+
+// IsWildcard tells whether the proxy matches any subdomain of a domain.
+func (p Proxy) IsWildcard() bool {
+	return strings.HasPrefix(p.Hostname, "*.")
+}
+
+// IsCIDR tells whether the proxy matches a range of IP addresses.
+func (p Proxy) IsCIDR() bool {
+	return strings.Contains(p.Hostname, "/")
+}
+
+// Rule returns the allowlist entry enforced by the L7 proxy and the DNS
+// forwarder: "[METHOD|METHOD ]host:port[/path/prefix]".
+func (p Proxy) Rule() string {
+	rule := net.JoinHostPort(p.Hostname, strconv.Itoa(int(p.Port))) + p.PathPrefix
+	if len(p.Methods) > 0 {
+		rule = strings.Join(p.Methods, "|") + " " + rule
+	}
+	return rule
 }
 
 // ParseProxySpec takes a string representing a Proxy spec, and returns a Proxy
 // or an error if the spec is invalid. A proxy spec is a string of the form
-// "hostname:port/protocol", where:
+// "[METHOD,METHOD ]hostname:port[/protocol[/path/prefix]]", where:
 //
-// - hostname is a DNS hostname or an IP address
+// - hostname is a DNS hostname, a wildcard like *.example.com, an IP address
+// or a CIDR range like 10.0.0.0/8 (IPv6 addresses and ranges go in brackets)
 // - port is a port number
-// - protocol is either "http", "https" or "tcp"
+// - protocol is either "http", "https", "tcp" or "udp"
+// - methods and path prefix restrict plain http requests
+//
+// Wildcards, CIDR ranges, methods and paths are not supported for tcp and udp.
+// IP addresses and ranges are returned in their canonical form.
 func ParseProxySpec(spec string) (Proxy, error) {
-	parts := strings.Split(spec, "/")
-	if len(parts) > 2 {
-		return Proxy{}, fmt.Errorf("invalid proxy spec %q", spec)
+	var methods []string
+	rest := spec
+	if before, after, found := strings.Cut(spec, " "); found {
+		for method := range strings.SplitSeq(before, ",") {
+			if !isMethod(method) {
+				return Proxy{}, fmt.Errorf("invalid proxy spec %q: invalid method %q", spec, method)
+			}
+			methods = append(methods, strings.ToUpper(method))
+		}
+		rest = strings.TrimSpace(after)
+	}
+
+	// host:port goes up to the first slash after the port separator, so that
+	// CIDR ranges keep their prefix length.
+	hostPort, tail := rest, ""
+	colon := strings.Index(rest, ":")
+	if strings.HasPrefix(rest, "[") {
+		colon = strings.Index(rest, "]:")
+	}
+	searchFrom := max(colon, 0)
+	if slash := strings.Index(rest[searchFrom:], "/"); slash >= 0 {
+		hostPort, tail = rest[:searchFrom+slash], rest[searchFrom+slash+1:]
 	}
 
-	hostname, portStr, err := net.SplitHostPort(parts[0])
+	hostname, portStr, err := net.SplitHostPort(hostPort)
 	if err != nil {
 		return Proxy{}, fmt.Errorf("invalid proxy spec %q: %w", spec, err)
 	}
@@ -55,13 +108,8 @@ func ParseProxySpec(spec string) (Proxy, error) {
 		return Proxy{}, fmt.Errorf("invalid proxy spec %q: missing port", spec)
 	}
 
-	// Consider the hostname component is a DNS hostname if it's not a valid IP
-	// address.
-	if ip, err := netip.ParseAddr(hostname); err == nil {
-		// If it's an IP, disallow localhost, and multicast addresses.
-		if ip.IsLoopback() || ip.IsMulticast() {
-			return Proxy{}, fmt.Errorf("invalid proxy spec %q: invalid hostname", spec)
-		}
+	if err := validateHostname(hostname); err != nil {
+		return Proxy{}, fmt.Errorf("invalid proxy spec %q: %w", spec, err)
 	}
 
 	port, err := strconv.ParseUint(portStr, 10, 16)
@@ -69,23 +117,86 @@ func ParseProxySpec(spec string) (Proxy, error) {
 		return Proxy{}, fmt.Errorf("invalid proxy spec %q: invalid port", spec)
 	}
 
+	protocolStr, pathPrefix, hasPath := strings.Cut(tail, "/")
 	protocol := HTTP
-	if len(parts) == 2 {
-		switch parts[1] {
-		case "http":
-			protocol = HTTP
-		case "https":
-			protocol = HTTP
-		case "tcp":
-			protocol = TCP
-		default:
-			return Proxy{}, fmt.Errorf("invalid proxy spec %q: invalid protocol", spec)
+	switch protocolStr {
+	case "", "http":
+		protocol = HTTP
+	case "https":
+		protocol = HTTP
+	case "tcp":
+		protocol = TCP
+	case "udp":
+		protocol = UDP
+	default:
+		return Proxy{}, fmt.Errorf("invalid proxy spec %q: invalid protocol", spec)
+	}
+	if hasPath {
+		pathPrefix = "/" + pathPrefix
+	}
+
+	if ip, err := netip.ParseAddr(hostname); err == nil {
+		hostname = ip.Unmap().String()
+	} else if prefix, err := netip.ParsePrefix(hostname); err == nil {
+		hostname = prefix.Masked().String()
+	}
+
+	proxy := Proxy{
+		Protocol:   protocol,
+		Hostname:   hostname,
+		Port:       uint16(port),
+		Methods:    methods,
+		PathPrefix: pathPrefix,
+	}
+
+	if protocol != HTTP && (proxy.IsWildcard() || proxy.IsCIDR()) {
+		return Proxy{}, fmt.Errorf("invalid proxy spec %q: wildcards and CIDR ranges are only supported for http and https", spec)
+	}
+	if (len(methods) > 0 || hasPath) && protocolStr != "http" {
+		return Proxy{}, fmt.Errorf("invalid proxy spec %q: methods and paths can only be restricted for http", spec)
+	}
+
+	return proxy, nil
+}
+
+func validateHostname(hostname string) error {
+	// Consider the hostname component is a DNS hostname if it's not a valid IP
+	// address or range.
+	if ip, err := netip.ParseAddr(hostname); err == nil {
+		// If it's an IP, disallow localhost, multicast and zoned addresses.
+		if ip.IsLoopback() || ip.IsMulticast() || ip.Zone() != "" {
+			return errors.New("invalid hostname")
 		}
+		return nil
 	}
 
-	return Proxy{
-		Protocol: protocol,
-		Hostname: hostname,
-		Port:     uint16(port),
-	}, nil
+	if strings.Contains(hostname, "/") {
+		prefix, err := netip.ParsePrefix(hostname)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR range: %w", err)
+		}
+		if prefix.Addr().IsLoopback() || prefix.Addr().IsMulticast() || prefix.Bits() == 0 {
+			return errors.New("invalid CIDR range")
+		}
+		return nil
+	}
+
+	domain := strings.TrimPrefix(hostname, "*.")
+	if domain == "" || strings.Contains(domain, "*") || !strings.Contains(domain, ".") && hostname != domain {
+		return errors.New("invalid wildcard, expected *.domain")
+	}
+
+	return nil
+}
+
+func isMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for _, c := range method {
+		if (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') {
+			return false
+		}
+	}
+	return true
 }
@@ -28,6 +28,101 @@ func TestParseProxySpec(t *testing.T) {
 			spec:     "localhost:8080/tcp",
 			expProxy: Proxy{Protocol: TCP, Hostname: "localhost", Port: 8080},
 		},
+		{
+			name:     "valid udp spec",
+			spec:     "resolver.example.com:53/udp",
+			expProxy: Proxy{Protocol: UDP, Hostname: "resolver.example.com", Port: 53},
+		},
+		{
+			name:     "valid ipv6 spec",
+			spec:     "[2001:DB8:0::1]:443",
+			expProxy: Proxy{Protocol: HTTP, Hostname: "2001:db8::1", Port: 443},
+		},
+		{
+			name:     "valid ipv6 udp spec",
+			spec:     "[2001:db8::53]:53/udp",
+			expProxy: Proxy{Protocol: UDP, Hostname: "2001:db8::53", Port: 53},
+		},
+		{
+			name:     "valid ipv4-mapped ipv6 spec",
+			spec:     "[::ffff:192.0.2.1]:5432/tcp",
+			expProxy: Proxy{Protocol: TCP, Hostname: "192.0.2.1", Port: 5432},
+		},
+		{
+			name:     "valid spec, default protocol",
+			spec:     "api.github.com:443",
+			expProxy: Proxy{Protocol: HTTP, Hostname: "api.github.com", Port: 443},
+		},
+		{
+			name:     "valid wildcard spec",
+			spec:     "*.githubusercontent.com:443/https",
+			expProxy: Proxy{Protocol: HTTP, Hostname: "*.githubusercontent.com", Port: 443},
+		},
+		{
+			name:     "valid cidr spec",
+			spec:     "10.0.0.0/8:8080/http",
+			expProxy: Proxy{Protocol: HTTP, Hostname: "10.0.0.0/8", Port: 8080},
+		},
+		{
+			name:     "valid ipv6 cidr spec",
+			spec:     "[fd00::/8]:443",
+			expProxy: Proxy{Protocol: HTTP, Hostname: "fd00::/8", Port: 443},
+		},
+		{
+			name:     "valid methods and path spec",
+			spec:     "get,HEAD example.com:80/http/api/v1",
+			expProxy: Proxy{Protocol: HTTP, Hostname: "example.com", Port: 80, Methods: []string{"GET", "HEAD"}, PathPrefix: "/api/v1"},
+		},
+		{
+			name:   "invalid spec, wildcard tcp",
+			spec:   "*.example.com:5432/tcp",
+			expErr: `invalid proxy spec "*.example.com:5432/tcp": wildcards and CIDR ranges are only supported for http and https`,
+		},
+		{
+			name:   "invalid spec, cidr udp",
+			spec:   "10.0.0.0/8:53/udp",
+			expErr: `invalid proxy spec "10.0.0.0/8:53/udp": wildcards and CIDR ranges are only supported for http and https`,
+		},
+		{
+			name:   "invalid spec, ipv6 without brackets",
+			spec:   "2001:db8::1:443",
+			expErr: `invalid proxy spec "2001:db8::1:443"`,
+		},
+		{
+			name:   "invalid spec, ipv6 loopback",
+			spec:   "[::1]:53/udp",
+			expErr: `invalid proxy spec "[::1]:53/udp": invalid hostname`,
+		},
+		{
+			name:   "invalid spec, ipv4-mapped loopback",
+			spec:   "[::ffff:127.0.0.1]:80",
+			expErr: `invalid proxy spec "[::ffff:127.0.0.1]:80": invalid hostname`,
+		},
+		{
+			name:   "invalid spec, zoned ipv6",
+			spec:   "[fe80::1%eth0]:80",
+			expErr: `invalid proxy spec "[fe80::1%eth0]:80": invalid hostname`,
+		},
+		{
+			name:   "invalid spec, path on https",
+			spec:   "example.com:443/https/api",
+			expErr: `invalid proxy spec "example.com:443/https/api": methods and paths can only be restricted for http`,
+		},
+		{
+			name:   "invalid spec, bad wildcard",
+			spec:   "*.com:443",
+			expErr: `invalid proxy spec "*.com:443": invalid wildcard, expected *.domain`,
+		},
+		{
+			name:   "invalid spec, bad cidr",
+			spec:   "10.0.0.0/33:443",
+			expErr: `invalid proxy spec "10.0.0.0/33:443": invalid CIDR range`,
+		},
+		{
+			name:   "invalid spec, bad method",
+			spec:   "GET/POST example.com:80/http",
+			expErr: `invalid proxy spec "GET/POST example.com:80/http": invalid method "GET/POST"`,
+		},
 		{
 			name:   "invalid spec, no port/proto",
 			spec:   "foobar",
@@ -78,3 +173,10 @@ func TestParseProxySpec(t *testing.T) {
 		})
 	}
 }
+
+func TestProxyRule(t *testing.T) {
+	assert.Equal(t, "api.github.com:443", Proxy{Protocol: HTTP, Hostname: "api.github.com", Port: 443}.Rule())
+	assert.Equal(t, "[fd00::/8]:443", Proxy{Protocol: HTTP, Hostname: "fd00::/8", Port: 443}.Rule())
+	assert.Equal(t, "[2001:db8::1]:443", Proxy{Protocol: HTTP, Hostname: "2001:db8::1", Port: 443}.Rule())
+	assert.Equal(t, "GET|HEAD example.com:80/api", Proxy{Protocol: HTTP, Hostname: "example.com", Port: 80, Methods: []string{"GET", "HEAD"}, PathPrefix: "/api"}.Rule())
+}
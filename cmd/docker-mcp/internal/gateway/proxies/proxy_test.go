@@ -0,0 +1,259 @@
+package proxies
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+)
+
+type fakeDocker struct {
+	docker.Client
+
+	mu         sync.Mutex
+	networks   map[string]bool
+	containers map[string][]string // container -> networks
+	logs       map[string]string   // container name prefix -> logs
+	env        map[string][]string // container -> env
+	ipv6       map[string]bool     // network -> IPv6 enabled
+	noIPv6     bool
+}
+
+func newFakeDocker() *fakeDocker {
+	return &fakeDocker{networks: map[string]bool{}, containers: map[string][]string{}, logs: map[string]string{}, env: map[string][]string{}, ipv6: map[string]bool{}}
+}
+
+func (f *fakeDocker) PullImage(context.Context, string) error { return nil }
+
+func (f *fakeDocker) CreateNetwork(_ context.Context, name string, _, ipv6 bool, _ map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ipv6 && f.noIPv6 {
+		return errors.New("no IPv6 address pool")
+	}
+	f.networks[name] = true
+	f.ipv6[name] = ipv6
+	return nil
+}
+
+func (f *fakeDocker) RemoveNetwork(_ context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.networks, name)
+	return nil
+}
+
+func (f *fakeDocker) StartContainer(_ context.Context, name string, config container.Config, _ container.HostConfig, networkingConfig network.NetworkingConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.env[name] = config.Env
+	for nw := range networkingConfig.EndpointsConfig {
+		f.containers[name] = append(f.containers[name], nw)
+	}
+	return nil
+}
+
+func (f *fakeDocker) ContainerExists(context.Context, string) (bool, container.InspectResponse, error) {
+	return true, container.InspectResponse{ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: true}}}, nil
+}
+
+func (f *fakeDocker) InspectContainer(_ context.Context, name string) (container.InspectResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	networks := map[string]*network.EndpointSettings{}
+	for i, nw := range f.containers[name] {
+		networks[nw] = &network.EndpointSettings{IPAddress: "172.18.0." + strconv.Itoa(i+2)}
+	}
+	return container.InspectResponse{NetworkSettings: &container.NetworkSettings{Networks: networks}}, nil
+}
+
+func (f *fakeDocker) RemoveContainer(_ context.Context, name string, _ bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.containers, name)
+	return nil
+}
+
+func (f *fakeDocker) ReadLogs(_ context.Context, name string, _ container.LogsOptions) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for prefix, logs := range f.logs {
+		if strings.HasPrefix(name, prefix) {
+			return io.NopCloser(strings.NewReader(logs)), nil
+		}
+	}
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func TestRunNetworkProxiesIsolation(t *testing.T) {
+	ctx := context.Background()
+	cli := newFakeDocker()
+
+	github, cleanupGithub, err := RunNetworkProxies(ctx, cli, "github", []Proxy{{Protocol: HTTP, Hostname: "api.github.com", Port: 443}}, false, false, nil)
+	require.NoError(t, err)
+	postgres, cleanupPostgres, err := RunNetworkProxies(ctx, cli, "postgres-1234", []Proxy{{Protocol: TCP, Hostname: "db", Port: 5432}}, true, false, nil)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(github.NetworkName, "docker-mcp-proxies-int-github-"))
+	assert.True(t, strings.HasPrefix(postgres.NetworkName, "docker-mcp-proxies-int-postgres-1234-"))
+	assert.NotEqual(t, github.NetworkName, postgres.NetworkName)
+	assert.Len(t, cli.networks, 3)
+	assert.True(t, cli.networks[extNwName])
+
+	// Proxies are only attached to their own internal network.
+	for name, networks := range cli.containers {
+		if strings.Contains(name, github.Instance) {
+			assert.ElementsMatch(t, []string{github.NetworkName, extNwName}, networks)
+		} else {
+			assert.ElementsMatch(t, []string{postgres.NetworkName, extNwName}, networks)
+		}
+	}
+
+	require.NoError(t, cleanupGithub(ctx))
+	require.NoError(t, cleanupGithub(ctx))
+	assert.Equal(t, map[string]bool{postgres.NetworkName: true, extNwName: true}, cli.networks)
+
+	require.NoError(t, cleanupPostgres(ctx))
+	assert.Empty(t, cli.networks)
+	assert.Empty(t, cli.containers)
+}
+
+func TestRunNetworkProxiesEgressEvents(t *testing.T) {
+	ctx := context.Background()
+	cli := newFakeDocker()
+	cli.logs["docker-mcp-proxy-"] = `starting proxy
+{"type":"egress","proxy":"l7","host":"api.github.com","port":443,"method":"CONNECT","bytes_sent":10,"bytes_received":20,"allowed":true}
+{"type":"egress","proxy":"l7","host":"evil.com","port":443,"method":"CONNECT","allowed":false}
+`
+
+	var (
+		mu     sync.Mutex
+		events []EgressEvent
+	)
+	_, cleanup, err := RunNetworkProxies(ctx, cli, "github", []Proxy{{Protocol: HTTP, Hostname: "api.github.com", Port: 443}}, false, false, func(event EgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+	require.NoError(t, err)
+	defer func() { _ = cleanup(ctx) }()
+
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(c, []EgressEvent{
+			{Proxy: "l7", Host: "api.github.com", Port: 443, Method: "CONNECT", BytesSent: 10, BytesReceived: 20, Allowed: true},
+			{Proxy: "l7", Host: "evil.com", Port: 443, Method: "CONNECT"},
+		}, events)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestParseEgressEvent(t *testing.T) {
+	event, ok := ParseEgressEvent(`{"type":"egress","proxy":"l4","host":"db","port":5432,"bytes_sent":1,"bytes_received":2,"allowed":true}` + "\n")
+	require.True(t, ok)
+	assert.Equal(t, EgressEvent{Proxy: "l4", Host: "db", Port: 5432, BytesSent: 1, BytesReceived: 2, Allowed: true}, event)
+
+	for _, line := range []string{
+		"",
+		"[NOTICE] haproxy started",
+		`{"type":"other","host":"db"}`,
+		`{"type":"egress"`,
+	} {
+		_, ok := ParseEgressEvent(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestRunNetworkProxiesSidecar(t *testing.T) {
+	ctx := context.Background()
+	cli := newFakeDocker()
+
+	target, cleanup, err := RunNetworkProxies(ctx, cli, "dns", []Proxy{
+		{Protocol: HTTP, Hostname: "api.github.com", Port: 443},
+		{Protocol: UDP, Hostname: "resolver.example.com", Port: 53},
+		{Protocol: TCP, Hostname: "resolver.example.com", Port: 53},
+		{Protocol: UDP, Hostname: "2001:db8::1", Port: 514},
+	}, false, true, nil)
+	require.NoError(t, err)
+	defer func() { _ = cleanup(ctx) }()
+
+	assert.True(t, cli.ipv6[extNwName])
+	assert.False(t, cli.ipv6[target.NetworkName])
+
+	// A single sidecar runs all the proxies and is the DNS server.
+	proxyName := "docker-mcp-proxy-" + target.Instance
+	assert.Len(t, cli.containers, 1)
+	assert.NotEmpty(t, target.DNS)
+	assert.Equal(t, []string{"http_proxy=" + proxyName + ":8080", "https_proxy=" + proxyName + ":8080"}, target.Env)
+	assert.Equal(t, []string{`PROXY_CONFIG={"httpPort":8080,"allowedHosts":["api.github.com:443"],"forwards":[{"protocol":"udp","host":"resolver.example.com","port":53},{"protocol":"tcp","host":"resolver.example.com","port":53},{"protocol":"udp","host":"2001:db8::1","port":514}],"logDNS":true}`}, cli.env[proxyName])
+}
+
+func TestNewSidecarConfig(t *testing.T) {
+	config, err := newSidecarConfig([]Proxy{
+		{Protocol: HTTP, Hostname: "example.com", Port: 80, Methods: []string{"GET"}},
+		{Protocol: TCP, Hostname: "db", Port: 8080},
+		{Protocol: TCP, Hostname: "db", Port: 8080},
+		{Protocol: TCP, Hostname: "other", Port: 8081},
+	}, false)
+	require.NoError(t, err)
+	assert.Equal(t, sidecarConfig{
+		HTTPPort:     8082,
+		AllowedHosts: []string{"GET example.com:80"},
+		Forwards: []sidecarForward{
+			{Protocol: "tcp", Host: "db", Port: 8080},
+			{Protocol: "tcp", Host: "other", Port: 8081},
+		},
+	}, config)
+
+	_, err = newSidecarConfig([]Proxy{
+		{Protocol: TCP, Hostname: "db1", Port: 5432},
+		{Protocol: TCP, Hostname: "db2", Port: 5432},
+	}, false)
+	require.EqualError(t, err, "db1 and db2 can't both be reached on tcp port 5432")
+}
+
+func TestNewSidecar(t *testing.T) {
+	sidecar, err := NewSidecar([]Proxy{
+		{Protocol: HTTP, Hostname: "api.github.com", Port: 443},
+		{Protocol: TCP, Hostname: "db", Port: 5432},
+		{Protocol: UDP, Hostname: "db", Port: 5353},
+		{Protocol: UDP, Hostname: "10.0.0.1", Port: 514},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, proxyImage, sidecar.Image)
+	assert.Equal(t, 8080, sidecar.HTTPPort)
+	assert.Equal(t, []string{"db"}, sidecar.Hosts)
+	assert.Equal(t, []string{`PROXY_CONFIG={"httpPort":8080,"allowedHosts":["api.github.com:443"],"forwards":[{"protocol":"tcp","host":"db","port":5432},{"protocol":"udp","host":"db","port":5353},{"protocol":"udp","host":"10.0.0.1","port":514}]}`}, sidecar.Env)
+}
+
+func TestRunNetworkProxiesWithoutIPv6(t *testing.T) {
+	ctx := context.Background()
+	cli := newFakeDocker()
+	cli.noIPv6 = true
+
+	_, cleanup, err := RunNetworkProxies(ctx, cli, "github", []Proxy{{Protocol: HTTP, Hostname: "api.github.com", Port: 443}}, false, false, nil)
+	require.NoError(t, err)
+	defer func() { _ = cleanup(ctx) }()
+
+	assert.True(t, cli.networks[extNwName])
+	assert.False(t, cli.ipv6[extNwName])
+}
+
+func TestInstanceName(t *testing.T) {
+	name := instanceName("My Server/abc")
+	assert.Regexp(t, `^my-server-abc-[a-z0-9]{11}$`, name)
+	assert.NotEqual(t, name, instanceName("My Server/abc"))
+	assert.Regexp(t, `^[a-z0-9]{11}$`, instanceName("///"))
+}
@@ -0,0 +1,168 @@
+package proxies
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+)
+
+// proxyImage is built from tools/proxy. It's pinned by digest with
+// `make push-proxy-image`, that pushes the image first.
+const proxyImage = "docker/mcp-proxy:v1"
+
+// sidecarConfig is the configuration of the proxy sidecar, see tools/proxy.
+type sidecarConfig struct {
+	HTTPPort     int              `json:"httpPort,omitempty"`
+	AllowedHosts []string         `json:"allowedHosts,omitempty"`
+	Forwards     []sidecarForward `json:"forwards,omitempty"`
+	LogDNS       bool             `json:"logDNS,omitempty"`
+}
+
+type sidecarForward struct {
+	Protocol string `json:"protocol"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+}
+
+// newSidecarConfig turns a list of proxies into a sidecar configuration. A
+// single sidecar can't forward the same port to two hosts. The HTTP proxy
+// listens on the first port that's not forwarded, from 8080.
+func newSidecarConfig(proxies []Proxy, logDNS bool) (sidecarConfig, error) {
+	config := sidecarConfig{
+		LogDNS: logDNS,
+	}
+
+	hosts := map[string]string{} // protocol/port -> host
+	for _, p := range proxies {
+		if p.Protocol == HTTP {
+			config.AllowedHosts = append(config.AllowedHosts, p.Rule())
+			continue
+		}
+
+		key := p.Protocol.String() + "/" + strconv.Itoa(int(p.Port))
+		if other, found := hosts[key]; found {
+			if other != p.Hostname {
+				return sidecarConfig{}, fmt.Errorf("%s and %s can't both be reached on %s port %d", other, p.Hostname, p.Protocol, p.Port)
+			}
+			continue
+		}
+		hosts[key] = p.Hostname
+
+		config.Forwards = append(config.Forwards, sidecarForward{
+			Protocol: p.Protocol.String(),
+			Host:     p.Hostname,
+			Port:     int(p.Port),
+		})
+	}
+
+	if len(config.AllowedHosts) > 0 {
+		config.HTTPPort = 8080
+		for hosts["tcp/"+strconv.Itoa(config.HTTPPort)] != "" {
+			config.HTTPPort++
+		}
+	}
+
+	return config, nil
+}
+
+// Sidecar describes the proxy sidecar of a server, for deployments where the
+// sidecar isn't started by the gateway, such as a compose project.
+type Sidecar struct {
+	Image string
+	Env   []string
+
+	// HTTPPort is where the HTTP/CONNECT proxy listens, zero if there's none.
+	HTTPPort int
+
+	// Hosts are the hostnames, not IP addresses, whose ports are forwarded.
+	// They must resolve to the sidecar.
+	Hosts []string
+}
+
+// NewSidecar describes the sidecar that runs a set of proxies.
+func NewSidecar(proxies []Proxy) (Sidecar, error) {
+	config, err := newSidecarConfig(proxies, false)
+	if err != nil {
+		return Sidecar{}, err
+	}
+	buf, err := json.Marshal(config)
+	if err != nil {
+		return Sidecar{}, err
+	}
+
+	sidecar := Sidecar{
+		Image:    proxyImage,
+		Env:      []string{"PROXY_CONFIG=" + string(buf)},
+		HTTPPort: config.HTTPPort,
+	}
+	for _, forward := range config.Forwards {
+		if net.ParseIP(forward.Host) == nil && !slices.Contains(sidecar.Hosts, forward.Host) {
+			sidecar.Hosts = append(sidecar.Hosts, forward.Host)
+		}
+	}
+
+	return sidecar, nil
+}
+
+// runSidecar starts the proxy sidecar of a server instance. It's attached to
+// both the internal network, where it serves the MCP server, and the external
+// network. It updates the target config with the http proxy to use.
+func runSidecar(ctx context.Context, cli docker.Client, target *TargetConfig, extNwName string, proxies []Proxy, keepCtrs, logDNS bool) (string, error) {
+	config, err := newSidecarConfig(proxies, logDNS)
+	if err != nil {
+		return "", err
+	}
+	buf, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+
+	if err := cli.PullImage(ctx, proxyImage); err != nil {
+		return "", fmt.Errorf("pulling image %s: %w", proxyImage, err)
+	}
+
+	proxyName := "docker-mcp-proxy-" + target.Instance
+	if config.HTTPPort != 0 {
+		httpProxy := proxyName + ":" + strconv.Itoa(config.HTTPPort)
+		target.Env = append(target.Env, "http_proxy="+httpProxy, "https_proxy="+httpProxy)
+	}
+
+	logf("    - Starting proxy %s", proxyName)
+
+	err = cli.StartContainer(ctx, proxyName,
+		container.Config{
+			Image: proxyImage,
+			Env: []string{
+				"PROXY_CONFIG=" + string(buf),
+			},
+			Labels: map[string]string{
+				"docker-mcp":                "true",
+				"docker-mcp-proxy":          "true",
+				"docker-mcp-proxy-instance": target.Instance,
+			},
+		},
+		container.HostConfig{
+			NetworkMode: container.NetworkMode(target.NetworkName),
+			AutoRemove:  !keepCtrs,
+		},
+		network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				target.NetworkName: {},
+				extNwName:          {},
+			},
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return proxyName, nil
+}
@@ -10,7 +10,7 @@ import (
 )
 
 func (g *Gateway) pullAndVerify(ctx context.Context, configuration Configuration) error {
-	dockerImages := configuration.DockerImages()
+	dockerImages := configuration.DockerImages(nil)
 	if len(dockerImages) == 0 {
 		return nil
 	}
@@ -25,7 +25,8 @@ func (g *Gateway) pullAndVerify(ctx context.Context, configuration Configuration
 		}
 	}
 
-	if err := g.pullImages(ctx, dockerImages); err != nil {
+	// Podman pulls its own images.
+	if err := g.pullImages(ctx, configuration.DockerImages(g.clientPool.onDocker)); err != nil {
 		return err
 	}
 
@@ -37,6 +38,10 @@ func (g *Gateway) pullAndVerify(ctx context.Context, configuration Configuration
 }
 
 func (g *Gateway) pullImages(ctx context.Context, images []string) error {
+	if len(images) == 0 {
+		return nil
+	}
+
 	start := time.Now()
 
 	if err := g.docker.PullImages(ctx, images...); err != nil {
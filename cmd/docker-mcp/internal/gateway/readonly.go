@@ -0,0 +1,202 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// readOnlyHeader lets HTTP clients ask for a read-only session.
+	readOnlyHeader = "X-MCP-Read-Only"
+
+	// readOnlyToolName is the gateway tool that switches a session to read-only mode.
+	readOnlyToolName = "mcp-read-only"
+)
+
+// isToolReadOnly tells whether a tool is annotated read-only or allow-listed.
+func isToolReadOnly(serverName string, tool *mcp.Tool, allowList []string) bool {
+	if tool.Annotations != nil && tool.Annotations.ReadOnlyHint {
+		return true
+	}
+
+	for _, allowed := range allowList {
+		if allowed == "*" ||
+			strings.EqualFold(allowed, tool.Name) ||
+			strings.EqualFold(allowed, serverName+":"+tool.Name) ||
+			strings.EqualFold(allowed, serverName+":*") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSessionReadOnly tells whether a client session is in read-only mode.
+func (g *Gateway) isSessionReadOnly(ss *mcp.ServerSession) bool {
+	if g.ReadOnly {
+		return true
+	}
+	if ss == nil {
+		return false
+	}
+
+	g.sessionCacheMu.RLock()
+	defer g.sessionCacheMu.RUnlock()
+
+	cache, exists := g.sessionCache[ss]
+	return exists && cache.ReadOnly
+}
+
+// setSessionReadOnly switches a client session to read-only mode. There's no way back.
+func (g *Gateway) setSessionReadOnly(ss *mcp.ServerSession) {
+	g.sessionCacheMu.Lock()
+	cache, exists := g.sessionCache[ss]
+	if !exists {
+		cache = &ServerSessionCache{}
+		g.sessionCache[ss] = cache
+	}
+	cache.ReadOnly = true
+	g.sessionCacheMu.Unlock()
+
+	// Long-lived servers might have been started with writable mounts.
+	g.clientPool.CloseSession(ss)
+}
+
+func (g *Gateway) isRegisteredToolReadOnly(toolName string) bool {
+	g.sessionCacheMu.RLock()
+	defer g.sessionCacheMu.RUnlock()
+
+	return g.readOnlyToolNames[toolName]
+}
+
+// readOnlyMiddleware hides and blocks the tools that are not read-only
+// from the sessions in read-only mode.
+func (g *Gateway) readOnlyMiddleware() mcp.Middleware[*mcp.ServerSession] {
+	return func(next mcp.MethodHandler[*mcp.ServerSession]) mcp.MethodHandler[*mcp.ServerSession] {
+		return func(ctx context.Context, session *mcp.ServerSession, method string, params mcp.Params) (mcp.Result, error) {
+			switch method {
+			case "initialize":
+				// The session was opened by an HTTP request with the X-MCP-Read-Only header.
+				if readOnly, _ := ctx.Value(readOnlyRequestKey{}).(bool); readOnly && !g.isSessionReadOnly(session) {
+					g.setSessionReadOnly(session)
+					log("- New session is read-only")
+				}
+				return next(ctx, session, method, params)
+
+			case "tools/list":
+				result, err := next(ctx, session, method, params)
+				if err != nil || !g.isSessionReadOnly(session) {
+					return result, err
+				}
+
+				if listResult, ok := result.(*mcp.ListToolsResult); ok {
+					var tools []*mcp.Tool
+					for _, tool := range listResult.Tools {
+						if g.isRegisteredToolReadOnly(tool.Name) {
+							tools = append(tools, tool)
+						}
+					}
+					listResult.Tools = tools
+				}
+				return result, nil
+
+			case "tools/call":
+				if !g.isSessionReadOnly(session) {
+					return next(ctx, session, method, params)
+				}
+
+				var toolName string
+				if jsonData, err := json.Marshal(params); err == nil {
+					var callParams mcp.CallToolParams
+					if err := json.Unmarshal(jsonData, &callParams); err == nil {
+						toolName = callParams.Name
+					}
+				}
+				if !g.isRegisteredToolReadOnly(toolName) {
+					return nil, fmt.Errorf("tool %s is not available in read-only mode", toolName)
+				}
+				return next(ctx, session, method, params)
+
+			default:
+				return next(ctx, session, method, params)
+			}
+		}
+	}
+}
+
+// readOnlyToolRegistration is the gateway tool that lets an agent give up
+// write access for the rest of its session.
+func (g *Gateway) readOnlyToolRegistration() ToolRegistration {
+	return ToolRegistration{
+		Tool: &mcp.Tool{
+			Name:        readOnlyToolName,
+			Description: "Switch this session to read-only mode. Only the tools that don't modify their environment stay available and every volume is mounted read-only. This can't be undone for the session.",
+			InputSchema: &jsonschema.Schema{
+				Type:       "object",
+				Properties: map[string]*jsonschema.Schema{},
+			},
+			Annotations: &mcp.ToolAnnotations{
+				Title: "Read-only mode",
+			},
+		},
+		ReadOnly: true,
+		Handler: func(ctx context.Context, ss *mcp.ServerSession, _ *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
+			if !g.isSessionReadOnly(ss) {
+				g.setSessionReadOnly(ss)
+				log("- Session", ss.ID(), "switched to read-only mode")
+				_ = mcp.HandleNotify(ctx, ss, "notifications/tools/list_changed", &mcp.ToolListChangedParams{})
+			}
+
+			return &mcp.CallToolResultFor[any]{
+				Content: []mcp.Content{&mcp.TextContent{Text: "This session is now read-only."}},
+			}, nil
+		},
+	}
+}
+
+// readOnlyRequestKey marks the context of an HTTP request that asked for a read-only session.
+type readOnlyRequestKey struct{}
+
+// readOnlyHandler switches the HTTP sessions that ask for it with the
+// X-MCP-Read-Only header to read-only mode.
+//
+// The SDK connects a new session with the context of the request that opens it:
+// the POST of the initialize request with the streaming transport, the GET of
+// the event stream with SSE. The context is marked and the session is switched
+// when it's initialized. An existing streaming session is looked up by its id.
+// SSE sessions have no id and SSE messages aren't handled with the context of
+// their POST: the header has to be set when the event stream is opened.
+func (g *Gateway) readOnlyHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		readOnly, _ := strconv.ParseBool(r.Header.Get(readOnlyHeader))
+		if !readOnly {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if sessionID := r.Header.Get("Mcp-Session-Id"); sessionID != "" {
+			if ss := g.sessionByID(sessionID); ss != nil && !g.isSessionReadOnly(ss) {
+				g.setSessionReadOnly(ss)
+				log("- Session", sessionID, "is read-only")
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), readOnlyRequestKey{}, true)))
+	})
+}
+
+func (g *Gateway) sessionByID(sessionID string) *mcp.ServerSession {
+	for ss := range g.mcpServer.Sessions() {
+		if ss.ID() == sessionID {
+			return ss
+		}
+	}
+	return nil
+}
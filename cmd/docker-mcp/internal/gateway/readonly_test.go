@@ -0,0 +1,189 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/gateway/proxies"
+)
+
+func TestIsToolReadOnly(t *testing.T) {
+	annotated := &mcp.Tool{Name: "get_file", Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true}}
+	plain := &mcp.Tool{Name: "search"}
+
+	assert.True(t, isToolReadOnly("github", annotated, nil))
+	assert.False(t, isToolReadOnly("github", plain, nil))
+	assert.True(t, isToolReadOnly("github", plain, []string{"search"}))
+	assert.True(t, isToolReadOnly("github", plain, []string{"github:search"}))
+	assert.True(t, isToolReadOnly("github", plain, []string{"github:*"}))
+	assert.False(t, isToolReadOnly("github", plain, []string{"gitlab:*"}))
+}
+
+func TestApplyConfigReadOnlyGateway(t *testing.T) {
+	clientPool := &clientPool{
+		Options: Options{
+			Cpus:     1,
+			Memory:   "2Gb",
+			ReadOnly: true,
+		},
+	}
+
+	args, _, err := clientPool.argsAndEnv(&catalog.ServerConfig{
+		Name: "fs",
+		Spec: parseSpec(t, `
+volumes:
+  - /local/data:/data
+  - /local/cache:/cache:rw
+`),
+	}, nil, proxies.TargetConfig{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"run", "--rm", "-i", "--init", "--security-opt", "no-new-privileges", "--cpus", "1", "--memory", "2Gb", "--pull", "never",
+		"-l", "docker-mcp=true", "-l", "docker-mcp-tool-type=mcp", "-l", "docker-mcp-name=fs", "-l", "docker-mcp-transport=stdio",
+		"-v", "/local/data:/data:ro",
+		"-v", "/local/cache:/cache:ro",
+	}, args)
+}
+
+func TestReadOnlyMiddleware(t *testing.T) {
+	g := &Gateway{
+		Options:           Options{ReadOnly: true},
+		readOnlyToolNames: map[string]bool{"get_file": true},
+	}
+
+	list := func(_ context.Context, _ *mcp.ServerSession, _ string, _ mcp.Params) (mcp.Result, error) {
+		return &mcp.ListToolsResult{Tools: []*mcp.Tool{{Name: "get_file"}, {Name: "delete_file"}}}, nil
+	}
+	result, err := g.readOnlyMiddleware()(list)(context.Background(), nil, "tools/list", &mcp.ListToolsParams{})
+	require.NoError(t, err)
+	tools := result.(*mcp.ListToolsResult).Tools
+	require.Len(t, tools, 1)
+	assert.Equal(t, "get_file", tools[0].Name)
+
+	call := func(_ context.Context, _ *mcp.ServerSession, _ string, _ mcp.Params) (mcp.Result, error) {
+		return &mcp.CallToolResult{}, nil
+	}
+	_, err = g.readOnlyMiddleware()(call)(context.Background(), nil, "tools/call", &mcp.CallToolParams{Name: "get_file"})
+	require.NoError(t, err)
+	_, err = g.readOnlyMiddleware()(call)(context.Background(), nil, "tools/call", &mcp.CallToolParams{Name: "delete_file"})
+	require.ErrorContains(t, err, "tool delete_file is not available in read-only mode")
+}
+
+// headerTransport adds the read-only header to the requests once it's enabled.
+type headerTransport struct {
+	readOnly bool
+}
+
+func (t *headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if t.readOnly {
+		r = r.Clone(r.Context())
+		r.Header.Set(readOnlyHeader, "true")
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func newReadOnlyTestGateway() *Gateway {
+	g := &Gateway{
+		sessionCache:      map[*mcp.ServerSession]*ServerSessionCache{},
+		readOnlyToolNames: map[string]bool{"get_file": true},
+		clientPool:        &clientPool{keptClients: map[clientKey]keptClient{}},
+	}
+
+	g.mcpServer = mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	for _, name := range []string{"get_file", "delete_file"} {
+		g.mcpServer.AddTool(&mcp.Tool{Name: name, InputSchema: &jsonschema.Schema{Type: "object"}}, func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
+			return &mcp.CallToolResultFor[any]{}, nil
+		})
+	}
+	g.mcpServer.AddReceivingMiddleware(g.readOnlyMiddleware())
+
+	return g
+}
+
+func listToolNames(t *testing.T, session *mcp.ClientSession) []string {
+	t.Helper()
+
+	result, err := session.ListTools(t.Context(), nil)
+	require.NoError(t, err)
+
+	var names []string
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestReadOnlyHeaderSSE(t *testing.T) {
+	g := newReadOnlyTestGateway()
+	server := httptest.NewServer(g.readOnlyHandler(mcp.NewSSEHandler(func(*http.Request) *mcp.Server { return g.mcpServer })))
+	t.Cleanup(server.Close)
+
+	connect := func(readOnly bool) *mcp.ClientSession {
+		httpClient := &http.Client{Transport: &headerTransport{readOnly: readOnly}}
+		session, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(t.Context(), mcp.NewSSEClientTransport(server.URL, &mcp.SSEClientTransportOptions{HTTPClient: httpClient}))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = session.Close() })
+		return session
+	}
+
+	assert.ElementsMatch(t, []string{"get_file", "delete_file"}, listToolNames(t, connect(false)))
+	assert.Equal(t, []string{"get_file"}, listToolNames(t, connect(true)))
+}
+
+func TestReadOnlyHeaderStreaming(t *testing.T) {
+	g := newReadOnlyTestGateway()
+	server := httptest.NewServer(g.readOnlyHandler(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return g.mcpServer }, nil)))
+	t.Cleanup(server.Close)
+
+	connect := func(transport *headerTransport) *mcp.ClientSession {
+		httpClient := &http.Client{Transport: transport}
+		session, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(t.Context(), mcp.NewStreamableClientTransport(server.URL, &mcp.StreamableClientTransportOptions{HTTPClient: httpClient}))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = session.Close() })
+		return session
+	}
+
+	// A new session asking to be read-only
+	assert.Equal(t, []string{"get_file"}, listToolNames(t, connect(&headerTransport{readOnly: true})))
+
+	// An existing session asking to be read-only
+	transport := &headerTransport{}
+	session := connect(transport)
+	assert.ElementsMatch(t, []string{"get_file", "delete_file"}, listToolNames(t, session))
+	transport.readOnly = true
+	assert.Equal(t, []string{"get_file"}, listToolNames(t, session))
+}
+
+func TestForgetSession(t *testing.T) {
+	g := newReadOnlyTestGateway()
+	g.mcpServer = mcp.NewServer(&mcp.Implementation{Name: "test"}, &mcp.ServerOptions{
+		InitializedHandler: func(_ context.Context, ss *mcp.ServerSession, _ *mcp.InitializedParams) {
+			go g.forgetSession(ss)
+		},
+	})
+	g.mcpServer.AddReceivingMiddleware(g.readOnlyMiddleware())
+	server := httptest.NewServer(g.readOnlyHandler(mcp.NewSSEHandler(func(*http.Request) *mcp.Server { return g.mcpServer })))
+	t.Cleanup(server.Close)
+
+	httpClient := &http.Client{Transport: &headerTransport{readOnly: true}}
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(t.Context(), mcp.NewSSEClientTransport(server.URL, &mcp.SSEClientTransportOptions{HTTPClient: httpClient}))
+	require.NoError(t, err)
+	require.Len(t, g.sessionCache, 1)
+
+	require.NoError(t, session.Close())
+	assert.Eventually(t, func() bool {
+		g.sessionCacheMu.RLock()
+		defer g.sessionCacheMu.RUnlock()
+		return len(g.sessionCache) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -12,14 +12,23 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"go.opentelemetry.io/otel"
 
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/audit"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/config"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/dataflow"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/health"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/interceptors"
 	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/telemetry"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/toollock"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/toolscan"
 )
 
 type ServerSessionCache struct {
-	Roots []*mcp.Root
+	Roots    []*mcp.Root
+	Taint    *dataflow.Taint
+	ReadOnly bool
+	// Elicited are the missing values the client gave, by server.
+	Elicited map[string]elicitedValues
 }
 
 // type SubsAction int
@@ -42,10 +51,17 @@ type Gateway struct {
 	clientPool   *clientPool
 	mcpServer    *mcp.Server
 	health       health.State
+	toolScan     toolscan.Action
+	toolLock     toollock.Mode
+	audit        *audit.Logger
+
+	dataFlow          dataflow.Mode
+	dataFlowOverrides dataflow.Overrides
 	// subsChannel  chan SubsMessage
 
-	sessionCacheMu sync.RWMutex
-	sessionCache   map[*mcp.ServerSession]*ServerSessionCache
+	sessionCacheMu    sync.RWMutex
+	sessionCache      map[*mcp.ServerSession]*ServerSessionCache
+	readOnlyToolNames map[string]bool
 
 	// Track registered capabilities for cleanup during reload
 	registeredToolNames            []string
@@ -55,7 +71,7 @@ type Gateway struct {
 }
 
 func NewGateway(config Config, docker docker.Client) *Gateway {
-	return &Gateway{
+	g := &Gateway{
 		Options: config.Options,
 		docker:  docker,
 		configurator: &FileBasedConfiguration{
@@ -64,14 +80,20 @@ func NewGateway(config Config, docker docker.Client) *Gateway {
 			RegistryPath: config.RegistryPath,
 			ConfigPath:   config.ConfigPath,
 			SecretsPath:  config.SecretsPath,
+			PolicyPath:   config.PolicyPath,
 			ToolsPath:    config.ToolsPath,
 			Watch:        config.Watch,
 			Central:      config.Central,
 			docker:       docker,
+
+			SecretsRefresh: config.SecretsRefresh,
 		},
 		clientPool:   newClientPool(config.Options, docker),
 		sessionCache: make(map[*mcp.ServerSession]*ServerSessionCache),
 	}
+	g.clientPool.onEgress = g.recordEgress
+
+	return g
 }
 
 func (g *Gateway) Run(ctx context.Context) error {
@@ -135,6 +157,74 @@ func (g *Gateway) Run(ctx context.Context) error {
 		log("- Interceptors enabled:", strings.Join(g.Interceptors, ", "))
 	}
 
+	// Tool poisoning scanner
+	g.toolScan, err = toolscan.ParseAction(g.ToolScan)
+	if err != nil {
+		return err
+	}
+	if g.toolScan != toolscan.ActionOff {
+		log("- Scanning tools for poisoning, action:", g.toolScan)
+	}
+
+	// Tool definition pinning
+	g.toolLock, err = toollock.ParseMode(g.PinTools)
+	if err != nil {
+		return err
+	}
+	if g.toolLock != toollock.ModeOff {
+		log("- Pinning tool definitions, mode:", g.toolLock)
+	}
+
+	// Audit log
+	if g.AuditLog != "" {
+		path, err := config.FilePath(g.AuditLog)
+		if err != nil {
+			return err
+		}
+		g.audit, err = audit.OpenFile(path)
+		if err != nil {
+			return fmt.Errorf("opening audit log: %w", err)
+		}
+		defer g.audit.Close()
+		log("- Writing audit log to", path)
+	}
+
+	// Session taint tracking
+	g.dataFlow, err = dataflow.ParseMode(g.DataFlowPolicy)
+	if err != nil {
+		return err
+	}
+	if g.dataFlow != dataflow.ModeOff {
+		g.dataFlowOverrides, err = readDataFlowOverrides(g.DataFlowLabels)
+		if err != nil {
+			return fmt.Errorf("reading data flow labels: %w", err)
+		}
+		log("- Tracking data flows between servers, policy:", g.dataFlow)
+	}
+
+	// Read-only mode
+	if g.ReadOnly {
+		log("- Read-only mode: only read-only tools are available and volumes are mounted read-only")
+	}
+
+	// Container hardening
+	if err := validateHardeningProfile(g.Hardening); err != nil {
+		return err
+	}
+	if g.Hardening != "" && g.Hardening != hardeningDefault {
+		log("- Hardening profile:", g.Hardening)
+	}
+
+	// Container runtime
+	if err := validateRuntime(g.Runtime); err != nil {
+		return err
+	}
+
+	// Servers that are missing secrets or config values
+	if err := validateMissingValues(g.MissingValues); err != nil {
+		return err
+	}
+
 	g.mcpServer = mcp.NewServer(&mcp.Implementation{
 		Name:    "Docker AI MCP Gateway",
 		Version: "2.0.1",
@@ -160,6 +250,7 @@ func (g *Gateway) Run(ctx context.Context) error {
 		CompletionHandler: nil,
 		InitializedHandler: func(_ context.Context, ss *mcp.ServerSession, _ *mcp.InitializedParams) {
 			log("- Client initialized: ", ss.ID())
+			go g.forgetSession(ss)
 		},
 		HasPrompts:   true,
 		HasResources: true,
@@ -168,10 +259,31 @@ func (g *Gateway) Run(ctx context.Context) error {
 
 	// Add interceptor middleware to the server (includes telemetry)
 	middlewares := interceptors.Callbacks(g.LogCalls, g.BlockSecrets, g.OAuthInterceptorEnabled, parsedInterceptors)
+	middlewares = append(middlewares, g.readOnlyMiddleware())
+	if g.ScanToolResults && g.toolScan != toolscan.ActionOff {
+		middlewares = append(middlewares, interceptors.ScanToolResultsMiddleware(g.toolScan))
+	}
 	if len(middlewares) > 0 {
 		g.mcpServer.AddReceivingMiddleware(middlewares...)
 	}
 
+	// Show every mount policy violation, not only the first one of each server.
+	if g.DryRun {
+		if violations := g.mountViolations(configuration); len(violations) > 0 {
+			log("- Mount policy violations:")
+			for _, violation := range violations {
+				log("  ! " + violation)
+			}
+		}
+	}
+
+	if g.BlockNetwork || g.DryRun {
+		log("- Network egress:")
+		for _, egress := range g.egressReport(configuration) {
+			log("  - " + egress)
+		}
+	}
+
 	if err := g.reloadConfiguration(ctx, configuration, nil); err != nil {
 		return fmt.Errorf("loading configuration: %w", err)
 	}
@@ -194,20 +306,28 @@ func (g *Gateway) Run(ctx context.Context) error {
 		if err := g.pullAndVerify(ctx, configuration); err != nil {
 			return err
 		}
+	}
 
-		// When running in a container, find on which network we are running.
-		if os.Getenv("DOCKER_MCP_IN_CONTAINER") == "1" {
-			networks, err := g.guessNetworks(ctx)
-			if err != nil {
-				return fmt.Errorf("guessing network: %w", err)
-			}
+	// When running in a container, find on which network we are running.
+	// That's also where pre-started servers are reachable, in static mode.
+	if os.Getenv("DOCKER_MCP_IN_CONTAINER") == "1" {
+		networks, project, err := g.guessNetworks(ctx)
+		switch {
+		case err == nil:
 			g.clientPool.SetNetworks(networks)
+			g.clientPool.SetComposeProject(project)
+		case g.Static:
+			// Pre-started servers are still reachable by their service name.
+			log("  > Can't guess the network, without the Docker API:", err)
+		default:
+			return fmt.Errorf("guessing network: %w", err)
 		}
 	}
 
 	// Optionally watch for configuration updates.
 	if configurationUpdates != nil {
 		log("- Watching for configuration updates...")
+		current := configuration
 		go func() {
 			for {
 				select {
@@ -226,6 +346,20 @@ func (g *Gateway) Run(ctx context.Context) error {
 						logf("> Unable to list capabilities: %s", err)
 						continue
 					}
+
+					// Long-lived servers would keep the previous values.
+					// Only the names of the secrets are ever logged.
+					rotated := configuration.rotatedSecrets(current)
+					policyChanged := configuration.policyChanged(current)
+					current = configuration
+					if len(rotated) > 0 {
+						log("> Secrets rotated:", strings.Join(rotated, ", "))
+						g.clientPool.RestartServers(configuration.serversUsingSecrets(rotated))
+					}
+					if policyChanged {
+						log("> Secret policy changed")
+						g.clientPool.RestartServers(configuration.serversUsingSecrets(secretNames(configuration.servers, configuration.serverNames, nil)))
+					}
 				}
 			}
 		}()
@@ -267,6 +401,14 @@ func (g *Gateway) reloadConfiguration(ctx context.Context, configuration Configu
 		log("- Those servers are enabled:", strings.Join(serverNames, ", "))
 	}
 
+	// The clients get the secrets that the policy allows, if any.
+	g.clientPool.SetSecretPolicy(configuration.policy)
+
+	// Pre-started servers have their own secrets and config.
+	if !g.Static {
+		serverNames = g.preflightServers(configuration, serverNames)
+	}
+
 	// List all the available tools.
 	startList := time.Now()
 	log("- Listing MCP tools...")
@@ -300,6 +442,21 @@ func (g *Gateway) reloadConfiguration(ctx context.Context, configuration Configu
 	g.registeredResourceURIs = nil
 	g.registeredResourceTemplateURIs = nil
 
+	// The gateway's own tools
+	if g.ReadOnlyTool && !g.ReadOnly {
+		capabilities.Tools = append(capabilities.Tools, g.readOnlyToolRegistration())
+	}
+
+	readOnlyToolNames := map[string]bool{}
+	for _, tool := range capabilities.Tools {
+		if tool.ReadOnly {
+			readOnlyToolNames[tool.Tool.Name] = true
+		}
+	}
+	g.sessionCacheMu.Lock()
+	g.readOnlyToolNames = readOnlyToolNames
+	g.sessionCacheMu.Unlock()
+
 	// Add new capabilities and track them
 	for _, tool := range capabilities.Tools {
 		g.mcpServer.AddTool(tool.Tool, tool.Handler)
@@ -348,6 +505,13 @@ func (g *Gateway) RemoveSessionCache(ss *mcp.ServerSession) {
 	delete(g.sessionCache, ss)
 }
 
+// forgetSession waits for a server session to close, then drops its cache and its long-lived clients.
+func (g *Gateway) forgetSession(ss *mcp.ServerSession) {
+	_ = ss.Wait()
+	g.RemoveSessionCache(ss)
+	g.clientPool.CloseSession(ss)
+}
+
 // ListRoots checks if client supports Roots, gets them, and caches the result
 func (g *Gateway) ListRoots(ctx context.Context, ss *mcp.ServerSession) {
 	// Check if client supports Roots and get them if available
@@ -0,0 +1,86 @@
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/runtime"
+)
+
+const (
+	// runtimeDocker runs the servers through the Docker Engine API.
+	runtimeDocker = "docker"
+
+	// runtimeDockerCLI runs the servers with the `docker` CLI binary.
+	runtimeDockerCLI = "docker-cli"
+
+	// runtimePodman runs the servers with Podman, through its API or its CLI.
+	runtimePodman = "podman"
+
+	// runtimeProcess runs the servers that are local processes.
+	runtimeProcess = "process"
+)
+
+func validateRuntime(name string) error {
+	switch name {
+	case "", runtimeDocker, runtimeDockerCLI, runtimePodman:
+		return nil
+	default:
+		return fmt.Errorf("unknown runtime %q, expected %s, %s or %s", name, runtimeDocker, runtimeDockerCLI, runtimePodman)
+	}
+}
+
+func newRuntime(name string, docker docker.Client) runtime.Runtime {
+	switch name {
+	case runtimeDockerCLI:
+		return runtime.NewCLI()
+	case runtimePodman:
+		return runtime.NewPodman()
+	default:
+		return runtime.NewEngine(docker.APIClient())
+	}
+}
+
+// serverRuntime picks the runtime of a server. Local processes aside, servers
+// can pick their own or use the gateway's.
+func (cp *clientPool) serverRuntime(spec *catalog.Server) string {
+	switch {
+	case spec.Process != nil:
+		return runtimeProcess
+	case spec.Runtime != "":
+		return spec.Runtime
+	case cp.Runtime != "":
+		return cp.Runtime
+	default:
+		return runtimeDocker
+	}
+}
+
+// onDocker tells whether a server runs on the Docker Engine, where the gateway
+// pulls its image and can run its network proxies.
+func (cp *clientPool) onDocker(spec *catalog.Server) bool {
+	name := cp.serverRuntime(spec)
+	return name == runtimeDocker || name == runtimeDockerCLI
+}
+
+// runtimeFor returns the runtime of a server. Container runtimes are shared.
+func (cp *clientPool) runtimeFor(spec *catalog.Server) (runtime.Runtime, error) {
+	name := cp.serverRuntime(spec)
+	if name == runtimeProcess {
+		return runtime.NewProcess(spec.Process.Sandbox), nil
+	}
+	if err := validateRuntime(name); err != nil {
+		return nil, err
+	}
+
+	cp.runtimesLock.Lock()
+	defer cp.runtimesLock.Unlock()
+
+	rt, found := cp.runtimes[name]
+	if !found {
+		rt = newRuntime(name, cp.docker)
+		cp.runtimes[name] = rt
+	}
+	return rt, nil
+}
@@ -0,0 +1,120 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/runtime"
+)
+
+type fakeRuntime struct {
+	runtime.Runtime
+	out  string
+	err  error
+	spec runtime.Spec
+}
+
+func (r *fakeRuntime) Run(_ context.Context, spec runtime.Spec) ([]byte, error) {
+	r.spec = spec
+	return []byte(r.out), r.err
+}
+
+func runTool(t *testing.T, rt runtime.Runtime) *mcp.CallToolResult {
+	t.Helper()
+
+	cp := newClientPool(Options{}, nil)
+	cp.runtimes[runtimeDocker] = rt
+
+	tool := catalog.Tool{
+		Name: "curl",
+		Container: catalog.Container{
+			Image:   "curlimages/curl",
+			Command: []string{"{{url}}"},
+		},
+	}
+	result, err := cp.runToolContainer(t.Context(), "curl", tool, &mcp.CallToolParams{
+		Arguments: map[string]any{"url": "https://example.com"},
+	}, false)
+	require.NoError(t, err)
+
+	return result
+}
+
+func TestRunToolContainer(t *testing.T) {
+	rt := &fakeRuntime{out: "<html>"}
+
+	result := runTool(t, rt)
+
+	assert.False(t, result.IsError)
+	assert.Equal(t, "<html>", result.Content[0].(*mcp.TextContent).Text)
+	assert.Equal(t, "curl", rt.spec.Name)
+	assert.Equal(t, "curlimages/curl", rt.spec.Image)
+	assert.Equal(t, []string{"https://example.com"}, rt.spec.Command)
+	assert.Equal(t, "--rm", rt.spec.Args[0])
+}
+
+func TestRunToolContainerExitError(t *testing.T) {
+	rt := &fakeRuntime{out: "curl: (6) Could not resolve host", err: &runtime.ExitError{Name: "curl", ExitCode: 6}}
+
+	result := runTool(t, rt)
+
+	assert.True(t, result.IsError)
+	assert.Equal(t, "curl: (6) Could not resolve host", result.Content[0].(*mcp.TextContent).Text)
+}
+
+func TestValidateRuntime(t *testing.T) {
+	require.NoError(t, validateRuntime(""))
+	require.NoError(t, validateRuntime("docker"))
+	require.NoError(t, validateRuntime("docker-cli"))
+	require.NoError(t, validateRuntime("podman"))
+	require.Error(t, validateRuntime("containerd"))
+}
+
+func TestServerRuntime(t *testing.T) {
+	cp := newClientPool(Options{}, nil)
+	assert.Equal(t, "docker", cp.serverRuntime(&catalog.Server{}))
+	assert.Equal(t, "podman", cp.serverRuntime(&catalog.Server{Runtime: "podman"}))
+	assert.Equal(t, "process", cp.serverRuntime(&catalog.Server{Process: &catalog.Process{Command: []string{"uvx", "mcp-server-time"}}}))
+
+	cp = newClientPool(Options{Runtime: "podman"}, nil)
+	assert.Equal(t, "podman", cp.serverRuntime(&catalog.Server{}))
+	assert.Equal(t, "docker-cli", cp.serverRuntime(&catalog.Server{Runtime: "docker-cli"}))
+	assert.False(t, cp.onDocker(&catalog.Server{}))
+	assert.True(t, cp.onDocker(&catalog.Server{Runtime: "docker-cli"}))
+}
+
+func TestApplyConfigPodman(t *testing.T) {
+	args, _ := argsAndEnv(t, "time", "runtime: podman", "", nil, nil)
+
+	assert.Equal(t, []string{
+		"run", "--rm", "-i", "--init", "--security-opt", "no-new-privileges", "--cpus", "1", "--memory", "2Gb", "--pull", "missing",
+		"-l", "docker-mcp=true", "-l", "docker-mcp-tool-type=mcp", "-l", "docker-mcp-name=time", "-l", "docker-mcp-transport=stdio",
+	}, args)
+}
+
+func TestProcessServerAllowHosts(t *testing.T) {
+	serverConfig := &catalog.ServerConfig{
+		Name: "fetch",
+		Spec: catalog.Server{
+			Process:    &catalog.Process{Command: []string{"uvx", "mcp-server-fetch"}, Sandbox: true},
+			AllowHosts: []string{"example.com:443"},
+		},
+	}
+
+	cp := newClientPool(Options{BlockNetwork: true}, nil)
+	_, err := newClientGetter(serverConfig, cp, nil).GetClient(t.Context())
+
+	require.ErrorContains(t, err, "allowHosts can't be enforced with the process runtime")
+}
+
+func TestValidateServerRuntime(t *testing.T) {
+	cp := newClientPool(Options{}, nil)
+	_, err := cp.runtimeFor(&catalog.Server{Runtime: "lxc"})
+
+	require.ErrorContains(t, err, `unknown runtime "lxc"`)
+}
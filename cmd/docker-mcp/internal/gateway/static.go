@@ -0,0 +1,307 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	mcpclient "github.com/docker/mcp-gateway/cmd/docker-mcp/internal/mcp"
+)
+
+// Labels of the pre-started containers of static mode.
+const (
+	labelServerName = "docker-mcp-name"
+	labelTransport  = "docker-mcp-transport"
+	labelPort       = "docker-mcp-port"
+	labelPath       = "docker-mcp-path"
+
+	// labelComposeProject is set by compose on the containers of a project.
+	labelComposeProject = "com.docker.compose.project"
+)
+
+const (
+	// defaultStaticPort is where docker-mcp-bridge listens.
+	defaultStaticPort = 4444
+
+	// defaultStaticPath is the path of streamable HTTP servers.
+	defaultStaticPath = "/mcp"
+
+	// staticConnectTimeout is how long to wait for a static server to be
+	// running, healthy and accepting connections.
+	staticConnectTimeout = 30 * time.Second
+
+	// staticHealthInterval is how often long-lived static clients are pinged.
+	staticHealthInterval = 30 * time.Second
+)
+
+// staticEndpoint is where a pre-started server can be reached.
+type staticEndpoint struct {
+	Container string
+
+	// Transport is "tcp", for newline delimited JSON over a TCP connection, or
+	// a remote transport, "streamable" or "sse".
+	Transport string
+	Address   string
+	Path      string
+}
+
+// URL of servers that speak HTTP.
+func (e staticEndpoint) URL() string {
+	return "http://" + e.Address + e.Path
+}
+
+// isStatic tells whether a server is pre-started, in static mode. Remote
+// servers and local processes are never pre-started.
+func (cp *clientPool) isStatic(spec *catalog.Server) bool {
+	return cp.Static && spec.Process == nil && spec.SSEEndpoint == "" && spec.Remote.URL == ""
+}
+
+// staticTransport normalizes the transport label.
+func staticTransport(label string) (string, error) {
+	switch strings.ToLower(label) {
+	case "", "stdio", "tcp":
+		// docker-mcp-bridge exposes the stdio of a server over TCP.
+		return "tcp", nil
+	case "http", "streamable", "streaming", "streamable-http":
+		return "streamable", nil
+	case "sse":
+		return "sse", nil
+	default:
+		return "", fmt.Errorf("unsupported transport %q", label)
+	}
+}
+
+// resolveStatic finds the running container of a pre-started server, from its
+// labels. Only the containers of the gateway's compose project, or on one of
+// its networks, are considered. Containers that are unhealthy are skipped and
+// the result says if a container is still starting.
+//
+// Without the Docker API, or without a labelled container, the server is
+// reached by its service name, as mcp-<name>:4444.
+func (cp *clientPool) resolveStatic(ctx context.Context, serverName string) (staticEndpoint, bool, error) {
+	ids, err := cp.docker.FindAllContainersByLabel(ctx, labelServerName+"="+serverName)
+	if err != nil {
+		return staticFallback(serverName), false, nil
+	}
+
+	var candidates []container.InspectResponse
+	for _, id := range ids {
+		inspect, err := cp.docker.InspectContainer(ctx, id)
+		if err != nil || inspect.State == nil || !inspect.State.Running || inspect.Config == nil || !cp.isNeighbour(inspect) {
+			continue
+		}
+		candidates = append(candidates, inspect)
+	}
+	if len(candidates) == 0 {
+		return staticFallback(serverName), false, nil
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name < candidates[j].Name })
+
+	starting := false
+	for _, inspect := range candidates {
+		if health := inspect.State.Health; health != nil {
+			switch health.Status {
+			case container.Healthy:
+			case container.Starting:
+				starting = true
+				continue
+			default:
+				continue
+			}
+		}
+
+		endpoint, err := cp.staticEndpoint(inspect)
+		return endpoint, false, err
+	}
+
+	if starting {
+		return staticEndpoint{}, true, fmt.Errorf("container of %s is starting", serverName)
+	}
+	return staticEndpoint{}, false, fmt.Errorf("no running and healthy container labelled %s=%s", labelServerName, serverName)
+}
+
+// staticFallback is the endpoint of a server started by compose, under the
+// service name that `docker mcp gateway compose` gives it, behind
+// docker-mcp-bridge.
+func staticFallback(serverName string) staticEndpoint {
+	name := composeName(serverName)
+	return staticEndpoint{
+		Container: name,
+		Transport: "tcp",
+		Address:   net.JoinHostPort(name, strconv.Itoa(defaultStaticPort)),
+		Path:      defaultStaticPath,
+	}
+}
+
+// isNeighbour tells whether a container belongs to the gateway's compose
+// project or shares a user-defined network with it. Other containers on the
+// same host can't pretend to be a server.
+func (cp *clientPool) isNeighbour(inspect container.InspectResponse) bool {
+	if cp.composeProject != "" && inspect.Config.Labels[labelComposeProject] == cp.composeProject {
+		return true
+	}
+	if inspect.NetworkSettings == nil {
+		return false
+	}
+	for network := range inspect.NetworkSettings.Networks {
+		if slices.Contains(cp.networks, network) && !isDefaultNetwork(network) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDefaultNetwork tells whether a network is one of the networks that every
+// Docker Engine has, and that any container can join.
+func isDefaultNetwork(network string) bool {
+	return network == "bridge" || network == "host" || network == "none"
+}
+
+func (cp *clientPool) staticEndpoint(inspect container.InspectResponse) (staticEndpoint, error) {
+	name := strings.TrimPrefix(inspect.Name, "/")
+	labels := inspect.Config.Labels
+
+	transport, err := staticTransport(labels[labelTransport])
+	if err != nil {
+		return staticEndpoint{}, fmt.Errorf("container %s: %w", name, err)
+	}
+
+	port := defaultStaticPort
+	if value := labels[labelPort]; value != "" {
+		if port, err = strconv.Atoi(value); err != nil || port <= 0 || port > 65535 {
+			return staticEndpoint{}, fmt.Errorf("container %s: invalid port %q", name, value)
+		}
+	}
+
+	path := labels[labelPath]
+	if path == "" {
+		path = defaultStaticPath
+	} else if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return staticEndpoint{
+		Container: name,
+		Transport: transport,
+		Address:   net.JoinHostPort(cp.staticHost(name, inspect), strconv.Itoa(port)),
+		Path:      path,
+	}, nil
+}
+
+// staticHost prefers the address of the container on a network shared with
+// the gateway, then any of its addresses and finally its name.
+func (cp *clientPool) staticHost(name string, inspect container.InspectResponse) string {
+	if inspect.NetworkSettings == nil {
+		return name
+	}
+
+	var networks []string
+	for network := range inspect.NetworkSettings.Networks {
+		networks = append(networks, network)
+	}
+	sort.SliceStable(networks, func(i, j int) bool {
+		return slices.Contains(cp.networks, networks[i]) && !slices.Contains(cp.networks, networks[j])
+	})
+
+	for _, network := range networks {
+		if endpoint := inspect.NetworkSettings.Networks[network]; endpoint != nil && endpoint.IPAddress != "" {
+			return endpoint.IPAddress
+		}
+	}
+	return name
+}
+
+// staticClient connects to a pre-started server. The container is looked up
+// again on every attempt, so that a restarted server is found at its new
+// address.
+func (cp *clientPool) staticClient(ctx context.Context, serverConfig *catalog.ServerConfig) (mcpclient.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, staticConnectTimeout)
+	defer cancel()
+
+	for {
+		client, retry, err := cp.tryStaticClient(ctx, serverConfig)
+		if err == nil || !retry {
+			return client, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("server %s: %w", serverConfig.Name, err)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func (cp *clientPool) tryStaticClient(ctx context.Context, serverConfig *catalog.ServerConfig) (mcpclient.Client, bool, error) {
+	endpoint, starting, err := cp.resolveStatic(ctx, serverConfig.Name)
+	if err != nil {
+		return nil, starting, err
+	}
+
+	if endpoint.Transport != "tcp" {
+		// The server might not be listening yet.
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", endpoint.Address)
+		if err != nil {
+			return nil, true, fmt.Errorf("connecting to %s: %w", endpoint.Container, err)
+		}
+		_ = conn.Close()
+
+		log("  - Connecting to", endpoint.Container, "at", endpoint.URL())
+
+		remote := *serverConfig
+		remote.Spec.Remote = catalog.Remote{URL: endpoint.URL(), Transport: endpoint.Transport}
+		return mcpclient.NewRemoteMCPClient(&remote), false, nil
+	}
+
+	log("  - Connecting to", endpoint.Container, "at", endpoint.Address)
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint.Address)
+	if err != nil {
+		// The server might not be listening yet.
+		return nil, true, fmt.Errorf("connecting to %s: %w", endpoint.Container, err)
+	}
+	return mcpclient.NewStreamClient(serverConfig.Name, conn), false, nil
+}
+
+// watchStatic pings a long-lived static client and forgets it once it's not
+// responding anymore, for example because its container restarted. The next
+// call will reconnect.
+func (cp *clientPool) watchStatic(key clientKey, getter *clientGetter, client mcpclient.Client) {
+	ticker := time.NewTicker(staticHealthInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cp.clientLock.RLock()
+		kc, found := cp.keptClients[key]
+		cp.clientLock.RUnlock()
+		if !found || kc.Getter != getter {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := client.Session().Ping(ctx, nil)
+		cancel()
+		if err == nil {
+			continue
+		}
+
+		logf("  - Static server %s is not responding, reconnecting on next use: %s", key.serverName, err)
+		cp.clientLock.Lock()
+		if kc, found := cp.keptClients[key]; found && kc.Getter == getter {
+			delete(cp.keptClients, key)
+		}
+		cp.clientLock.Unlock()
+		_ = client.Session().Close()
+		return
+	}
+}
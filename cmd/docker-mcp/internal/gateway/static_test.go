@@ -0,0 +1,169 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/catalog"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+)
+
+// staticDocker lists pre-started containers.
+type staticDocker struct {
+	docker.Client
+	containers []container.InspectResponse
+	err        error
+}
+
+func (d *staticDocker) FindAllContainersByLabel(_ context.Context, label string) ([]string, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	var ids []string
+	for _, c := range d.containers {
+		if labelServerName+"="+c.Config.Labels[labelServerName] == label {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (d *staticDocker) InspectContainer(_ context.Context, id string) (container.InspectResponse, error) {
+	for _, c := range d.containers {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return container.InspectResponse{}, nil
+}
+
+func staticContainer(name, health string, labels map[string]string, networks map[string]string) container.InspectResponse {
+	c := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:    name,
+			Name:  "/" + name,
+			State: &container.State{Running: true},
+		},
+		Config: &container.Config{Labels: labels},
+		NetworkSettings: &container.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{},
+		},
+	}
+	if health != "" {
+		c.State.Health = &container.Health{Status: health}
+	}
+	for nw, ip := range networks {
+		c.NetworkSettings.Networks[nw] = &network.EndpointSettings{IPAddress: ip}
+	}
+	return c
+}
+
+func TestResolveStatic(t *testing.T) {
+	cp := newClientPool(Options{Static: true}, &staticDocker{containers: []container.InspectResponse{
+		staticContainer("fetch-1", container.Unhealthy, map[string]string{labelServerName: "fetch"}, map[string]string{"mcp": "10.0.0.2"}),
+		staticContainer("fetch-2", container.Healthy, map[string]string{labelServerName: "fetch"}, map[string]string{"bridge": "172.17.0.3", "mcp": "10.0.0.3"}),
+		staticContainer("time", "", map[string]string{labelServerName: "time", labelTransport: "streamable", labelPort: "8000", labelPath: "api/mcp", labelComposeProject: "mcp"}, nil),
+	}})
+	cp.SetNetworks([]string{"mcp"})
+	cp.SetComposeProject("mcp")
+
+	endpoint, starting, err := cp.resolveStatic(t.Context(), "fetch")
+	require.NoError(t, err)
+	assert.False(t, starting)
+	assert.Equal(t, staticEndpoint{Container: "fetch-2", Transport: "tcp", Address: "10.0.0.3:4444", Path: "/mcp"}, endpoint)
+
+	endpoint, _, err = cp.resolveStatic(t.Context(), "time")
+	require.NoError(t, err)
+	assert.Equal(t, "streamable", endpoint.Transport)
+	assert.Equal(t, "http://time:8000/api/mcp", endpoint.URL())
+
+	// Servers without a labelled container are reached by their service name.
+	endpoint, starting, err = cp.resolveStatic(t.Context(), "unknown")
+	require.NoError(t, err)
+	assert.False(t, starting)
+	assert.Equal(t, staticEndpoint{Container: "mcp-unknown", Transport: "tcp", Address: "mcp-unknown:4444", Path: "/mcp"}, endpoint)
+}
+
+func TestResolveStaticOtherContainers(t *testing.T) {
+	cp := newClientPool(Options{Static: true}, &staticDocker{containers: []container.InspectResponse{
+		staticContainer("evil-fetch", "", map[string]string{labelServerName: "fetch", labelComposeProject: "other"}, map[string]string{"bridge": "172.17.0.2", "other": "10.0.1.2"}),
+	}})
+	cp.SetNetworks([]string{"bridge", "mcp"})
+	cp.SetComposeProject("mcp")
+
+	endpoint, _, err := cp.resolveStatic(t.Context(), "fetch")
+	require.NoError(t, err)
+	assert.Equal(t, "mcp-fetch:4444", endpoint.Address)
+}
+
+func TestResolveStaticWithoutDockerAPI(t *testing.T) {
+	cp := newClientPool(Options{Static: true}, &staticDocker{err: errors.New("cannot connect to the Docker daemon")})
+
+	endpoint, starting, err := cp.resolveStatic(t.Context(), "fetch")
+	require.NoError(t, err)
+	assert.False(t, starting)
+	assert.Equal(t, staticEndpoint{Container: "mcp-fetch", Transport: "tcp", Address: "mcp-fetch:4444", Path: "/mcp"}, endpoint)
+}
+
+func TestResolveStaticStarting(t *testing.T) {
+	cp := newClientPool(Options{Static: true}, &staticDocker{containers: []container.InspectResponse{
+		staticContainer("fetch", container.Starting, map[string]string{labelServerName: "fetch"}, map[string]string{"mcp": "10.0.0.2"}),
+	}})
+	cp.SetNetworks([]string{"mcp"})
+
+	_, starting, err := cp.resolveStatic(t.Context(), "fetch")
+	require.Error(t, err)
+	assert.True(t, starting)
+}
+
+func TestResolveStaticInvalidLabels(t *testing.T) {
+	cp := newClientPool(Options{Static: true}, &staticDocker{containers: []container.InspectResponse{
+		staticContainer("port", "", map[string]string{labelServerName: "port", labelPort: "http", labelComposeProject: "mcp"}, nil),
+		staticContainer("transport", "", map[string]string{labelServerName: "transport", labelTransport: "websocket", labelComposeProject: "mcp"}, nil),
+	}})
+	cp.SetComposeProject("mcp")
+
+	_, _, err := cp.resolveStatic(t.Context(), "port")
+	require.ErrorContains(t, err, `invalid port "http"`)
+
+	_, _, err = cp.resolveStatic(t.Context(), "transport")
+	require.ErrorContains(t, err, `unsupported transport "websocket"`)
+}
+
+func TestStaticClientStreamable(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "time"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "now"}, func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[struct{}]) (*mcp.CallToolResultFor[any], error) {
+		return &mcp.CallToolResultFor[any]{}, nil
+	})
+	httpServer := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	t.Cleanup(httpServer.Close)
+
+	host, port, err := net.SplitHostPort(httpServer.Listener.Addr().String())
+	require.NoError(t, err)
+
+	cp := newClientPool(Options{Static: true}, &staticDocker{containers: []container.InspectResponse{
+		staticContainer("time", "", map[string]string{labelServerName: "time", labelTransport: "streamable", labelPort: port}, map[string]string{"mcp": host}),
+	}})
+	cp.SetNetworks([]string{"mcp"})
+
+	serverConfig := &catalog.ServerConfig{Name: "time"}
+	client, err := newClientGetter(serverConfig, cp, nil).GetClient(t.Context())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Session().Close() })
+
+	tools, err := client.Session().ListTools(t.Context(), &mcp.ListToolsParams{})
+	require.NoError(t, err)
+	require.Len(t, tools.Tools, 1)
+	assert.Equal(t, "now", tools.Tools[0].Name)
+}
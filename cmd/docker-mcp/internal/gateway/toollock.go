@@ -0,0 +1,72 @@
+package gateway
+
+import (
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/config"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/toollock"
+)
+
+// readToolLock reads the lock file holding the approved tool definitions.
+// It returns nil if pinning is disabled or the lock file can't be read.
+func (g *Gateway) readToolLock() (*toollock.Lock, string) {
+	if g.toolLock == "" || g.toolLock == toollock.ModeOff {
+		return nil, ""
+	}
+
+	path, err := config.FilePath(toollock.Filename)
+	if err != nil {
+		logf("  > Can't locate the tools lock file: %s", err)
+		return nil, ""
+	}
+
+	lock, err := toollock.ReadFile(path)
+	if err != nil {
+		logf("  > Can't read the tools lock file: %s", err)
+		return nil, ""
+	}
+
+	return lock, path
+}
+
+// checkToolPins compares the tools of a server with the approved ones and
+// returns a function that tells which tools can be registered.
+func (g *Gateway) checkToolPins(lock *toollock.Lock, serverName string, tools []*mcp.Tool) func(*mcp.Tool) bool {
+	allowAll := func(*mcp.Tool) bool { return true }
+	if lock == nil {
+		return allowAll
+	}
+
+	changes := lock.Check(serverName, tools)
+	if len(changes) == 0 {
+		return allowAll
+	}
+
+	changed := map[string]toollock.ChangeKind{}
+	for _, change := range changes {
+		logf("  ! Tool %s:%s %s since it was approved", serverName, change.Tool, change.Kind)
+		changed[change.Tool] = change.Kind
+	}
+
+	switch g.toolLock {
+	case toollock.ModeBlock:
+		logf("  ! Blocking all the tools of %s until the changes are approved with `docker mcp tools approve %s`", serverName, serverName)
+		return func(*mcp.Tool) bool { return false }
+	case toollock.ModeApprove:
+		logf("  ! Holding back new and changed tools of %s until they are approved with `docker mcp tools approve %s`", serverName, serverName)
+		return func(tool *mcp.Tool) bool {
+			_, found := changed[tool.Name]
+			return !found
+		}
+	default:
+		return func(tool *mcp.Tool) bool {
+			if kind, found := changed[tool.Name]; found {
+				if tool.Meta == nil {
+					tool.Meta = mcp.Meta{}
+				}
+				tool.Meta[toollock.MetaKey] = string(kind)
+			}
+			return true
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package gateway
+
+import (
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/toolscan"
+)
+
+// scanTool checks a tool's metadata for tool poisoning and prompt injection.
+// It returns the tool to register, which can be a sanitized copy, or false if
+// the tool must not be registered.
+func (g *Gateway) scanTool(serverName string, tool *mcp.Tool) (*mcp.Tool, bool) {
+	if g.toolScan == "" || g.toolScan == toolscan.ActionOff {
+		return tool, true
+	}
+
+	findings := toolscan.ScanTool(tool)
+	if len(findings) == 0 {
+		return tool, true
+	}
+
+	for _, finding := range findings {
+		logf("  ! Tool %s:%s %s", serverName, tool.Name, finding)
+	}
+
+	switch g.toolScan {
+	case toolscan.ActionRefuse:
+		logf("  ! Refusing to register tool %s:%s", serverName, tool.Name)
+		return nil, false
+	case toolscan.ActionStrip:
+		stripped, ok := toolscan.StripTool(tool)
+		if !ok {
+			logf("  ! Can't sanitize tool %s:%s, refusing to register it", serverName, tool.Name)
+			return nil, false
+		}
+		logf("  ! Registering sanitized tool %s:%s", serverName, tool.Name)
+		toolscan.Annotate(stripped, findings)
+		return stripped, true
+	default:
+		toolscan.Annotate(tool, findings)
+		return tool, true
+	}
+}
@@ -25,7 +25,7 @@ func (g *Gateway) startSseServer(ctx context.Context, ln net.Listener) error {
 	sseHandler := mcp.NewSSEHandler(func(_ *http.Request) *mcp.Server {
 		return g.mcpServer
 	})
-	mux.Handle("/sse", sseHandler)
+	mux.Handle("/sse", g.readOnlyHandler(sseHandler))
 	httpServer := &http.Server{
 		Handler: mux,
 	}
@@ -43,7 +43,7 @@ func (g *Gateway) startStreamingServer(ctx context.Context, ln net.Listener) err
 	streamHandler := mcp.NewStreamableHTTPHandler(func(_ *http.Request) *mcp.Server {
 		return g.mcpServer
 	}, nil)
-	mux.Handle("/mcp", streamHandler)
+	mux.Handle("/mcp", g.readOnlyHandler(streamHandler))
 	httpServer := &http.Server{
 		Handler: mux,
 	}
@@ -62,7 +62,7 @@ func (g *Gateway) startCentralStreamingServer(ctx context.Context, ln net.Listen
 
 	var lock sync.Mutex
 	handlersPerSelectionOfServers := map[string]*mcp.StreamableHTTPHandler{}
-	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/mcp", g.readOnlyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		serverNames := r.Header.Get("x-mcp-servers")
 		if len(serverNames) == 0 {
 			log("No server names provided in the request header 'x-mcp-servers'")
@@ -88,7 +88,7 @@ func (g *Gateway) startCentralStreamingServer(ctx context.Context, ln net.Listen
 		lock.Unlock()
 
 		handler.ServeHTTP(w, r)
-	})
+	})))
 	httpServer := &http.Server{
 		Handler: mux,
 	}
@@ -126,6 +126,9 @@ func healthHandler(state *health.State) http.HandlerFunc {
 			w.WriteHeader(http.StatusOK)
 		} else {
 			w.WriteHeader(http.StatusServiceUnavailable)
+			for _, problem := range state.Problems() {
+				_, _ = io.WriteString(w, problem+"\n")
+			}
 		}
 	}
 }
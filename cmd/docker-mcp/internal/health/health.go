@@ -3,13 +3,27 @@ package health
 import "sync/atomic"
 
 type State struct {
-	healthy atomic.Bool
+	healthy  atomic.Bool
+	problems atomic.Pointer[[]string]
 }
 
 func (h *State) IsHealthy() bool {
-	return h.healthy.Load()
+	return h.healthy.Load() && len(h.Problems()) == 0
 }
 
 func (h *State) SetHealthy() {
 	h.healthy.Store(true)
 }
+
+// SetProblems replaces the problems that keep a running gateway unhealthy,
+// such as servers that can't start. No problems means healthy again.
+func (h *State) SetProblems(problems []string) {
+	h.problems.Store(&problems)
+}
+
+func (h *State) Problems() []string {
+	if problems := h.problems.Load(); problems != nil {
+		return *problems
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package interceptors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/toolscan"
+)
+
+func ScanToolResultsMiddleware(action toolscan.Action) mcp.Middleware[*mcp.ServerSession] {
+	return func(next mcp.MethodHandler[*mcp.ServerSession]) mcp.MethodHandler[*mcp.ServerSession] {
+		return func(ctx context.Context, session *mcp.ServerSession, method string, params mcp.Params) (mcp.Result, error) {
+			// Only scan results of the tools/call method
+			if method != "tools/call" {
+				return next(ctx, session, method, params)
+			}
+
+			result, err := next(ctx, session, method, params)
+			if err != nil || result == nil {
+				return result, err
+			}
+
+			callResult, ok := result.(*mcp.CallToolResult)
+			if !ok {
+				return result, nil
+			}
+
+			var toolName string
+			if jsonData, err := json.Marshal(params); err == nil {
+				var callParams mcp.CallToolParams
+				if err := json.Unmarshal(jsonData, &callParams); err == nil {
+					toolName = callParams.Name
+				}
+			}
+
+			var findings []toolscan.Finding
+			for i, content := range callResult.Content {
+				if c, ok := content.(*mcp.TextContent); ok {
+					findings = append(findings, toolscan.ScanText(fmt.Sprintf("content[%d]", i), c.Text)...)
+				}
+			}
+			if len(findings) == 0 {
+				return result, nil
+			}
+
+			for _, finding := range findings {
+				logf("  ! Result of tool %s: %s\n", toolName, finding)
+			}
+
+			switch action {
+			case toolscan.ActionRefuse:
+				return nil, fmt.Errorf("the result of tool %s looks like a prompt injection", toolName)
+			case toolscan.ActionStrip:
+				for _, content := range callResult.Content {
+					if c, ok := content.(*mcp.TextContent); ok {
+						c.Text = toolscan.StripText(c.Text)
+					}
+				}
+				logf("  > Sanitized the result of tool %s\n", toolName)
+			}
+
+			return result, nil
+		}
+	}
+}
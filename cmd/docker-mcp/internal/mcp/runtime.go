@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/logs"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/runtime"
+)
+
+type runtimeMCPClient struct {
+	name        string
+	runtime     runtime.Runtime
+	spec        runtime.Spec
+	client      *mcp.Client
+	session     *mcp.ClientSession
+	roots       []*mcp.Root
+	initialized atomic.Bool
+}
+
+// NewRuntimeClient returns a client for an MCP server that a Runtime runs in a
+// container, talking to it over stdio.
+func NewRuntimeClient(name string, rt runtime.Runtime, spec runtime.Spec) Client {
+	return &runtimeMCPClient{
+		name:    name,
+		runtime: rt,
+		spec:    spec,
+	}
+}
+
+func (c *runtimeMCPClient) Initialize(ctx context.Context, _ *mcp.InitializeParams, debug bool, ss *mcp.ServerSession, server *mcp.Server) error {
+	if c.initialized.Load() {
+		return fmt.Errorf("client already initialized")
+	}
+
+	spec := c.spec
+	if debug {
+		spec.Stderr = logs.NewPrefixer(os.Stderr, "- "+c.name+": ")
+	}
+
+	process, err := c.runtime.Start(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	c.client = mcp.NewClient(&mcp.Implementation{
+		Name:    "docker-mcp-gateway",
+		Version: "1.0.0",
+	}, notifications(ss, server))
+
+	c.client.AddRoots(c.roots...)
+
+	session, err := c.client.Connect(ctx, &streamTransport{rwc: process})
+	if err != nil {
+		// Most of the time, the server failed to start. Its exit code and
+		// stderr say more than a closed connection.
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		var exitErr *runtime.ExitError
+		if waitErr := process.Wait(waitCtx); errors.As(waitErr, &exitErr) {
+			err = exitErr
+		}
+		_ = process.Close()
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	c.session = session
+	c.initialized.Store(true)
+
+	return nil
+}
+
+func (c *runtimeMCPClient) AddRoots(roots []*mcp.Root) {
+	if c.initialized.Load() {
+		c.client.AddRoots(roots...)
+	}
+	c.roots = roots
+}
+
+func (c *runtimeMCPClient) Session() *mcp.ClientSession {
+	if !c.initialized.Load() {
+		panic("client not initialize")
+	}
+	return c.session
+}
+
+func (c *runtimeMCPClient) GetClient() *mcp.Client {
+	if !c.initialized.Load() {
+		panic("client not initialize")
+	}
+	return c.client
+}
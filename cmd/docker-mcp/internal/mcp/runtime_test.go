@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/runtime"
+)
+
+// pipeProcess is one end of an in-memory stdio.
+type pipeProcess struct {
+	net.Conn
+	exit error
+}
+
+func (p *pipeProcess) Wait(context.Context) error {
+	return p.exit
+}
+
+// fakeRuntime starts an in-process MCP server, or a container that exits
+// right away.
+type fakeRuntime struct {
+	server *mcp.Server
+	exit   error
+	spec   runtime.Spec
+}
+
+func (r *fakeRuntime) Start(ctx context.Context, spec runtime.Spec) (runtime.Process, error) {
+	r.spec = spec
+
+	client, server := net.Pipe()
+	if r.server == nil {
+		_ = server.Close()
+		return &pipeProcess{Conn: client, exit: r.exit}, nil
+	}
+
+	if _, err := r.server.Connect(ctx, &streamTransport{rwc: server}); err != nil {
+		return nil, err
+	}
+	return &pipeProcess{Conn: client}, nil
+}
+
+func (r *fakeRuntime) Run(context.Context, runtime.Spec) ([]byte, error) {
+	return nil, nil
+}
+
+func TestRuntimeClientListTools(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "hello"}, func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[struct{}]) (*mcp.CallToolResultFor[any], error) {
+		return &mcp.CallToolResultFor[any]{Content: []mcp.Content{&mcp.TextContent{Text: "world"}}}, nil
+	})
+	rt := &fakeRuntime{server: server}
+
+	client := NewRuntimeClient("test", rt, runtime.Spec{Name: "test", Image: "mcp/test"})
+	require.NoError(t, client.Initialize(t.Context(), nil, false, nil, nil))
+	t.Cleanup(func() { _ = client.Session().Close() })
+
+	assert.Equal(t, "mcp/test", rt.spec.Image)
+
+	tools, err := client.Session().ListTools(t.Context(), &mcp.ListToolsParams{})
+	require.NoError(t, err)
+	require.Len(t, tools.Tools, 1)
+	assert.Equal(t, "hello", tools.Tools[0].Name)
+
+	result, err := client.Session().CallTool(t.Context(), &mcp.CallToolParams{Name: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "world", result.Content[0].(*mcp.TextContent).Text)
+}
+
+func TestRuntimeClientExitError(t *testing.T) {
+	rt := &fakeRuntime{exit: &runtime.ExitError{Name: "test", ExitCode: 1, Stderr: "missing API key"}}
+
+	client := NewRuntimeClient("test", rt, runtime.Spec{Name: "test", Image: "mcp/test"})
+	err := client.Initialize(t.Context(), nil, false, nil, nil)
+
+	var exitErr *runtime.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 1, exitErr.ExitCode)
+	assert.Contains(t, err.Error(), "missing API key")
+}
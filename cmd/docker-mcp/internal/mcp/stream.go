@@ -0,0 +1,174 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type streamMCPClient struct {
+	name        string
+	rwc         io.ReadWriteCloser
+	client      *mcp.Client
+	session     *mcp.ClientSession
+	roots       []*mcp.Root
+	initialized atomic.Bool
+}
+
+// NewStreamClient returns a client for an MCP server that talks newline
+// delimited JSON over a stream, such as a TCP connection.
+func NewStreamClient(name string, rwc io.ReadWriteCloser) Client {
+	return &streamMCPClient{
+		name: name,
+		rwc:  rwc,
+	}
+}
+
+func (c *streamMCPClient) Initialize(ctx context.Context, _ *mcp.InitializeParams, _ bool, ss *mcp.ServerSession, server *mcp.Server) error {
+	if c.initialized.Load() {
+		return fmt.Errorf("client already initialized")
+	}
+
+	c.client = mcp.NewClient(&mcp.Implementation{
+		Name:    "docker-mcp-gateway",
+		Version: "1.0.0",
+	}, notifications(ss, server))
+
+	c.client.AddRoots(c.roots...)
+
+	session, err := c.client.Connect(ctx, &streamTransport{rwc: c.rwc})
+	if err != nil {
+		_ = c.rwc.Close()
+		return fmt.Errorf("failed to connect to %s: %w", c.name, err)
+	}
+
+	c.session = session
+	c.initialized.Store(true)
+
+	return nil
+}
+
+func (c *streamMCPClient) AddRoots(roots []*mcp.Root) {
+	if c.initialized.Load() {
+		c.client.AddRoots(roots...)
+	}
+	c.roots = roots
+}
+
+func (c *streamMCPClient) Session() *mcp.ClientSession {
+	if !c.initialized.Load() {
+		panic("client not initialize")
+	}
+	return c.session
+}
+
+func (c *streamMCPClient) GetClient() *mcp.Client {
+	if !c.initialized.Load() {
+		panic("client not initialize")
+	}
+	return c.client
+}
+
+// streamTransport is an mcp.Transport that talks newline delimited JSON over
+// a stream, such as the stdio of a container or a TCP connection.
+type streamTransport struct {
+	rwc io.ReadWriteCloser
+}
+
+func (t *streamTransport) Connect(context.Context) (mcp.Connection, error) {
+	return newStreamConn(t.rwc), nil
+}
+
+type messageOrError struct {
+	msg jsonrpc.Message
+	err error
+}
+
+type streamConn struct {
+	rwc       io.ReadWriteCloser
+	incoming  chan messageOrError
+	closed    chan struct{}
+	writeLock sync.Mutex
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newStreamConn(rwc io.ReadWriteCloser) *streamConn {
+	c := &streamConn{
+		rwc:      rwc,
+		incoming: make(chan messageOrError),
+		closed:   make(chan struct{}),
+	}
+
+	// Read in a goroutine, so that Read can return as soon as the context is
+	// done or the connection is closed.
+	go func() {
+		dec := json.NewDecoder(rwc)
+		for {
+			var raw json.RawMessage
+			var msg jsonrpc.Message
+			err := dec.Decode(&raw)
+			if err == nil {
+				msg, err = jsonrpc.DecodeMessage(raw)
+			}
+
+			select {
+			case c.incoming <- messageOrError{msg: msg, err: err}:
+			case <-c.closed:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return c
+}
+
+func (c *streamConn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.closed:
+		return nil, io.EOF
+	case m := <-c.incoming:
+		return m.msg, m.err
+	}
+}
+
+func (c *streamConn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := jsonrpc.EncodeMessage(msg)
+	if err != nil {
+		return fmt.Errorf("marshaling message: %w", err)
+	}
+	data = append(data, '\n')
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	_, err = c.rwc.Write(data)
+	return err
+}
+
+func (c *streamConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.closeErr = c.rwc.Close()
+	})
+	return c.closeErr
+}
+
+func (c *streamConn) SessionID() string {
+	return ""
+}
@@ -0,0 +1,39 @@
+package mcp
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamClient(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "hello"}, func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[struct{}]) (*mcp.CallToolResultFor[any], error) {
+		return &mcp.CallToolResultFor[any]{}, nil
+	})
+
+	clientConn, serverConn := net.Pipe()
+	_, err := server.Connect(t.Context(), &streamTransport{rwc: serverConn})
+	require.NoError(t, err)
+
+	client := NewStreamClient("test", clientConn)
+	require.NoError(t, client.Initialize(t.Context(), nil, false, nil, nil))
+	t.Cleanup(func() { _ = client.Session().Close() })
+
+	tools, err := client.Session().ListTools(t.Context(), &mcp.ListToolsParams{})
+	require.NoError(t, err)
+	require.Len(t, tools.Tools, 1)
+	assert.Equal(t, "hello", tools.Tools[0].Name)
+}
+
+func TestStreamClientClosed(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	_ = serverConn.Close()
+
+	client := NewStreamClient("test", clientConn)
+	require.Error(t, client.Initialize(t.Context(), nil, false, nil, nil))
+}
@@ -0,0 +1,228 @@
+package mounts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/user"
+)
+
+// DefaultDenied are the host paths that MCP servers can't mount by default.
+var DefaultDenied = []string{
+	"~/.ssh",
+	"~/.gnupg",
+	"~/.aws",
+	"~/.azure",
+	"~/.config/gcloud",
+	"~/.kube",
+	"~/.docker",
+	"/etc",
+	"/proc",
+	"/sys",
+	"/dev",
+	"/boot",
+}
+
+// Policy decides which host paths can be mounted into MCP server containers.
+type Policy struct {
+	// Allowed are the host path prefixes that can be mounted. Empty means any path that's not denied.
+	Allowed []string
+	// Denied are the host paths that can't be mounted, nor any of their parents.
+	Denied []string
+	// ReadOnly are the host path prefixes that are always mounted read-only.
+	ReadOnly []string
+	// AllowSockets lets servers mount unix sockets and named pipes, such as the Docker socket.
+	AllowSockets bool
+}
+
+// Violation is a mount that the policy doesn't allow.
+type Violation struct {
+	Mount  string
+	Reason string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("mount %q is not allowed: %s", v.Mount, v.Reason)
+}
+
+// Apply checks a `docker run -v` mount against the policy and returns the mount to use,
+// forced read-only if needed. Named volumes are not host paths and are always allowed.
+func (p *Policy) Apply(mount string) (string, error) {
+	source, options := splitMount(mount)
+	if isNamedPipe(source) {
+		if !p.AllowSockets {
+			return "", &Violation{Mount: mount, Reason: "named pipes can't be mounted"}
+		}
+		return mount, nil
+	}
+	if !isHostPath(source) {
+		return mount, nil
+	}
+
+	path, err := resolve(source)
+	if err != nil {
+		return "", &Violation{Mount: mount, Reason: err.Error()}
+	}
+
+	if !p.AllowSockets && isSocket(path) {
+		return "", &Violation{Mount: mount, Reason: path + " is a socket, sockets can't be mounted"}
+	}
+
+	for _, denied := range p.Denied {
+		deniedPath, err := resolve(denied)
+		if err != nil {
+			return "", err
+		}
+		if within(path, deniedPath) {
+			return "", &Violation{Mount: mount, Reason: path + " is denied"}
+		}
+		if within(deniedPath, path) {
+			return "", &Violation{Mount: mount, Reason: path + " contains denied path " + deniedPath}
+		}
+	}
+
+	if len(p.Allowed) > 0 {
+		allowed := false
+		for _, prefix := range p.Allowed {
+			allowedPath, err := resolve(prefix)
+			if err != nil {
+				return "", err
+			}
+			if within(path, allowedPath) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "", &Violation{Mount: mount, Reason: fmt.Sprintf("%s is outside of the allowed paths (%s)", path, strings.Join(p.Allowed, ", "))}
+		}
+	}
+
+	for _, prefix := range p.ReadOnly {
+		readOnlyPath, err := resolve(prefix)
+		if err != nil {
+			return "", err
+		}
+		if within(path, readOnlyPath) && !isReadOnly(options) {
+			return ReadOnly(mount), nil
+		}
+	}
+
+	return mount, nil
+}
+
+// ReadOnly makes sure a volume mount is read-only. The other options, like the
+// SELinux labels, are kept.
+func ReadOnly(mount string) string {
+	_, options := splitMount(mount)
+	if isReadOnly(options) {
+		return mount
+	}
+
+	var readOnly []string
+	if options != "" {
+		mount = strings.TrimSuffix(mount, ":"+options)
+		for _, option := range strings.Split(options, ",") {
+			if option != "rw" {
+				readOnly = append(readOnly, option)
+			}
+		}
+	}
+	readOnly = append(readOnly, "ro")
+
+	return mount + ":" + strings.Join(readOnly, ",")
+}
+
+// splitMount splits a `src:dst[:options]` mount into its source and options.
+func splitMount(mount string) (string, string) {
+	// Windows paths start with a drive letter: C:\Users\...
+	offset := 0
+	if len(mount) >= 2 && mount[1] == ':' && isLetter(mount[0]) {
+		offset = 2
+	}
+
+	parts := strings.Split(mount[offset:], ":")
+	source := mount[:offset] + parts[0]
+	if len(parts) >= 3 {
+		return source, parts[len(parts)-1]
+	}
+	return source, ""
+}
+
+func isReadOnly(options string) bool {
+	for _, option := range strings.Split(options, ",") {
+		if option == "ro" || option == "readonly" {
+			return true
+		}
+	}
+	return false
+}
+
+func isHostPath(source string) bool {
+	return strings.HasPrefix(source, "/") ||
+		strings.HasPrefix(source, "~") ||
+		strings.HasPrefix(source, ".") ||
+		filepath.IsAbs(source) ||
+		(len(source) >= 2 && source[1] == ':' && isLetter(source[0]))
+}
+
+func isNamedPipe(source string) bool {
+	return strings.HasPrefix(source, `\\.\pipe\`) || strings.HasPrefix(source, "//./pipe/")
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isSocket detects unix sockets, even when they don't exist on this machine,
+// like the Docker socket of a remote engine.
+func isSocket(path string) bool {
+	if strings.HasSuffix(path, ".sock") {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
+
+// resolve expands ~, cleans the path and follows symlinks so that
+// a link can't be used to escape the policy.
+func resolve(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := user.HomeDir()
+		if err != nil {
+			return "", fmt.Errorf("resolving %s: %w", path, err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", path, err)
+	}
+
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved, nil
+	}
+
+	// Resolve the longest existing parent.
+	dir, rest := filepath.Dir(path), filepath.Base(path)
+	for dir != filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		dir, rest = filepath.Dir(dir), filepath.Join(filepath.Base(dir), rest)
+	}
+
+	return path, nil
+}
+
+// within tells whether path is prefix or is inside prefix.
+func within(path, prefix string) bool {
+	if path == prefix {
+		return true
+	}
+	rel, err := filepath.Rel(prefix, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
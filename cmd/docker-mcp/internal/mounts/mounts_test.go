@@ -0,0 +1,115 @@
+package mounts
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnly(t *testing.T) {
+	assert.Equal(t, "/src:/dst:ro", ReadOnly("/src:/dst"))
+	assert.Equal(t, "/src:/dst:ro", ReadOnly("/src:/dst:ro"))
+	assert.Equal(t, "/src:/dst:ro", ReadOnly("/src:/dst:rw"))
+	assert.Equal(t, "/src:/dst:z,ro", ReadOnly("/src:/dst:z"))
+	assert.Equal(t, "/src:/dst:z,ro", ReadOnly("/src:/dst:rw,z"))
+	assert.Equal(t, "/src:/dst:ro,Z", ReadOnly("/src:/dst:ro,Z"))
+}
+
+func TestSplitMount(t *testing.T) {
+	source, options := splitMount("/src:/dst:ro")
+	assert.Equal(t, "/src", source)
+	assert.Equal(t, "ro", options)
+
+	source, options = splitMount(`C:\Users\me:/dst`)
+	assert.Equal(t, `C:\Users\me`, source)
+	assert.Empty(t, options)
+}
+
+func TestApplyDenied(t *testing.T) {
+	policy := &Policy{Denied: []string{"/etc", "~/.ssh"}}
+
+	_, err := policy.Apply("/etc/passwd:/passwd")
+	require.ErrorContains(t, err, `mount "/etc/passwd:/passwd" is not allowed: /etc/passwd is denied`)
+
+	_, err = policy.Apply("/:/host")
+	require.ErrorContains(t, err, "/ contains denied path /etc")
+
+	_, err = policy.Apply("~:/home")
+	require.ErrorContains(t, err, "contains denied path")
+
+	_, err = policy.Apply("~/.ssh/id_rsa:/key")
+	require.ErrorContains(t, err, "is denied")
+
+	mount, err := policy.Apply("/etcetera:/data")
+	require.NoError(t, err)
+	assert.Equal(t, "/etcetera:/data", mount)
+}
+
+func TestApplySymlink(t *testing.T) {
+	dir := t.TempDir()
+	secrets := filepath.Join(dir, "secrets")
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Mkdir(secrets, 0o700))
+	require.NoError(t, os.Symlink(secrets, link))
+
+	policy := &Policy{Denied: []string{secrets}}
+
+	_, err := policy.Apply(link + ":/data")
+	require.ErrorContains(t, err, "is denied")
+}
+
+func TestApplyAllowed(t *testing.T) {
+	policy := &Policy{Allowed: []string{"/projects"}}
+
+	mount, err := policy.Apply("/projects/app:/app")
+	require.NoError(t, err)
+	assert.Equal(t, "/projects/app:/app", mount)
+
+	_, err = policy.Apply("/var/lib:/lib")
+	require.ErrorContains(t, err, "/var/lib is outside of the allowed paths (/projects)")
+
+	// Named volumes are not host paths.
+	mount, err = policy.Apply("cache:/cache")
+	require.NoError(t, err)
+	assert.Equal(t, "cache:/cache", mount)
+}
+
+func TestApplyReadOnly(t *testing.T) {
+	policy := &Policy{ReadOnly: []string{"/projects"}}
+
+	mount, err := policy.Apply("/projects/app:/app:rw")
+	require.NoError(t, err)
+	assert.Equal(t, "/projects/app:/app:ro", mount)
+
+	mount, err = policy.Apply("/tmp/app:/app")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/app:/app", mount)
+}
+
+func TestApplySockets(t *testing.T) {
+	policy := &Policy{}
+
+	_, err := policy.Apply("/var/run/docker.sock:/var/run/docker.sock")
+	require.ErrorContains(t, err, "docker.sock is a socket, sockets can't be mounted")
+
+	_, err = policy.Apply(`\\.\pipe\docker_engine:\\.\pipe\docker_engine`)
+	require.ErrorContains(t, err, "named pipes can't be mounted")
+
+	// A socket that doesn't look like one.
+	path := filepath.Join(t.TempDir(), "api")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	_, err = policy.Apply(path + ":/api")
+	require.ErrorContains(t, err, "is a socket")
+
+	policy.AllowSockets = true
+	mount, err := policy.Apply("/var/run/docker.sock:/var/run/docker.sock")
+	require.NoError(t, err)
+	assert.Equal(t, "/var/run/docker.sock:/var/run/docker.sock", mount)
+}
@@ -0,0 +1,219 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-units"
+)
+
+// ParseRunArgs turns the `docker run` flags the gateway uses into Docker Engine
+// API configurations. The values of the environment variables passed by name,
+// with `-e NAME`, are read from env. Unsupported flags are an error, rather
+// than silently ignored.
+func ParseRunArgs(args, env []string) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	config := &container.Config{
+		Labels: map[string]string{},
+	}
+	hostConfig := &container.HostConfig{}
+	networkingConfig := &network.NetworkingConfig{}
+
+	if len(args) > 0 && args[0] == "run" {
+		args = args[1:]
+	}
+
+	for i := 0; i < len(args); i++ {
+		flag := args[i]
+
+		// Flags with a value, either as the next argument or after a `=`.
+		value := func() (string, error) {
+			if name, v, found := strings.Cut(flag, "="); found && strings.HasPrefix(name, "--") {
+				return v, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("flag %s needs a value", flag)
+			}
+			i++
+			return args[i], nil
+		}
+		name, _, _ := strings.Cut(flag, "=")
+
+		var err error
+		switch name {
+		case "--rm":
+			hostConfig.AutoRemove = true
+		case "-i", "--interactive":
+			config.OpenStdin = true
+			config.StdinOnce = true
+			config.AttachStdin = true
+		case "--init":
+			init := true
+			hostConfig.Init = &init
+		case "--read-only":
+			hostConfig.ReadonlyRootfs = true
+		case "--privileged":
+			hostConfig.Privileged = true
+		case "--pull":
+			var policy string
+			if policy, err = value(); err == nil && policy != pullNever && policy != pullMissing {
+				err = fmt.Errorf("unsupported pull policy %q", policy)
+			}
+		case "--security-opt":
+			var opt string
+			if opt, err = value(); err == nil {
+				if opt, err = inlineSeccompProfile(opt); err == nil {
+					hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, opt)
+				}
+			}
+		case "--cpus":
+			var cpus string
+			if cpus, err = value(); err == nil {
+				var nanoCPUs float64
+				if nanoCPUs, err = strconv.ParseFloat(cpus, 64); err == nil {
+					hostConfig.NanoCPUs = int64(nanoCPUs * 1e9)
+				}
+			}
+		case "--memory", "-m":
+			var memory string
+			if memory, err = value(); err == nil {
+				hostConfig.Memory, err = units.RAMInBytes(memory)
+			}
+		case "--pids-limit":
+			var pids string
+			if pids, err = value(); err == nil {
+				var limit int64
+				if limit, err = strconv.ParseInt(pids, 10, 64); err == nil {
+					hostConfig.PidsLimit = &limit
+				}
+			}
+		case "--ulimit":
+			var ulimit string
+			if ulimit, err = value(); err == nil {
+				var parsed *units.Ulimit
+				if parsed, err = units.ParseUlimit(ulimit); err == nil {
+					hostConfig.Ulimits = append(hostConfig.Ulimits, &container.Ulimit{Name: parsed.Name, Soft: parsed.Soft, Hard: parsed.Hard})
+				}
+			}
+		case "--cap-add":
+			var capability string
+			if capability, err = value(); err == nil {
+				hostConfig.CapAdd = append(hostConfig.CapAdd, capability)
+			}
+		case "--cap-drop":
+			var capability string
+			if capability, err = value(); err == nil {
+				hostConfig.CapDrop = append(hostConfig.CapDrop, capability)
+			}
+		case "--tmpfs":
+			var tmpfs string
+			if tmpfs, err = value(); err == nil {
+				if hostConfig.Tmpfs == nil {
+					hostConfig.Tmpfs = map[string]string{}
+				}
+				path, options, _ := strings.Cut(tmpfs, ":")
+				hostConfig.Tmpfs[path] = options
+			}
+		case "--runtime":
+			hostConfig.Runtime, err = value()
+		case "-l", "--label":
+			var label string
+			if label, err = value(); err == nil {
+				key, v, _ := strings.Cut(label, "=")
+				config.Labels[key] = v
+			}
+		case "-e", "--env":
+			var variable string
+			if variable, err = value(); err == nil {
+				if strings.Contains(variable, "=") {
+					config.Env = append(config.Env, variable)
+				} else if v, found := lookupEnv(env, variable); found {
+					config.Env = append(config.Env, variable+"="+v)
+				}
+			}
+		case "-v", "--volume":
+			var volume string
+			if volume, err = value(); err == nil {
+				hostConfig.Binds = append(hostConfig.Binds, volume)
+			}
+		case "-u", "--user":
+			config.User, err = value()
+		case "--network":
+			var nw string
+			if nw, err = value(); err == nil {
+				if hostConfig.NetworkMode == "" {
+					hostConfig.NetworkMode = container.NetworkMode(nw)
+				}
+				if nw != "none" && nw != "host" {
+					if networkingConfig.EndpointsConfig == nil {
+						networkingConfig.EndpointsConfig = map[string]*network.EndpointSettings{}
+					}
+					networkingConfig.EndpointsConfig[nw] = &network.EndpointSettings{}
+				}
+			}
+		case "--dns":
+			var dns string
+			if dns, err = value(); err == nil {
+				hostConfig.DNS = append(hostConfig.DNS, dns)
+			}
+		default:
+			err = fmt.Errorf("unsupported flag %s", flag)
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid docker run flag %s: %w", name, err)
+		}
+	}
+
+	return config, hostConfig, networkingConfig, nil
+}
+
+// inlineSeccompProfile replaces the path of a seccomp profile with its
+// content, like the `docker` CLI does: the Engine API takes the profile itself.
+func inlineSeccompProfile(opt string) (string, error) {
+	profile, found := strings.CutPrefix(opt, "seccomp=")
+	if !found || profile == "unconfined" {
+		return opt, nil
+	}
+
+	buf, err := os.ReadFile(profile)
+	if err != nil {
+		return "", fmt.Errorf("reading seccomp profile: %w", err)
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, buf); err != nil {
+		return "", fmt.Errorf("compacting seccomp profile %s: %w", profile, err)
+	}
+	return "seccomp=" + compacted.String(), nil
+}
+
+const (
+	pullNever   = "never"
+	pullMissing = "missing"
+)
+
+// pullPolicy is the value of the --pull flag, if any.
+func pullPolicy(args []string) string {
+	policy := pullNever
+	for i, arg := range args {
+		if arg == "--pull" && i+1 < len(args) {
+			policy = args[i+1]
+		} else if v, found := strings.CutPrefix(arg, "--pull="); found {
+			policy = v
+		}
+	}
+	return policy
+}
+
+func lookupEnv(env []string, name string) (string, bool) {
+	for _, e := range env {
+		if v, found := strings.CutPrefix(e, name+"="); found {
+			return v, true
+		}
+	}
+	return "", false
+}
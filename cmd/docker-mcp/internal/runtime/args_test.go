@@ -0,0 +1,85 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRunArgs(t *testing.T) {
+	config, hostConfig, networkingConfig, err := ParseRunArgs([]string{
+		"run", "--rm", "-i", "--init", "--security-opt", "no-new-privileges", "--cpus", "0.5", "--memory", "2Gb", "--pull", "never",
+		"--read-only", "--tmpfs", "/tmp:rw,noexec", "--cap-drop", "ALL", "--pids-limit", "256", "--ulimit", "nofile=1024:2048",
+		"-l", "docker-mcp=true", "-l", "docker-mcp-name=test",
+		"-e", "API_KEY", "-e", "MISSING", "-e", "URL=http://localhost",
+		"-v", "/data:/data:ro", "-u", "1000",
+		"--network", "internal", "--network", "external", "--dns", "10.0.0.2",
+	}, []string{"API_KEY=secret"})
+	require.NoError(t, err)
+
+	assert.True(t, config.OpenStdin)
+	assert.True(t, config.StdinOnce)
+	assert.Equal(t, map[string]string{"docker-mcp": "true", "docker-mcp-name": "test"}, config.Labels)
+	assert.Equal(t, []string{"API_KEY=secret", "URL=http://localhost"}, config.Env)
+	assert.Equal(t, "1000", config.User)
+
+	assert.True(t, hostConfig.AutoRemove)
+	assert.True(t, *hostConfig.Init)
+	assert.True(t, hostConfig.ReadonlyRootfs)
+	assert.Equal(t, []string{"no-new-privileges"}, hostConfig.SecurityOpt)
+	assert.Equal(t, int64(500_000_000), hostConfig.NanoCPUs)
+	assert.Equal(t, int64(2*1024*1024*1024), hostConfig.Memory)
+	assert.Equal(t, map[string]string{"/tmp": "rw,noexec"}, hostConfig.Tmpfs)
+	assert.Equal(t, []string{"ALL"}, []string(hostConfig.CapDrop))
+	assert.Equal(t, int64(256), *hostConfig.PidsLimit)
+	assert.Equal(t, []*container.Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}}, hostConfig.Ulimits)
+	assert.Equal(t, []string{"/data:/data:ro"}, hostConfig.Binds)
+	assert.Equal(t, container.NetworkMode("internal"), hostConfig.NetworkMode)
+	assert.Equal(t, []string{"10.0.0.2"}, hostConfig.DNS)
+
+	assert.Equal(t, map[string]*network.EndpointSettings{
+		"internal": {},
+		"external": {},
+	}, networkingConfig.EndpointsConfig)
+}
+
+func TestParseRunArgsNoNetwork(t *testing.T) {
+	_, hostConfig, networkingConfig, err := ParseRunArgs([]string{"--network", "none"}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, container.NetworkMode("none"), hostConfig.NetworkMode)
+	assert.Empty(t, networkingConfig.EndpointsConfig)
+}
+
+func TestParseRunArgsSeccompProfile(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "profile.json")
+	require.NoError(t, os.WriteFile(profile, []byte(`{
+  "defaultAction": "SCMP_ACT_ERRNO",
+  "syscalls": []
+}`), 0o644))
+
+	_, hostConfig, _, err := ParseRunArgs([]string{"--security-opt", "seccomp=" + profile, "--security-opt", "seccomp=unconfined"}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{`seccomp={"defaultAction":"SCMP_ACT_ERRNO","syscalls":[]}`, "seccomp=unconfined"}, hostConfig.SecurityOpt)
+}
+
+func TestParseRunArgsErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"--detach"},
+		{"--memory"},
+		{"--memory", "lots"},
+		{"--cpus", "one"},
+		{"--pull", "always"},
+		{"--ulimit", "nofile"},
+		{"--security-opt", "seccomp=/missing/profile.json"},
+	} {
+		_, _, _, err := ParseRunArgs(args, nil)
+		assert.Error(t, err, args)
+	}
+}
@@ -0,0 +1,201 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewCLI returns a Runtime that shells out to the `docker` CLI.
+func NewCLI() Runtime {
+	return &cliRuntime{binary: "docker"}
+}
+
+type cliRuntime struct {
+	// binary is `docker` or any CLI that accepts the same `run` flags.
+	binary string
+}
+
+func (r *cliRuntime) command(ctx context.Context, spec Spec) (*exec.Cmd, error) {
+	if len(spec.Files) > 0 {
+		return r.startCommand(ctx, spec)
+	}
+
+	args := append([]string{"run"}, spec.Args...)
+	args = append(args, spec.Image)
+	args = append(args, spec.Command...)
+
+	cmd := exec.CommandContext(ctx, r.binary, args...)
+	cmd.Env = spec.Env
+	return cmd, nil
+}
+
+// startCommand creates the container, copies the files into it and returns
+// the command that starts it, since `run` can't copy files before the
+// container starts.
+func (r *cliRuntime) startCommand(ctx context.Context, spec Spec) (*exec.Cmd, error) {
+	config, hostConfig, _, err := ParseRunArgs(spec.Args, spec.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"create"}, spec.Args...)
+	if hostConfig.ReadonlyRootfs {
+		for _, dir := range fileDirs(spec.Files) {
+			args = append(args, "-v", dir)
+		}
+	}
+	args = append(args, spec.Image)
+	args = append(args, spec.Command...)
+
+	create := exec.CommandContext(ctx, r.binary, args...)
+	create.Env = spec.Env
+	stderr := &stderrTail{}
+	create.Stderr = stderr
+	out, err := create.Output()
+	if err != nil {
+		return nil, fmt.Errorf("creating container for %s: %w", spec.Name, exitError(spec.Name, err, stderr))
+	}
+	id := strings.TrimSpace(string(out))
+
+	archive, err := filesArchive(spec.Files)
+	if err == nil {
+		cp := exec.CommandContext(ctx, r.binary, "cp", "-a", "-", id+":/")
+		cp.Stdin = archive
+		stderr := &stderrTail{}
+		cp.Stderr = stderr
+		err = exitError(spec.Name, cp.Run(), stderr)
+	}
+	if err != nil {
+		_ = exec.CommandContext(context.WithoutCancel(ctx), r.binary, "rm", "-f", id).Run()
+		return nil, fmt.Errorf("copying files into container of %s: %w", spec.Name, err)
+	}
+
+	if config.OpenStdin {
+		return exec.CommandContext(ctx, r.binary, "start", "-a", "-i", id), nil
+	}
+	return exec.CommandContext(ctx, r.binary, "start", "-a", id), nil
+}
+
+func (r *cliRuntime) Run(ctx context.Context, spec Spec) ([]byte, error) {
+	cmd, err := r.command(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	return runCommand(cmd, spec)
+}
+
+func (r *cliRuntime) Start(ctx context.Context, spec Spec) (Process, error) {
+	cmd, err := r.command(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	return startCommand(cmd, spec)
+}
+
+// runCommand runs a command to completion and returns its stdout.
+func runCommand(cmd *exec.Cmd, spec Spec) ([]byte, error) {
+	stderr := &stderrTail{}
+	cmd.Stderr = stderrWriter(spec, stderr)
+
+	out, err := cmd.Output()
+	return out, exitError(spec.Name, err, stderr)
+}
+
+// startCommand starts a command and attaches to its stdin and stdout.
+func startCommand(cmd *exec.Cmd, spec Spec) (Process, error) {
+	stderr := &stderrTail{}
+	cmd.Stderr = stderrWriter(spec, stderr)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	// Not cmd.StdoutPipe(), that is closed as soon as the command exits,
+	// even if the client still has to read its last messages.
+	stdout, stdoutWriter := io.Pipe()
+	cmd.Stdout = stdoutWriter
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", spec.Name, err)
+	}
+
+	p := &cmdProcess{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: stdout,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		p.err = exitError(spec.Name, cmd.Wait(), stderr)
+		_ = stdoutWriter.Close()
+		close(p.done)
+	}()
+
+	return p, nil
+}
+
+// stderrWriter sends stderr to the Spec's writer, if any, and keeps its tail
+// for error messages.
+func stderrWriter(spec Spec, tail *stderrTail) io.Writer {
+	if spec.Stderr != nil {
+		return io.MultiWriter(spec.Stderr, tail)
+	}
+	return tail
+}
+
+// exitError turns the error of the command into an *ExitError when it exited
+// with a non zero code.
+func exitError(name string, err error, stderr *stderrTail) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{Name: name, ExitCode: exitErr.ExitCode(), Stderr: stderr.String()}
+	}
+	return err
+}
+
+type cmdProcess struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stdout    *io.PipeReader
+	done      chan struct{}
+	err       error
+	closeOnce sync.Once
+}
+
+func (p *cmdProcess) Read(b []byte) (int, error) {
+	return p.stdout.Read(b)
+}
+
+func (p *cmdProcess) Write(b []byte) (int, error) {
+	return p.stdin.Write(b)
+}
+
+func (p *cmdProcess) Wait(ctx context.Context) error {
+	select {
+	case <-p.done:
+		return p.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close closes stdin and gives the command a chance to exit before it gets
+// killed.
+func (p *cmdProcess) Close() error {
+	p.closeOnce.Do(func() {
+		_ = p.stdin.Close()
+		select {
+		case <-p.done:
+		case <-time.After(stopTimeout):
+			_ = p.cmd.Process.Kill()
+		}
+		_ = p.stdout.CloseWithError(io.ErrClosedPipe)
+		<-p.done
+	})
+	return nil
+}
@@ -0,0 +1,276 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// EngineAPI is the subset of the Docker Engine API that the engine runtime
+// uses.
+type EngineAPI interface {
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error)
+	ContainerAttach(ctx context.Context, containerID string, options container.AttachOptions) (types.HijackedResponse, error)
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+	NetworkConnect(ctx context.Context, network, container string, config *network.EndpointSettings) error
+	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error
+	ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.WaitResponse, <-chan error)
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+	ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error)
+}
+
+// NewEngine returns a Runtime that talks to the Docker Engine API directly,
+// without the `docker` CLI binary.
+func NewEngine(api EngineAPI) Runtime {
+	return &engineRuntime{api: api}
+}
+
+type engineRuntime struct {
+	api EngineAPI
+}
+
+func (r *engineRuntime) Run(ctx context.Context, spec Spec) ([]byte, error) {
+	p, err := r.start(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	out, readErr := io.ReadAll(p)
+	if err := p.Wait(ctx); err != nil {
+		return out, err
+	}
+	return out, readErr
+}
+
+func (r *engineRuntime) Start(ctx context.Context, spec Spec) (Process, error) {
+	return r.start(ctx, spec)
+}
+
+func (r *engineRuntime) start(ctx context.Context, spec Spec) (*engineProcess, error) {
+	config, hostConfig, networkingConfig, err := ParseRunArgs(spec.Args, spec.Env)
+	if err != nil {
+		return nil, err
+	}
+	config.Image = spec.Image
+	config.Cmd = spec.Command
+	config.AttachStdout = true
+	config.AttachStderr = true
+	if hostConfig.ReadonlyRootfs {
+		for _, dir := range fileDirs(spec.Files) {
+			if config.Volumes == nil {
+				config.Volumes = map[string]struct{}{}
+			}
+			config.Volumes[dir] = struct{}{}
+		}
+	}
+
+	otherNetworks := splitEndpoints(hostConfig, networkingConfig)
+
+	resp, err := r.api.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, "")
+	if cerrdefs.IsNotFound(err) && pullPolicy(spec.Args) == pullMissing {
+		if err := r.pull(ctx, spec.Image); err != nil {
+			return nil, err
+		}
+		resp, err = r.api.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, "")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating container for %s: %w", spec.Name, err)
+	}
+	id := resp.ID
+
+	// From now on, the container has to be removed if anything goes wrong.
+	remove := func() {
+		_ = r.api.ContainerRemove(context.WithoutCancel(ctx), id, container.RemoveOptions{Force: true})
+	}
+
+	if len(spec.Files) > 0 {
+		archive, err := filesArchive(spec.Files)
+		if err == nil {
+			err = r.api.CopyToContainer(ctx, id, "/", archive, container.CopyToContainerOptions{CopyUIDGID: true})
+		}
+		if err != nil {
+			remove()
+			return nil, fmt.Errorf("copying files into container of %s: %w", spec.Name, err)
+		}
+	}
+
+	for _, endpoint := range otherNetworks {
+		if err := r.api.NetworkConnect(ctx, endpoint.name, id, endpoint.settings); err != nil {
+			remove()
+			return nil, fmt.Errorf("connecting container of %s to network %s: %w", spec.Name, endpoint.name, err)
+		}
+	}
+
+	hijacked, err := r.api.ContainerAttach(ctx, id, container.AttachOptions{
+		Stream: true,
+		Stdin:  config.OpenStdin,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		remove()
+		return nil, fmt.Errorf("attaching to container of %s: %w", spec.Name, err)
+	}
+
+	// Like `docker run`, start waiting before the container starts, so that
+	// its exit code is never missed, even with --rm.
+	condition := container.WaitConditionNextExit
+	if hostConfig.AutoRemove {
+		condition = container.WaitConditionRemoved
+	}
+	waitCh, waitErrCh := r.api.ContainerWait(context.WithoutCancel(ctx), id, condition)
+
+	if err := r.api.ContainerStart(ctx, id, container.StartOptions{}); err != nil {
+		hijacked.Close()
+		remove()
+		return nil, fmt.Errorf("starting container of %s: %w", spec.Name, err)
+	}
+
+	stdout, stdoutWriter := io.Pipe()
+	p := &engineProcess{
+		api:      r.api,
+		id:       id,
+		hijacked: hijacked,
+		stdout:   stdout,
+		done:     make(chan struct{}),
+	}
+
+	// Split stdout and stderr, that the engine multiplexes on the same stream.
+	stderr := &stderrTail{}
+	demuxed := make(chan struct{})
+	go func() {
+		_, err := stdcopy.StdCopy(stdoutWriter, stderrWriter(spec, stderr), hijacked.Reader)
+		_ = stdoutWriter.CloseWithError(err)
+		close(demuxed)
+	}()
+
+	go func() {
+		defer close(p.done)
+
+		var err error
+		select {
+		case wait := <-waitCh:
+			switch {
+			case wait.Error != nil:
+				err = fmt.Errorf("waiting for container of %s: %s", spec.Name, wait.Error.Message)
+			case wait.StatusCode != 0:
+				<-demuxed
+				err = &ExitError{Name: spec.Name, ExitCode: int(wait.StatusCode), Stderr: stderr.String()}
+			}
+		case err = <-waitErrCh:
+			err = fmt.Errorf("waiting for container of %s: %w", spec.Name, err)
+		}
+		p.err = err
+	}()
+
+	// Cancelling the context kills the container, like it would kill the
+	// `docker` CLI.
+	go func() {
+		select {
+		case <-ctx.Done():
+			remove()
+		case <-p.done:
+		}
+	}()
+
+	return p, nil
+}
+
+// pull pulls an image that's missing, like `docker run --pull missing` does.
+type endpoint struct {
+	name     string
+	settings *network.EndpointSettings
+}
+
+// splitEndpoints keeps only the endpoint of the primary network in the
+// networking config and returns the others. Before API 1.44, the engine
+// doesn't accept more than one endpoint when a container is created: the
+// container is connected to the other networks before it starts, like
+// `docker run` does.
+func splitEndpoints(hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig) []endpoint {
+	var others []endpoint
+	for name, settings := range networkingConfig.EndpointsConfig {
+		if name != string(hostConfig.NetworkMode) {
+			others = append(others, endpoint{name: name, settings: settings})
+			delete(networkingConfig.EndpointsConfig, name)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i].name < others[j].name })
+	return others
+}
+
+func (r *engineRuntime) pull(ctx context.Context, imageName string) error {
+	response, err := r.api.ImagePull(ctx, imageName, image.PullOptions{})
+	if err != nil {
+		return fmt.Errorf("pulling image %s: %w", imageName, err)
+	}
+	defer response.Close()
+
+	if _, err := io.Copy(io.Discard, response); err != nil {
+		return fmt.Errorf("pulling image %s: %w", imageName, err)
+	}
+	return nil
+}
+
+type engineProcess struct {
+	api       EngineAPI
+	id        string
+	hijacked  types.HijackedResponse
+	stdout    *io.PipeReader
+	done      chan struct{}
+	err       error
+	closeOnce sync.Once
+}
+
+func (p *engineProcess) Read(b []byte) (int, error) {
+	return p.stdout.Read(b)
+}
+
+func (p *engineProcess) Write(b []byte) (int, error) {
+	return p.hijacked.Conn.Write(b)
+}
+
+func (p *engineProcess) Wait(ctx context.Context) error {
+	select {
+	case <-p.done:
+		return p.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close closes stdin and gives the container a chance to exit before it gets
+// removed.
+func (p *engineProcess) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		_ = p.hijacked.CloseWrite()
+
+		select {
+		case <-p.done:
+		case <-time.After(stopTimeout):
+			err = p.api.ContainerRemove(context.Background(), p.id, container.RemoveOptions{Force: true})
+		}
+
+		p.hijacked.Close()
+		_ = p.stdout.CloseWithError(io.ErrClosedPipe)
+		<-p.done
+	})
+
+	if err != nil && !cerrdefs.IsNotFound(err) {
+		return fmt.Errorf("removing container %s: %w", p.id, err)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package runtime
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEngine runs a Go function in place of each container.
+type fakeEngine struct {
+	run func(config *container.Config, stdin io.Reader, stdout, stderr io.Writer) int
+
+	mu        sync.Mutex
+	missing   bool
+	pulled    []string
+	config    *container.Config
+	endpoints []string
+	connected []string
+	conn      net.Conn
+	exit      chan container.WaitResponse
+	copied    []byte
+	started   bool
+	removed   []string
+}
+
+func (f *fakeEngine) ContainerCreate(_ context.Context, config *container.Config, _ *container.HostConfig, networkingConfig *network.NetworkingConfig, _ *ocispec.Platform, _ string) (container.CreateResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.missing {
+		return container.CreateResponse{}, cerrdefs.ErrNotFound.WithMessage("No such image: " + config.Image)
+	}
+	f.config = config
+	f.endpoints = nil
+	for name := range networkingConfig.EndpointsConfig {
+		f.endpoints = append(f.endpoints, name)
+	}
+	f.exit = make(chan container.WaitResponse, 1)
+	return container.CreateResponse{ID: "ctr"}, nil
+}
+
+func (f *fakeEngine) ContainerAttach(context.Context, string, container.AttachOptions) (types.HijackedResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	client, server := net.Pipe()
+	f.conn = server
+	return types.NewHijackedResponse(client, ""), nil
+}
+
+func (f *fakeEngine) ContainerStart(context.Context, string, container.StartOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.started = true
+	go func() {
+		code := f.run(f.config, f.conn, stdcopy.NewStdWriter(f.conn, stdcopy.Stdout), stdcopy.NewStdWriter(f.conn, stdcopy.Stderr))
+		_ = f.conn.Close()
+		f.exit <- container.WaitResponse{StatusCode: int64(code)}
+	}()
+	return nil
+}
+
+func (f *fakeEngine) NetworkConnect(_ context.Context, network, _ string, _ *network.EndpointSettings) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.started {
+		return errors.New("unexpected connect")
+	}
+	f.connected = append(f.connected, network)
+	return nil
+}
+
+func (f *fakeEngine) CopyToContainer(_ context.Context, _, dstPath string, content io.Reader, options container.CopyToContainerOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.started || dstPath != "/" || !options.CopyUIDGID {
+		return errors.New("unexpected copy")
+	}
+	buf, err := io.ReadAll(content)
+	f.copied = buf
+	return err
+}
+
+func (f *fakeEngine) ContainerWait(context.Context, string, container.WaitCondition) (<-chan container.WaitResponse, <-chan error) {
+	return f.exit, make(chan error)
+}
+
+func (f *fakeEngine) ContainerRemove(_ context.Context, containerID string, _ container.RemoveOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.removed = append(f.removed, containerID)
+	return nil
+}
+
+func (f *fakeEngine) ImagePull(_ context.Context, ref string, _ image.PullOptions) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.missing = false
+	f.pulled = append(f.pulled, ref)
+	return io.NopCloser(strings.NewReader(`{"status":"Downloaded newer image"}`)), nil
+}
+
+func TestEngineRun(t *testing.T) {
+	engine := &fakeEngine{
+		run: func(config *container.Config, _ io.Reader, stdout, stderr io.Writer) int {
+			_, _ = io.WriteString(stdout, strings.Join(config.Cmd, " "))
+			_, _ = io.WriteString(stderr, "some logs")
+			return 0
+		},
+	}
+
+	out, err := NewEngine(engine).Run(t.Context(), Spec{
+		Name:    "echo",
+		Args:    []string{"--rm", "-e", "TOKEN"},
+		Image:   "alpine",
+		Command: []string{"echo", "hello"},
+		Env:     []string{"TOKEN=secret"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "echo hello", string(out))
+	assert.Equal(t, "alpine", engine.config.Image)
+	assert.Equal(t, []string{"TOKEN=secret"}, engine.config.Env)
+	assert.False(t, engine.config.OpenStdin)
+}
+
+func TestEngineRunNetworks(t *testing.T) {
+	engine := &fakeEngine{
+		run: func(*container.Config, io.Reader, io.Writer, io.Writer) int { return 0 },
+	}
+
+	_, err := NewEngine(engine).Run(t.Context(), Spec{
+		Name:  "networks",
+		Args:  []string{"--network", "internal", "--network", "proxies", "--network", "external"},
+		Image: "alpine",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"internal"}, engine.endpoints)
+	assert.Equal(t, []string{"external", "proxies"}, engine.connected)
+}
+
+func TestEngineRunPullMissing(t *testing.T) {
+	engine := &fakeEngine{
+		missing: true,
+		run: func(_ *container.Config, _ io.Reader, stdout, _ io.Writer) int {
+			_, _ = io.WriteString(stdout, "ok")
+			return 0
+		},
+	}
+
+	out, err := NewEngine(engine).Run(t.Context(), Spec{Name: "pull", Args: []string{"--pull", "missing"}, Image: "alpine"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ok", string(out))
+	assert.Equal(t, []string{"alpine"}, engine.pulled)
+}
+
+func TestEngineRunNeverPull(t *testing.T) {
+	engine := &fakeEngine{missing: true}
+
+	_, err := NewEngine(engine).Run(t.Context(), Spec{Name: "pull", Args: []string{"--pull", "never"}, Image: "alpine"})
+
+	require.Error(t, err)
+	assert.True(t, cerrdefs.IsNotFound(err))
+	assert.Empty(t, engine.pulled)
+}
+
+func TestEngineRunExitCode(t *testing.T) {
+	engine := &fakeEngine{
+		run: func(_ *container.Config, _ io.Reader, stdout, stderr io.Writer) int {
+			_, _ = io.WriteString(stdout, "partial output")
+			_, _ = io.WriteString(stderr, "something went wrong\n")
+			return 3
+		},
+	}
+
+	out, err := NewEngine(engine).Run(t.Context(), Spec{Name: "failing", Image: "alpine"})
+
+	assert.Equal(t, "partial output", string(out))
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, exitErr.ExitCode)
+	assert.Equal(t, "failing exited with code 3: something went wrong", exitErr.Error())
+}
+
+func TestEngineStart(t *testing.T) {
+	engine := &fakeEngine{
+		run: func(_ *container.Config, stdin io.Reader, stdout, _ io.Writer) int {
+			line, _ := bufio.NewReader(stdin).ReadString('\n')
+			_, _ = io.WriteString(stdout, strings.ToUpper(line))
+			return 0
+		},
+	}
+
+	process, err := NewEngine(engine).Start(t.Context(), Spec{Name: "upper", Args: []string{"-i"}, Image: "alpine"})
+	require.NoError(t, err)
+	assert.True(t, engine.config.OpenStdin)
+
+	_, err = io.WriteString(process, "hello\n")
+	require.NoError(t, err)
+
+	line, err := bufio.NewReader(process).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO\n", line)
+
+	require.NoError(t, process.Wait(t.Context()))
+	require.NoError(t, process.Close())
+}
+
+func TestEngineStartCancel(t *testing.T) {
+	stop := make(chan struct{})
+	engine := &fakeEngine{
+		run: func(_ *container.Config, _ io.Reader, _, _ io.Writer) int {
+			<-stop
+			return 137
+		},
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	process, err := NewEngine(engine).Start(ctx, Spec{Name: "sleep", Image: "alpine"})
+	require.NoError(t, err)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		engine.mu.Lock()
+		defer engine.mu.Unlock()
+		return len(engine.removed) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	close(stop)
+	var exitErr *ExitError
+	require.ErrorAs(t, process.Wait(t.Context()), &exitErr)
+	assert.Equal(t, 137, exitErr.ExitCode)
+}
+
+func TestEngineRunFiles(t *testing.T) {
+	engine := &fakeEngine{
+		run: func(_ *container.Config, _ io.Reader, _, _ io.Writer) int {
+			return 0
+		},
+	}
+
+	_, err := NewEngine(engine).Run(t.Context(), Spec{
+		Name:  "psql",
+		Args:  []string{"--rm", "--read-only"},
+		Image: "postgres",
+		Files: []File{{Path: "/run/secrets/password", Content: []byte("s3cr3t"), Mode: 0o400, UID: 999}},
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, engine.config.Env)
+	assert.Equal(t, map[string]struct{}{"/run/secrets": {}}, engine.config.Volumes)
+	assert.Equal(t, map[string]string{"run/secrets/password": "s3cr3t 400 999"}, readArchive(t, engine.copied))
+}
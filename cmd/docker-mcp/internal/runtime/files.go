@@ -0,0 +1,65 @@
+package runtime
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+)
+
+// File is a file to copy into a container before it starts, such as a secret
+// that must not be passed as an environment variable.
+type File struct {
+	// Path is the absolute path of the file in the container.
+	Path    string
+	Content []byte
+	Mode    int64
+	UID     int
+}
+
+// filesArchive is a tar archive of the files, to extract at the root of the
+// container. Missing parent directories are created by the engine.
+func filesArchive(files []File) (io.Reader, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	for _, file := range files {
+		if !path.IsAbs(file.Path) {
+			return nil, fmt.Errorf("file path %q is not absolute", file.Path)
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     strings.TrimPrefix(path.Clean(file.Path), "/"),
+			Mode:     file.Mode,
+			Uid:      file.UID,
+			Size:     int64(len(file.Content)),
+		}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(file.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// fileDirs are the directories of the files. With a read-only root
+// filesystem, the files can only be copied to volumes, so each directory gets
+// an anonymous volume, that's removed along with the container.
+func fileDirs(files []File) []string {
+	var dirs []string
+	for _, file := range files {
+		dir := path.Dir(path.Clean(file.Path))
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
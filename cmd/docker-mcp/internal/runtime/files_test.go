@@ -0,0 +1,60 @@
+package runtime
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readArchive returns the content, mode and uid of each file in a tar archive.
+func readArchive(t *testing.T, buf []byte) map[string]string {
+	t.Helper()
+
+	files := map[string]string{}
+	tr := tar.NewReader(bytes.NewReader(buf))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[header.Name] = fmt.Sprintf("%s %o %d", content, header.Mode, header.Uid)
+	}
+}
+
+func TestFilesArchive(t *testing.T) {
+	archive, err := filesArchive([]File{
+		{Path: "/run/secrets/token", Content: []byte("ghp_123"), Mode: 0o400},
+		{Path: "/home/app/.pgpass", Content: []byte("db:5432:*:app:pass\n"), Mode: 0o600, UID: 1000},
+	})
+	require.NoError(t, err)
+
+	buf, err := io.ReadAll(archive)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"run/secrets/token": "ghp_123 400 0",
+		"home/app/.pgpass":  "db:5432:*:app:pass\n 600 1000",
+	}, readArchive(t, buf))
+}
+
+func TestFilesArchiveRelativePath(t *testing.T) {
+	_, err := filesArchive([]File{{Path: "secrets/token"}})
+	require.EqualError(t, err, `file path "secrets/token" is not absolute`)
+}
+
+func TestFileDirs(t *testing.T) {
+	dirs := fileDirs([]File{
+		{Path: "/run/secrets/a"},
+		{Path: "/run/secrets/b"},
+		{Path: "/home/app/.pgpass"},
+	})
+	assert.Equal(t, []string{"/run/secrets", "/home/app"}, dirs)
+}
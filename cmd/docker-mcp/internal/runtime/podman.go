@@ -0,0 +1,44 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/client"
+)
+
+// NewPodman returns a Runtime that runs containers with Podman. It uses
+// Podman's Docker compatible API when its socket is found and falls back to
+// the `podman` CLI otherwise.
+func NewPodman() Runtime {
+	if host := podmanHost(); host != "" {
+		api, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+		if err == nil {
+			return NewEngine(api)
+		}
+	}
+
+	return &cliRuntime{binary: "podman"}
+}
+
+// podmanHost finds the socket of the Podman API service, either configured
+// with CONTAINER_HOST or at its default location, rootless first.
+func podmanHost() string {
+	if host := os.Getenv("CONTAINER_HOST"); strings.HasPrefix(host, "unix://") {
+		return host
+	}
+
+	var sockets []string
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		sockets = append(sockets, filepath.Join(dir, "podman", "podman.sock"))
+	}
+	sockets = append(sockets, "/run/podman/podman.sock")
+
+	for _, socket := range sockets {
+		if _, err := os.Stat(socket); err == nil {
+			return "unix://" + socket
+		}
+	}
+	return ""
+}
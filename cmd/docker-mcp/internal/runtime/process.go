@@ -0,0 +1,134 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/user"
+)
+
+// NewProcess returns a Runtime that runs local processes instead of
+// containers. Spec.Command is the command line and Spec.Dir its working
+// directory. Of the `docker run` flags, only the environment variables,
+// volumes and network are honored.
+//
+// With sandbox, the process runs under bubblewrap (`bwrap`). It sees the host
+// filesystem read-only, gets an empty /tmp and home directory, can write only
+// to its volumes and has no network unless it's attached to one.
+func NewProcess(sandbox bool) Runtime {
+	return &processRuntime{sandbox: sandbox}
+}
+
+type processRuntime struct {
+	sandbox bool
+}
+
+func (r *processRuntime) command(ctx context.Context, spec Spec) (*exec.Cmd, error) {
+	if len(spec.Command) == 0 {
+		return nil, fmt.Errorf("%s: no command to run", spec.Name)
+	}
+	if len(spec.Files) > 0 {
+		return nil, fmt.Errorf("%s: files can only be copied into containers", spec.Name)
+	}
+
+	config, hostConfig, _, err := ParseRunArgs(spec.Args, spec.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.sandbox {
+		if hostConfig.NetworkMode.IsNone() {
+			return nil, fmt.Errorf("%s: a local process can't be cut off the network without a sandbox", spec.Name)
+		}
+
+		cmd := exec.CommandContext(ctx, spec.Command[0], spec.Command[1:]...)
+		cmd.Env = append(processEnv(), config.Env...)
+		cmd.Dir = spec.Dir
+		return cmd, nil
+	}
+
+	args, err := bwrapArgs(spec.Dir, hostConfig)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", spec.Name, err)
+	}
+
+	cmd := exec.CommandContext(ctx, "bwrap", append(args, spec.Command...)...)
+	cmd.Env = append(processEnv(), config.Env...)
+	return cmd, nil
+}
+
+func (r *processRuntime) Run(ctx context.Context, spec Spec) ([]byte, error) {
+	cmd, err := r.command(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	return runCommand(cmd, spec)
+}
+
+func (r *processRuntime) Start(ctx context.Context, spec Spec) (Process, error) {
+	cmd, err := r.command(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	return startCommand(cmd, spec)
+}
+
+// bwrapArgs are the bubblewrap flags that sandbox a process.
+func bwrapArgs(dir string, hostConfig *container.HostConfig) ([]string, error) {
+	args := []string{
+		"--die-with-parent", "--new-session", "--unshare-all",
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	}
+	if home, err := user.HomeDir(); err == nil {
+		args = append(args, "--tmpfs", home)
+	}
+	if !hostConfig.NetworkMode.IsNone() {
+		args = append(args, "--share-net")
+	}
+
+	// The working directory is visible, read-only unless it's also a volume.
+	if dir != "" {
+		args = append(args, "--ro-bind", dir, dir)
+	}
+
+	for _, bind := range hostConfig.Binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) < 2 || !filepath.IsAbs(parts[0]) {
+			return nil, fmt.Errorf("invalid volume %q, only host paths can be mounted in a sandbox", bind)
+		}
+
+		flag := "--bind"
+		if len(parts) > 2 && strings.Contains(","+parts[2]+",", ",ro,") {
+			flag = "--ro-bind"
+		}
+		args = append(args, flag, parts[0], parts[1])
+	}
+
+	if dir != "" {
+		args = append(args, "--chdir", dir)
+	}
+
+	return append(args, "--"), nil
+}
+
+// processEnv is the part of the gateway's environment that a process
+// inherits, sandboxed or not. The rest, that can hold secrets or what secret
+// providers read, is never passed.
+func processEnv() []string {
+	var env []string
+	for _, name := range []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TERM"} {
+		if value, found := os.LookupEnv(name); found {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
@@ -0,0 +1,117 @@
+package runtime
+
+import (
+	"bufio"
+	"io"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessRun(t *testing.T) {
+	dir := t.TempDir()
+
+	out, err := NewProcess(false).Run(t.Context(), Spec{
+		Name:    "echo",
+		Args:    []string{"-e", "GREETING"},
+		Command: []string{"sh", "-c", "echo $GREETING from $(pwd)"},
+		Env:     []string{"GREETING=hello"},
+		Dir:     dir,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello from "+dir+"\n", string(out))
+}
+
+func TestProcessRunEnv(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "s3cr3t")
+	t.Setenv("LANG", "C.UTF-8")
+
+	out, err := NewProcess(false).Run(t.Context(), Spec{
+		Name:    "env",
+		Command: []string{"sh", "-c", "echo token=$VAULT_TOKEN lang=$LANG"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "token= lang=C.UTF-8\n", string(out))
+}
+
+func TestProcessRunExitCode(t *testing.T) {
+	_, err := NewProcess(false).Run(t.Context(), Spec{
+		Name:    "failing",
+		Command: []string{"sh", "-c", "echo missing API key >&2; exit 2"},
+	})
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, "failing exited with code 2: missing API key", exitErr.Error())
+}
+
+func TestProcessStart(t *testing.T) {
+	process, err := NewProcess(false).Start(t.Context(), Spec{
+		Name:    "cat",
+		Args:    []string{"--rm", "-i", "--init", "--memory", "2Gb", "-l", "docker-mcp=true"},
+		Command: []string{"cat"},
+	})
+	require.NoError(t, err)
+
+	_, err = io.WriteString(process, "hello\n")
+	require.NoError(t, err)
+
+	line, err := bufio.NewReader(process).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", line)
+
+	require.NoError(t, process.Close())
+	require.NoError(t, process.Wait(t.Context()))
+}
+
+func TestProcessNoNetworkNeedsSandbox(t *testing.T) {
+	_, err := NewProcess(false).Run(t.Context(), Spec{
+		Name:    "offline",
+		Args:    []string{"--network", "none"},
+		Command: []string{"true"},
+	})
+
+	require.ErrorContains(t, err, "without a sandbox")
+}
+
+func TestBwrapArgs(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+
+	args, err := bwrapArgs("/home/user/project", &container.HostConfig{
+		NetworkMode: "none",
+		Binds:       []string{"/data:/data", "/home/user/.config/app:/config:ro"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"--die-with-parent", "--new-session", "--unshare-all",
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+		"--tmpfs", "/home/user",
+		"--ro-bind", "/home/user/project", "/home/user/project",
+		"--bind", "/data", "/data",
+		"--ro-bind", "/home/user/.config/app", "/config",
+		"--chdir", "/home/user/project",
+		"--",
+	}, args)
+}
+
+func TestBwrapArgsNetwork(t *testing.T) {
+	args, err := bwrapArgs("", &container.HostConfig{})
+	require.NoError(t, err)
+
+	assert.Contains(t, args, "--share-net")
+	assert.NotContains(t, args, "--chdir")
+}
+
+func TestBwrapArgsNamedVolume(t *testing.T) {
+	_, err := bwrapArgs("", &container.HostConfig{Binds: []string{"cache:/cache"}})
+
+	require.Error(t, err)
+}
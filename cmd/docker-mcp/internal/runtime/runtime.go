@@ -0,0 +1,102 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stopTimeout is how long a container has to exit once its stdin is closed,
+// before it gets killed.
+const stopTimeout = 5 * time.Second
+
+// Runtime runs MCP servers and tools in containers.
+type Runtime interface {
+	// Start starts a container and attaches to its stdin and stdout.
+	Start(ctx context.Context, spec Spec) (Process, error)
+
+	// Run runs a container to completion and returns its stdout. A non zero
+	// exit code is reported as an *ExitError.
+	Run(ctx context.Context, spec Spec) ([]byte, error)
+}
+
+// Spec describes a container to run.
+type Spec struct {
+	// Name identifies the server, or tool, in error messages.
+	Name string
+
+	// Args are the `docker run` flags, without the image and command.
+	Args []string
+
+	Image   string
+	Command []string
+
+	// Env holds the values of the environment variables that Args pass by
+	// name, with `-e NAME`.
+	Env []string
+
+	// Files are copied into the container before it starts.
+	Files []File
+
+	// Dir is the working directory of a local process.
+	Dir string
+
+	// Stderr, if not nil, receives the stderr of the container.
+	Stderr io.Writer
+}
+
+// Process is a running container. Reads come from its stdout and writes go to
+// its stdin. Close closes stdin and removes the container.
+type Process interface {
+	io.ReadWriteCloser
+
+	// Wait waits for the container to exit. A non zero exit code is reported
+	// as an *ExitError.
+	Wait(ctx context.Context) error
+}
+
+// ExitError reports a container that exited with a non zero exit code.
+type ExitError struct {
+	Name     string
+	ExitCode int
+
+	// Stderr is the tail of what the container wrote to stderr.
+	Stderr string
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("%s exited with code %d", e.Name, e.ExitCode)
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+// stderrTail keeps the last bytes written to stderr, for error messages.
+type stderrTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+const maxStderrTail = 4096
+
+func (t *stderrTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > maxStderrTail {
+		t.buf = t.buf[len(t.buf)-maxStderrTail:]
+	}
+	return len(p), nil
+}
+
+func (t *stderrTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return string(t.buf)
+}
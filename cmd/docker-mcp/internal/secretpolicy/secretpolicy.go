@@ -0,0 +1,75 @@
+package secretpolicy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Rule lets a server receive a secret.
+type Rule struct {
+	Secret string
+	Server string
+}
+
+func (r Rule) String() string {
+	return r.Secret + " allows " + r.Server
+}
+
+// Policy is the list of secrets that each server can receive, with the same
+// format as Docker Desktop's: a `<secret> allows <server>` rule per line.
+// Whatever a server's catalog entry asks for, it only receives the secrets
+// that a rule allows. A nil policy allows everything.
+type Policy struct {
+	rules []Rule
+}
+
+// Parse reads the rules, one per line. Empty lines and lines that start with
+// `#` are ignored.
+func Parse(content string) (*Policy, error) {
+	policy := &Policy{}
+
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[1] != "allows" {
+			return nil, fmt.Errorf("line %d: expected `<secret> allows <server>`, got %q", i+1, line)
+		}
+
+		rule := Rule{Secret: fields[0], Server: fields[2]}
+		if !slices.Contains(policy.rules, rule) {
+			policy.rules = append(policy.rules, rule)
+		}
+	}
+
+	return policy, nil
+}
+
+// Allows tells whether a server can receive a secret.
+func (p *Policy) Allows(secret, server string) bool {
+	if p == nil {
+		return true
+	}
+	return slices.Contains(p.rules, Rule{Secret: secret, Server: server})
+}
+
+// Rules are the rules, in the order they were written.
+func (p *Policy) Rules() []Rule {
+	if p == nil {
+		return nil
+	}
+	return p.rules
+}
+
+// String formats the policy the way `docker mcp policy dump` prints it.
+func (p *Policy) String() string {
+	var lines []string
+	for _, rule := range p.Rules() {
+		lines = append(lines, rule.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,53 @@
+package secretpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	policy, err := Parse(`
+# Postgres
+postgres.password allows postgres
+  github.token   allows   github
+
+github.token allows github
+github.token allows github-enterprise
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Rule{
+		{Secret: "postgres.password", Server: "postgres"},
+		{Secret: "github.token", Server: "github"},
+		{Secret: "github.token", Server: "github-enterprise"},
+	}, policy.Rules())
+	assert.Equal(t, "postgres.password allows postgres\ngithub.token allows github\ngithub.token allows github-enterprise", policy.String())
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := Parse("github.token allows github\ngithub.token denies fetch")
+	require.EqualError(t, err, "line 2: expected `<secret> allows <server>`, got \"github.token denies fetch\"")
+
+	_, err = Parse("github.token allows")
+	require.ErrorContains(t, err, "line 1: expected")
+}
+
+func TestAllows(t *testing.T) {
+	policy, err := Parse("github.token allows github")
+	require.NoError(t, err)
+
+	assert.True(t, policy.Allows("github.token", "github"))
+	assert.False(t, policy.Allows("github.token", "fetch"))
+	assert.False(t, policy.Allows("postgres.password", "github"))
+
+	empty, err := Parse("")
+	require.NoError(t, err)
+	assert.False(t, empty.Allows("github.token", "github"))
+	assert.Empty(t, empty.String())
+
+	var none *Policy
+	assert.True(t, none.Allows("github.token", "github"))
+	assert.Empty(t, none.String())
+}
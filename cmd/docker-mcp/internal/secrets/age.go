@@ -0,0 +1,188 @@
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/config"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/secret-management/secret"
+)
+
+const (
+	// AgeKeyEnv holds age identities, as written by age-keygen.
+	AgeKeyEnv = "DOCKER_MCP_AGE_KEY"
+
+	// AgeKeyFileEnv is the path of a file with age identities.
+	AgeKeyFileEnv = "DOCKER_MCP_AGE_KEY_FILE"
+
+	// AgeKeySecret is the name of the credstore secret with age identities.
+	AgeKeySecret = "age.identity"
+
+	// ageKeyFile is the default file with age identities, in ~/.docker/mcp/.
+	ageKeyFile = "age.key"
+)
+
+// IsEncrypted tells whether a secrets file is encrypted with age, armored or
+// not.
+func IsEncrypted(buf []byte) bool {
+	return bytes.HasPrefix(buf, []byte("age-encryption.org/")) || bytes.HasPrefix(bytes.TrimSpace(buf), []byte(armor.Header))
+}
+
+// Decrypt decrypts an age encrypted secrets file, in memory.
+func Decrypt(buf []byte, identities []age.Identity) ([]byte, error) {
+	var in io.Reader = bytes.NewReader(buf)
+	if bytes.HasPrefix(bytes.TrimSpace(buf), []byte(armor.Header)) {
+		in = armor.NewReader(bytes.NewReader(bytes.TrimSpace(buf)))
+	}
+
+	r, err := age.Decrypt(in, identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// Encrypt encrypts a secrets file for a list of recipients. The output is
+// armored, so that it can be committed and reviewed as text.
+func Encrypt(plaintext []byte, recipients []age.Recipient) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+
+	var buf bytes.Buffer
+	armored := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armored, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := armored.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// RecipientsFile is the file next to an encrypted secrets file that lists the
+// recipients it's encrypted for, one per line, so that any of them can edit it.
+func RecipientsFile(path string) string {
+	return path + ".recipients"
+}
+
+// CountStanzas counts the recipient stanzas in the header of an age encrypted
+// file, that is one per recipient it's encrypted for.
+func CountStanzas(buf []byte) (int, error) {
+	var in io.Reader = bytes.NewReader(buf)
+	if bytes.HasPrefix(bytes.TrimSpace(buf), []byte(armor.Header)) {
+		in = armor.NewReader(bytes.NewReader(bytes.TrimSpace(buf)))
+	}
+
+	r := bufio.NewReader(in)
+	version, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(version, "age-encryption.org/") {
+		return 0, errors.New("invalid age header")
+	}
+
+	stanzas := 0
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return 0, errors.New("invalid age header")
+		}
+		switch {
+		case strings.HasPrefix(line, "-> "):
+			stanzas++
+		case strings.HasPrefix(line, "--- "):
+			return stanzas, nil
+		}
+	}
+}
+
+// AgeIdentities finds the age identities to decrypt secrets files with, in
+// order: $DOCKER_MCP_AGE_KEY, the file at $DOCKER_MCP_AGE_KEY_FILE, the
+// credstore and ~/.docker/mcp/age.key.
+func AgeIdentities() ([]age.Identity, error) {
+	if key := os.Getenv(AgeKeyEnv); key != "" {
+		return parseIdentities(AgeKeyEnv, key)
+	}
+
+	if path := os.Getenv(AgeKeyFileEnv); path != "" {
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading age key: %w", err)
+		}
+		return parseIdentities(path, string(buf))
+	}
+
+	// The credential helper might not be installed.
+	if key, err := secret.NewCredStoreProvider().GetSecret(AgeKeySecret); err == nil && key != "" {
+		return parseIdentities("credstore", key)
+	}
+
+	path, err := config.FilePath(ageKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no age key found, set %s, %s, the %s credstore secret, or create %s", AgeKeyEnv, AgeKeyFileEnv, AgeKeySecret, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading age key: %w", err)
+	}
+	return parseIdentities(path, string(buf))
+}
+
+func parseIdentities(source, keys string) ([]age.Identity, error) {
+	identities, err := age.ParseIdentities(strings.NewReader(keys))
+	if err != nil {
+		return nil, fmt.Errorf("parsing age key from %s: %w", source, err)
+	}
+	return identities, nil
+}
+
+// ParseRecipients parses age recipients, age1..., one per line or
+// comma separated. Comments and empty lines are ignored.
+func ParseRecipients(list string) ([]age.Recipient, error) {
+	var recipients []age.Recipient
+
+	scanner := bufio.NewScanner(strings.NewReader(strings.ReplaceAll(list, ",", "\n")))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		recipient, err := age.ParseX25519Recipient(line)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, scanner.Err()
+}
+
+// IdentityRecipients are the recipients of a list of identities, to
+// re-encrypt a file for its owner.
+func IdentityRecipients(identities []age.Identity) []age.Recipient {
+	var recipients []age.Recipient
+	for _, identity := range identities {
+		if x25519, ok := identity.(*age.X25519Identity); ok {
+			recipients = append(recipients, x25519.Recipient())
+		}
+	}
+	return recipients
+}
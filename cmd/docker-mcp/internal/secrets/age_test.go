@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	ciphertext, err := Encrypt([]byte("github.token=s3cr3t\n"), []age.Recipient{identity.Recipient()})
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(ciphertext))
+	assert.NotContains(t, string(ciphertext), "s3cr3t")
+
+	plaintext, err := Decrypt(ciphertext, []age.Identity{identity})
+	require.NoError(t, err)
+	assert.Equal(t, "github.token=s3cr3t\n", string(plaintext))
+
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	_, err = Decrypt(ciphertext, []age.Identity{other})
+	require.Error(t, err)
+}
+
+func TestCountStanzas(t *testing.T) {
+	var recipients []age.Recipient
+	for range 3 {
+		identity, err := age.GenerateX25519Identity()
+		require.NoError(t, err)
+		recipients = append(recipients, identity.Recipient())
+	}
+
+	ciphertext, err := Encrypt([]byte("github.token=s3cr3t\n"), recipients)
+	require.NoError(t, err)
+	stanzas, err := CountStanzas(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stanzas)
+
+	_, err = CountStanzas([]byte("github.token=s3cr3t\n"))
+	require.EqualError(t, err, "invalid age header")
+}
+
+func TestEncryptedFileProvider(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	t.Setenv(AgeKeyEnv, identity.String())
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"secrets.env.age":  "github.token=s3cr3t\n",
+		"secrets.yaml.age": "github:\n  token: s3cr3t\n",
+	} {
+		ciphertext, err := Encrypt([]byte(content), []age.Recipient{identity.Recipient()})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), ciphertext, 0o600))
+
+		secrets, err := NewFileProvider(filepath.Join(dir, name)).GetSecrets(t.Context(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"github.token": "s3cr3t"}, secrets, name)
+	}
+}
+
+func TestAgeIdentitiesFromFile(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# created: today\n"+identity.String()+"\n"), 0o600))
+	t.Setenv(AgeKeyEnv, "")
+	t.Setenv(AgeKeyFileEnv, path)
+
+	identities, err := AgeIdentities()
+	require.NoError(t, err)
+	assert.Equal(t, []age.Recipient{identity.Recipient()}, IdentityRecipients(identities))
+}
+
+func TestParseRecipients(t *testing.T) {
+	first, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	second, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	recipients, err := ParseRecipients("# team\n" + first.Recipient().String() + "," + second.Recipient().String() + "\n\n")
+	require.NoError(t, err)
+	assert.Len(t, recipients, 2)
+
+	_, err = ParseRecipients("not-a-recipient")
+	require.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/secret-management/secret"
+)
+
+// dockerDesktopProvider reads the secrets of Docker Desktop's secrets API.
+type dockerDesktopProvider struct {
+	docker docker.Client
+}
+
+func NewDockerDesktopProvider(dockerClient docker.Client) SecretProvider {
+	return &dockerDesktopProvider{docker: dockerClient}
+}
+
+func (p *dockerDesktopProvider) GetSecrets(ctx context.Context, names []string) (map[string]string, error) {
+	if len(names) == 0 {
+		return map[string]string{}, nil
+	}
+
+	secrets, err := p.docker.ReadSecrets(ctx, names, true)
+	if err != nil {
+		return nil, fmt.Errorf("finding secrets %s: %w", names, err)
+	}
+	return secrets, nil
+}
+
+func (p *dockerDesktopProvider) String() string {
+	return "docker-desktop"
+}
+
+// credStoreProvider reads the secrets stored in the credential helper by
+// `docker mcp secret set --provider=credstore`.
+type credStoreProvider struct {
+	store *secret.CredStoreProvider
+}
+
+func NewCredStoreProvider() SecretProvider {
+	return &credStoreProvider{store: secret.NewCredStoreProvider()}
+}
+
+func (p *credStoreProvider) GetSecrets(ctx context.Context, names []string) (map[string]string, error) {
+	secrets := map[string]string{}
+
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		value, err := p.store.GetSecret(name)
+		if credentials.IsErrCredentialsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading secret %s: %w", name, err)
+		}
+		secrets[name] = value
+	}
+
+	return secrets, nil
+}
+
+func (p *credStoreProvider) String() string {
+	return "credstore"
+}
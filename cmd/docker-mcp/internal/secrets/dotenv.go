@@ -0,0 +1,321 @@
+package secrets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseDotenv parses a .env file:
+//
+//   - KEY=value, with an optional `export ` prefix. Keys are made of letters,
+//     digits, `_`, `.` and `-`.
+//   - Unquoted values are trimmed and end at the end of the line, or at a `#`
+//     preceded by a space, that starts a comment.
+//   - Single quoted values are literal and can span multiple lines.
+//   - Double quoted values can span multiple lines and support the \n, \r,
+//     \t, \\, \" and \$ escapes.
+//   - ${VAR}, ${VAR:-default} and $VAR, in unquoted and double quoted values,
+//     are replaced by the value of a previous key, or by an empty string.
+//
+// Errors mention the line where the faulty key starts.
+func ParseDotenv(content string) (map[string]string, error) {
+	p, err := parseDotenv(content)
+	if err != nil {
+		return nil, err
+	}
+	return p.values, nil
+}
+
+func parseDotenv(content string) (*dotenvParser, error) {
+	p := &dotenvParser{
+		src:    strings.ReplaceAll(content, "\r\n", "\n"),
+		line:   1,
+		values: map[string]string{},
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+type dotenvParser struct {
+	src    string
+	pos    int
+	line   int
+	values map[string]string
+
+	// entries are where each KEY=value is in src, comments excluded.
+	entries []dotenvEntry
+}
+
+type dotenvEntry struct {
+	key        string
+	start, end int
+}
+
+func (p *dotenvParser) parse() error {
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		line, start := p.line, p.pos
+		key := p.readKey()
+		if key == "export" && (p.peek() == ' ' || p.peek() == '\t') {
+			p.skipSpaces()
+			key = p.readKey()
+		}
+		if key == "" {
+			return fmt.Errorf("line %d: invalid key %q", line, p.restOfLine())
+		}
+
+		p.skipSpaces()
+		if p.eof() || p.peek() != '=' {
+			return fmt.Errorf("line %d: expected = after %s", line, key)
+		}
+		p.pos++
+		p.skipSpaces()
+
+		var (
+			value string
+			err   error
+		)
+		switch p.peek() {
+		case '\'':
+			value, err = p.readSingleQuoted()
+		case '"':
+			value, err = p.readDoubleQuoted()
+		default:
+			value, err = p.readUnquoted()
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %s: %w", line, key, err)
+		}
+
+		p.values[key] = value
+		p.entries = append(p.entries, dotenvEntry{key: key, start: start, end: p.pos})
+	}
+}
+
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotenvParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// skipBlank skips spaces and empty lines.
+func (p *dotenvParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case '\n':
+			p.line++
+		case ' ', '\t':
+		default:
+			return
+		}
+		p.pos++
+	}
+}
+
+func (p *dotenvParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipLine skips to the start of the next line.
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+	if !p.eof() {
+		p.pos++
+		p.line++
+	}
+}
+
+func (p *dotenvParser) restOfLine() string {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end < 0 {
+		return p.src[p.pos:]
+	}
+	return p.src[p.pos : p.pos+end]
+}
+
+func (p *dotenvParser) readKey() string {
+	start := p.pos
+	for !p.eof() && isKeyChar(p.peek()) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func isKeyChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '.' || c == '-'
+}
+
+func (p *dotenvParser) readUnquoted() (string, error) {
+	start := p.pos
+	for !p.eof() && p.peek() != '\n' {
+		if p.peek() == '#' && (p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+			break
+		}
+		p.pos++
+	}
+	raw := strings.TrimSpace(p.src[start:p.pos])
+	p.skipLine()
+
+	return p.expand(raw)
+}
+
+func (p *dotenvParser) readSingleQuoted() (string, error) {
+	p.pos++
+	end := strings.IndexByte(p.src[p.pos:], '\'')
+	if end < 0 {
+		return "", fmt.Errorf("unterminated single quoted value")
+	}
+
+	value := p.src[p.pos : p.pos+end]
+	p.line += strings.Count(value, "\n")
+	p.pos += end + 1
+
+	return value, p.endOfValue()
+}
+
+func (p *dotenvParser) readDoubleQuoted() (string, error) {
+	p.pos++
+
+	// Escaped dollars are protected from the expansion.
+	var raw strings.Builder
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("unterminated double quoted value")
+		}
+
+		c := p.peek()
+		p.pos++
+		switch c {
+		case '"':
+			value, err := p.expand(raw.String())
+			if err != nil {
+				return "", err
+			}
+			return strings.ReplaceAll(value, escapedDollar, "$"), p.endOfValue()
+		case '\n':
+			p.line++
+			raw.WriteByte(c)
+		case '\\':
+			if p.eof() {
+				return "", fmt.Errorf("unterminated double quoted value")
+			}
+			escaped := p.peek()
+			p.pos++
+			switch escaped {
+			case 'n':
+				raw.WriteByte('\n')
+			case 'r':
+				raw.WriteByte('\r')
+			case 't':
+				raw.WriteByte('\t')
+			case '\\', '"':
+				raw.WriteByte(escaped)
+			case '$':
+				raw.WriteString(escapedDollar)
+			case '\n':
+				// A backslash at the end of a line continues the value.
+				p.line++
+			default:
+				raw.WriteByte('\\')
+				raw.WriteByte(escaped)
+			}
+		default:
+			raw.WriteByte(c)
+		}
+	}
+}
+
+// escapedDollar stands for \$ until the value is expanded.
+const escapedDollar = "\x00$"
+
+// endOfValue checks that nothing but a comment follows a quoted value.
+func (p *dotenvParser) endOfValue() error {
+	p.skipSpaces()
+	switch {
+	case p.eof():
+		return nil
+	case p.peek() == '\n' || p.peek() == '#':
+		p.skipLine()
+		return nil
+	default:
+		return fmt.Errorf("unexpected %q after the closing quote", p.restOfLine())
+	}
+}
+
+// expand replaces ${VAR}, ${VAR:-default} and $VAR with the values of the
+// previous keys.
+func (p *dotenvParser) expand(value string) (string, error) {
+	var sb strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '$' || (i > 0 && value[i-1] == 0) || i+1 == len(value) {
+			sb.WriteByte(c)
+			continue
+		}
+
+		if value[i+1] == '{' {
+			end := strings.IndexByte(value[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ in %q", value)
+			}
+			name, fallback, hasFallback := strings.Cut(value[i+2:i+end], ":-")
+			if name == "" || strings.IndexFunc(name, func(r rune) bool { return r > 127 || !isKeyChar(byte(r)) }) >= 0 {
+				return "", fmt.Errorf("invalid variable ${%s}", value[i+2:i+end])
+			}
+			if v, found := p.values[name]; found && (v != "" || !hasFallback) {
+				sb.WriteString(v)
+			} else {
+				sb.WriteString(fallback)
+			}
+			i += end
+			continue
+		}
+
+		j := i + 1
+		for j < len(value) && (isKeyChar(value[j]) && value[j] != '.' && value[j] != '-') {
+			j++
+		}
+		if j == i+1 {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteString(p.values[value[i+1:j]])
+		i = j - 1
+	}
+
+	return sb.String(), nil
+}
+
+// QuoteDotenv quotes a value, if needed, so that ParseDotenv reads it back
+// as is.
+func QuoteDotenv(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\r#'\"$\\") {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\r") {
+		return "'" + value + "'"
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`)
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EditSecretsFile sets and removes secrets in the content of a secrets file,
+// keeping the rest of the file as is.
+func EditSecretsFile(name string, buf []byte, set map[string]string, unset []string) ([]byte, error) {
+	switch filepath.Ext(strings.TrimSuffix(name, ".age")) {
+	case ".yaml", ".yml":
+		return editYAMLSecrets(buf, set, unset)
+	default:
+		return editEnvSecrets(buf, set, unset)
+	}
+}
+
+func editEnvSecrets(buf []byte, set map[string]string, unset []string) ([]byte, error) {
+	p, err := parseDotenv(string(buf))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets file: %w", err)
+	}
+
+	var out strings.Builder
+	done := map[string]bool{}
+	last := 0
+	for _, entry := range p.entries {
+		if !slices.Contains(unset, entry.key) && !hasKey(set, entry.key) {
+			continue
+		}
+
+		out.WriteString(p.src[last:entry.start])
+		last = entry.end
+		if hasKey(set, entry.key) && !done[entry.key] {
+			out.WriteString(entry.key + "=" + QuoteDotenv(set[entry.key]) + "\n")
+			done[entry.key] = true
+		}
+	}
+	out.WriteString(p.src[last:])
+
+	if out.Len() > 0 && !strings.HasSuffix(out.String(), "\n") {
+		out.WriteString("\n")
+	}
+	for _, key := range slices.Sorted(maps.Keys(set)) {
+		if !done[key] {
+			out.WriteString(key + "=" + QuoteDotenv(set[key]) + "\n")
+		}
+	}
+
+	return []byte(out.String()), nil
+}
+
+func editYAMLSecrets(buf []byte, set map[string]string, unset []string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		return nil, fmt.Errorf("invalid secrets file: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid secrets file: expected a map")
+	}
+
+	for _, key := range unset {
+		if mapping, i := findYAMLSecret(root, key); mapping != nil {
+			mapping.Content = slices.Delete(mapping.Content, i, i+2)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(set)) {
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: set[key]}
+		if mapping, i := findYAMLSecret(root, key); mapping != nil {
+			// Keep the comments of the previous value.
+			previous := mapping.Content[i+1]
+			value.HeadComment, value.LineComment, value.FootComment = previous.HeadComment, previous.LineComment, previous.FootComment
+			mapping.Content[i+1] = value
+			continue
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// findYAMLSecret finds the mapping, and the index of the key in it, of a
+// secret that's either a top level key, like github.token, or nested, like
+// github: {token: ...}.
+func findYAMLSecret(mapping *yaml.Node, key string) (*yaml.Node, int) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		name, value := mapping.Content[i].Value, mapping.Content[i+1]
+		if name == key {
+			return mapping, i
+		}
+		if rest, found := strings.CutPrefix(key, name+"."); found && value.Kind == yaml.MappingNode {
+			if nested, j := findYAMLSecret(value, rest); nested != nil {
+				return nested, j
+			}
+		}
+	}
+	return nil, 0
+}
+
+func hasKey(m map[string]string, key string) bool {
+	_, found := m[key]
+	return found
+}
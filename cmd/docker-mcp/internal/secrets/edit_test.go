@@ -0,0 +1,47 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditEnvSecrets(t *testing.T) {
+	content := "# GitHub\ngithub.token=old\nslack.token=gone\n"
+
+	edited, err := EditSecretsFile(".env.age", []byte(content), map[string]string{"github.token": "new", "notion.token": "added"}, []string{"slack.token"})
+	require.NoError(t, err)
+	assert.Equal(t, "# GitHub\ngithub.token=new\nnotion.token=added\n", string(edited))
+}
+
+func TestEditYAMLSecrets(t *testing.T) {
+	content := "# GitHub\ngithub:\n  token: old # rotated monthly\nslack.token: gone\n"
+
+	edited, err := EditSecretsFile("secrets.yaml.age", []byte(content), map[string]string{"github.token": "new", "notion.token": "added"}, []string{"slack.token"})
+	require.NoError(t, err)
+
+	secrets, err := ParseSecretsFile("secrets.yaml", edited)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"github.token": "new", "notion.token": "added"}, secrets)
+	assert.Contains(t, string(edited), "# GitHub")
+	assert.Contains(t, string(edited), "# rotated monthly")
+}
+
+func TestEditEmptyYAMLSecrets(t *testing.T) {
+	edited, err := EditSecretsFile("secrets.yml", nil, map[string]string{"github.token": "new"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "github.token: new\n", string(edited))
+}
+
+func TestEditEnvSecretsMultiLine(t *testing.T) {
+	content := "KEY='" + testPEM + "' # deploy key\nOTHER=value\n"
+
+	edited, err := EditSecretsFile(".env", []byte(content), map[string]string{"OTHER": "it's $new"}, []string{"KEY"})
+	require.NoError(t, err)
+	assert.Equal(t, "OTHER=\"it's \\$new\"\n", string(edited))
+
+	edited, err = EditSecretsFile(".env", []byte(content), map[string]string{"KEY": "short"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "KEY=short\nOTHER=value\n", string(edited))
+}
@@ -0,0 +1,47 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// envProvider reads secrets from environment variables named after them,
+// with a prefix: github.personal_access_token is read from
+// <prefix>GITHUB_PERSONAL_ACCESS_TOKEN.
+type envProvider struct {
+	prefix string
+}
+
+func NewEnvProvider(prefix string) SecretProvider {
+	return &envProvider{prefix: prefix}
+}
+
+func (p *envProvider) GetSecrets(_ context.Context, names []string) (map[string]string, error) {
+	secrets := map[string]string{}
+	for _, name := range names {
+		if value, found := os.LookupEnv(p.prefix + EnvName(name)); found {
+			secrets[name] = value
+		}
+	}
+	return secrets, nil
+}
+
+func (p *envProvider) String() string {
+	return "env:" + p.prefix
+}
+
+// EnvName turns a secret name into an environment variable name, by upper
+// casing it and replacing anything that's not a letter or a digit with an
+// underscore.
+func EnvName(secretName string) string {
+	var sb strings.Builder
+	for _, c := range strings.ToUpper(secretName) {
+		if (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	return sb.String()
+}
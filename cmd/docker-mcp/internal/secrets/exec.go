@@ -0,0 +1,50 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker-credential-helpers/client"
+	"github.com/docker/docker-credential-helpers/credentials"
+)
+
+// execProvider reads secrets from an external program that speaks the
+// protocol of docker credential helpers: `<program> get` reads a secret
+// name on stdin and writes {"ServerURL":..,"Username":..,"Secret":..} on
+// stdout.
+type execProvider struct {
+	program string
+	run     client.ProgramFunc
+}
+
+func NewExecProvider(program string) SecretProvider {
+	return &execProvider{
+		program: program,
+		run:     client.NewShellProgramFunc(program),
+	}
+}
+
+func (p *execProvider) GetSecrets(ctx context.Context, names []string) (map[string]string, error) {
+	secrets := map[string]string{}
+
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		creds, err := client.Get(p.run, name)
+		if credentials.IsErrCredentialsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading secret %s: %w", name, err)
+		}
+		secrets[name] = creds.Secret
+	}
+
+	return secrets, nil
+}
+
+func (p *execProvider) String() string {
+	return "exec:" + p.program
+}
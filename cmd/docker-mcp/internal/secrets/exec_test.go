@@ -0,0 +1,50 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain turns the test binary into a credential helper that knows a
+// single secret, when SECRETS_TEST_HELPER is set.
+func TestMain(m *testing.M) {
+	if os.Getenv("SECRETS_TEST_HELPER") == "" {
+		os.Exit(m.Run())
+	}
+
+	if len(os.Args) != 2 || os.Args[1] != "get" {
+		fmt.Println("unsupported action")
+		os.Exit(1)
+	}
+	name, _ := io.ReadAll(os.Stdin)
+	switch strings.TrimSpace(string(name)) {
+	case "github.token":
+		_ = json.NewEncoder(os.Stdout).Encode(map[string]string{"ServerURL": "github.token", "Username": "mcp", "Secret": "s3cr3t"})
+	case "broken":
+		fmt.Println("keychain is locked")
+		os.Exit(1)
+	default:
+		fmt.Println("credentials not found in native keychain")
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestExecProvider(t *testing.T) {
+	t.Setenv("SECRETS_TEST_HELPER", "1")
+	provider := NewExecProvider(os.Args[0])
+
+	secrets, err := provider.GetSecrets(t.Context(), []string{"github.token", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"github.token": "s3cr3t"}, secrets)
+
+	_, err = provider.GetSecrets(t.Context(), []string{"broken"})
+	require.ErrorContains(t, err, "keychain is locked")
+}
@@ -0,0 +1,143 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileProvider reads every secret of a .env or YAML file, optionally
+// encrypted with age, or of a directory with a file per secret. A missing
+// file has no secrets.
+type fileProvider struct {
+	path string
+}
+
+func NewFileProvider(path string) SecretProvider {
+	return &fileProvider{path: path}
+}
+
+func (p *fileProvider) GetSecrets(_ context.Context, _ []string) (map[string]string, error) {
+	info, err := os.Stat(p.path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err == nil && info.IsDir() {
+		return readSecretsFromDir(p.path)
+	}
+
+	buf, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("reading secrets from %s: %w", p.path, err)
+	}
+
+	// Encrypted files are only ever decrypted in memory.
+	if IsEncrypted(buf) {
+		identities, err := AgeIdentities()
+		if err != nil {
+			return nil, err
+		}
+		if buf, err = Decrypt(buf, identities); err != nil {
+			return nil, fmt.Errorf("decrypting %s: %w", p.path, err)
+		}
+	}
+
+	return ParseSecretsFile(p.path, buf)
+}
+
+func (p *fileProvider) String() string {
+	return p.path
+}
+
+func (p *fileProvider) Path() string {
+	return p.path
+}
+
+// ParseSecretsFile parses the content of a secrets file, as YAML if its
+// name ends with .yaml or .yml, before an optional .age, or as a .env file.
+func ParseSecretsFile(name string, buf []byte) (map[string]string, error) {
+	switch filepath.Ext(strings.TrimSuffix(name, ".age")) {
+	case ".yaml", ".yml":
+		return parseYAMLSecrets(buf)
+	default:
+		return parseEnvSecrets(buf)
+	}
+}
+
+// parseYAMLSecrets reads a map of secrets. Nested maps are flattened, so
+// that github: {token: x} is the github.token secret.
+func parseYAMLSecrets(buf []byte) (map[string]string, error) {
+	var content map[string]any
+	if err := yaml.Unmarshal(buf, &content); err != nil {
+		return nil, fmt.Errorf("invalid secrets file: %w", err)
+	}
+
+	secrets := map[string]string{}
+	var flatten func(prefix string, m map[string]any) error
+	flatten = func(prefix string, m map[string]any) error {
+		for key, value := range m {
+			switch value := value.(type) {
+			case map[string]any:
+				if err := flatten(prefix+key+".", value); err != nil {
+					return err
+				}
+			case string:
+				secrets[prefix+key] = value
+			case nil:
+				secrets[prefix+key] = ""
+			case []any:
+				return fmt.Errorf("invalid secrets file: %s%s is a list", prefix, key)
+			default:
+				secrets[prefix+key] = fmt.Sprint(value)
+			}
+		}
+		return nil
+	}
+	if err := flatten("", content); err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+func parseEnvSecrets(buf []byte) (map[string]string, error) {
+	secrets, err := ParseDotenv(string(buf))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets file: %w", err)
+	}
+	return secrets, nil
+}
+
+// readSecretsFromDir reads a file per secret, named after the secret, as
+// mounted from a Kubernetes Secret. Hidden files are ignored.
+func readSecretsFromDir(path string) (map[string]string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading secrets from %s: %w", path, err)
+	}
+
+	secrets := map[string]string{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		// Kubernetes mounts secrets as symlinks.
+		info, err := os.Stat(filepath.Join(path, entry.Name()))
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		buf, err := os.ReadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading secret %s: %w", entry.Name(), err)
+		}
+		secrets[entry.Name()] = strings.TrimSuffix(string(buf), "\n")
+	}
+
+	return secrets, nil
+}
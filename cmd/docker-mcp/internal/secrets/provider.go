@@ -0,0 +1,151 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/docker"
+)
+
+// SecretProvider reads the values of secrets, such as
+// github.personal_access_token.
+type SecretProvider interface {
+	// GetSecrets returns the values of the secrets it has, among names.
+	// Missing secrets are not an error. Providers that can list their secrets
+	// may return more than what's asked.
+	GetSecrets(ctx context.Context, names []string) (map[string]string, error)
+
+	// String describes the provider, for logs.
+	String() string
+}
+
+// PathProvider is implemented by the providers that read their secrets from
+// a file, or a directory, that can be watched for changes.
+type PathProvider interface {
+	Path() string
+}
+
+// Chain is an ordered list of providers. Each secret is read from the first
+// provider that has it.
+type Chain []SecretProvider
+
+// GetSecrets asks every provider, in order, for the secrets that are still
+// missing. A provider that fails doesn't stop the chain: its error is
+// returned along with the secrets of the others.
+func (c Chain) GetSecrets(ctx context.Context, names []string) (map[string]string, error) {
+	secrets := map[string]string{}
+	var errs []error
+
+	for _, provider := range c {
+		var missing []string
+		for _, name := range names {
+			if _, found := secrets[name]; !found {
+				missing = append(missing, name)
+			}
+		}
+		if len(names) > 0 && len(missing) == 0 {
+			break
+		}
+
+		values, err := provider.GetSecrets(ctx, missing)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", provider, err))
+			continue
+		}
+		for name, value := range values {
+			if _, found := secrets[name]; !found {
+				secrets[name] = value
+			}
+		}
+	}
+
+	return secrets, errors.Join(errs...)
+}
+
+// Paths are the files and directories that the providers of the chain read
+// from. Other providers can only be polled for changes.
+func (c Chain) Paths() []string {
+	var paths []string
+	for _, provider := range c {
+		if pathProvider, ok := provider.(PathProvider); ok {
+			paths = append(paths, pathProvider.Path())
+		}
+	}
+	return paths
+}
+
+// Parse parses the value of `--secrets`: a comma separated chain of
+// providers.
+//
+//   - docker-desktop: Docker Desktop's secrets API.
+//   - credstore: the secrets set with `docker mcp secret set --provider=credstore`.
+//   - exec:<program>: a docker credential helper, or anything that speaks its protocol.
+//   - vault:<mount>/<path>: a Vault KV v2 secret, see VAULT_ADDR and VAULT_TOKEN.
+//   - env:<PREFIX>: environment variables, github.token is read from <PREFIX>GITHUB_TOKEN.
+//   - file:<path>, or a path: a .env or YAML file, optionally encrypted with
+//     age, or a directory with a file per secret.
+//
+// For compatibility, an entry that's not a provider is a colon separated list
+// of docker-desktop and paths.
+func Parse(spec string, dockerClient docker.Client) (Chain, error) {
+	var chain Chain
+
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kind, value, _ := strings.Cut(entry, ":")
+		switch kind {
+		case "credstore":
+			if value != "" {
+				return nil, fmt.Errorf("invalid secret provider %q: credstore takes no argument", entry)
+			}
+			chain = append(chain, NewCredStoreProvider())
+		case "exec", "vault", "env", "file":
+			provider, err := parseProvider(kind, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid secret provider %q: %w", entry, err)
+			}
+			chain = append(chain, provider)
+		default:
+			for path := range strings.SplitSeq(entry, ":") {
+				switch path {
+				case "":
+				case "docker-desktop":
+					chain = append(chain, NewDockerDesktopProvider(dockerClient))
+				default:
+					chain = append(chain, NewFileProvider(path))
+				}
+			}
+		}
+	}
+
+	return chain, nil
+}
+
+func parseProvider(kind, value string) (SecretProvider, error) {
+	switch kind {
+	case "exec":
+		if value == "" {
+			return nil, errors.New("missing program")
+		}
+		return NewExecProvider(value), nil
+	case "vault":
+		mount, path, found := strings.Cut(strings.Trim(value, "/"), "/")
+		if !found || mount == "" || path == "" {
+			return nil, errors.New("expected vault:<mount>/<path>")
+		}
+		return NewVaultProviderFromEnv(mount, path), nil
+	case "env":
+		return NewEnvProvider(value), nil
+	default:
+		if value == "" {
+			return nil, errors.New("missing path")
+		}
+		return NewFileProvider(value), nil
+	}
+}
@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticProvider struct {
+	secrets map[string]string
+	err     error
+	asked   [][]string
+}
+
+func (p *staticProvider) GetSecrets(_ context.Context, names []string) (map[string]string, error) {
+	p.asked = append(p.asked, names)
+	return p.secrets, p.err
+}
+
+func (p *staticProvider) String() string {
+	return "static"
+}
+
+func TestChain(t *testing.T) {
+	first := &staticProvider{secrets: map[string]string{"a": "first"}}
+	failing := &staticProvider{err: errors.New("unavailable")}
+	last := &staticProvider{secrets: map[string]string{"a": "last", "b": "last"}}
+
+	secrets, err := Chain{first, failing, last}.GetSecrets(t.Context(), []string{"a", "b"})
+	require.EqualError(t, err, "static: unavailable")
+	assert.Equal(t, map[string]string{"a": "first", "b": "last"}, secrets)
+	assert.Equal(t, [][]string{{"a", "b"}}, first.asked)
+	assert.Equal(t, [][]string{{"b"}}, last.asked)
+}
+
+func TestChainStopsOnceComplete(t *testing.T) {
+	first := &staticProvider{secrets: map[string]string{"a": "first"}}
+	last := &staticProvider{}
+
+	secrets, err := Chain{first, last}.GetSecrets(t.Context(), []string{"a"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "first"}, secrets)
+	assert.Empty(t, last.asked)
+}
+
+func TestParse(t *testing.T) {
+	chain, err := Parse("exec:/usr/bin/docker-credential-pass, credstore,vault:secret/mcp/prod,env:MCP_,file:/run/secrets/mcp", nil)
+	require.NoError(t, err)
+
+	var names []string
+	for _, provider := range chain {
+		names = append(names, provider.String())
+	}
+	assert.Equal(t, []string{"exec:/usr/bin/docker-credential-pass", "credstore", "vault:secret/mcp/prod", "env:MCP_", "/run/secrets/mcp"}, names)
+}
+
+func TestChainPaths(t *testing.T) {
+	chain, err := Parse("env:MCP_,file:/run/secrets/mcp,docker-desktop:.env", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/run/secrets/mcp", ".env"}, chain.Paths())
+}
+
+func TestParseLegacyPaths(t *testing.T) {
+	chain, err := Parse("docker-desktop:/run/secrets/mcp_secret:/.env", nil)
+	require.NoError(t, err)
+
+	var names []string
+	for _, provider := range chain {
+		names = append(names, provider.String())
+	}
+	assert.Equal(t, []string{"docker-desktop", "/run/secrets/mcp_secret", "/.env"}, names)
+
+	chain, err = Parse("", nil)
+	require.NoError(t, err)
+	assert.Empty(t, chain)
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"exec:", "vault:secret", "file:", "credstore:x"} {
+		_, err := Parse(spec, nil)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("MCP_GITHUB_PERSONAL_ACCESS_TOKEN", "s3cr3t")
+
+	secrets, err := NewEnvProvider("MCP_").GetSecrets(t.Context(), []string{"github.personal_access_token", "other"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"github.personal_access_token": "s3cr3t"}, secrets)
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(envFile, []byte("# comment\ngithub.token=s3cr3t\n\n"), 0o600))
+
+	secrets, err := NewFileProvider(envFile).GetSecrets(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"github.token": "s3cr3t"}, secrets)
+
+	secrets, err = NewFileProvider(filepath.Join(dir, "missing.env")).GetSecrets(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, secrets)
+}
+
+func TestFileProviderDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "github.token"), []byte("s3cr3t\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte("ignored"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..data"), 0o700))
+
+	secrets, err := NewFileProvider(dir).GetSecrets(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"github.token": "s3cr3t"}, secrets)
+}
@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// vaultProvider reads the keys of a single secret of a Vault KV v2 secrets
+// engine, or of anything compatible with its HTTP API.
+type vaultProvider struct {
+	address   string
+	token     string
+	namespace string
+	mount     string
+	path      string
+	client    *http.Client
+}
+
+func NewVaultProvider(address, token, mount, path string) SecretProvider {
+	return &vaultProvider{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		mount:   mount,
+		path:    path,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// NewVaultProviderFromEnv configures the provider like the vault CLI: with
+// VAULT_ADDR, VAULT_TOKEN, or ~/.vault-token, and VAULT_NAMESPACE.
+func NewVaultProviderFromEnv(mount, path string) SecretProvider {
+	address := os.Getenv("VAULT_ADDR")
+	if address == "" {
+		address = "https://127.0.0.1:8200"
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			if buf, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+				token = strings.TrimSpace(string(buf))
+			}
+		}
+	}
+
+	p := NewVaultProvider(address, token, mount, path).(*vaultProvider)
+	p.namespace = os.Getenv("VAULT_NAMESPACE")
+	return p
+}
+
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+}
+
+func (p *vaultProvider) GetSecrets(ctx context.Context, _ []string) (map[string]string, error) {
+	endpoint := p.address + "/v1/" + url.PathEscape(p.mount) + "/data/" + escapePath(p.path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" {
+		req.Header.Set("X-Vault-Token", p.token)
+	}
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return map[string]string{}, nil
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("reading %s/%s: %s: %s", p.mount, p.path, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var kv vaultKVResponse
+	if err := json.NewDecoder(resp.Body).Decode(&kv); err != nil {
+		return nil, fmt.Errorf("decoding %s/%s: %w", p.mount, p.path, err)
+	}
+
+	secrets := map[string]string{}
+	for name, value := range kv.Data.Data {
+		switch value := value.(type) {
+		case string:
+			secrets[name] = value
+		default:
+			buf, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			secrets[name] = string(buf)
+		}
+	}
+	return secrets, nil
+}
+
+func (p *vaultProvider) String() string {
+	return "vault:" + p.mount + "/" + p.path
+}
+
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
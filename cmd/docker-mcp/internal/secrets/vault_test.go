@@ -0,0 +1,64 @@
+package secrets
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vaultStandIn serves a KV v2 secrets engine mounted on secret/.
+func vaultStandIn(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/mcp/prod":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{
+					"data":     map[string]any{"github.token": "s3cr3t", "port": 5432},
+					"metadata": map[string]any{"version": 3},
+				},
+			})
+		default:
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := vaultStandIn(t)
+
+	secrets, err := NewVaultProvider(server.URL, "root", "secret", "mcp/prod").GetSecrets(t.Context(), []string{"github.token"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"github.token": "s3cr3t", "port": "5432"}, secrets)
+
+	secrets, err = NewVaultProvider(server.URL, "root", "secret", "mcp/dev").GetSecrets(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, secrets)
+
+	_, err = NewVaultProvider(server.URL, "wrong", "secret", "mcp/prod").GetSecrets(t.Context(), nil)
+	require.ErrorContains(t, err, "403 Forbidden")
+}
+
+func TestVaultProviderFromEnv(t *testing.T) {
+	server := vaultStandIn(t)
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+
+	chain, err := Parse("vault:secret/mcp/prod", nil)
+	require.NoError(t, err)
+
+	secrets, err := chain.GetSecrets(t.Context(), []string{"github.token"})
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", secrets["github.token"])
+}
@@ -72,6 +72,13 @@ var (
 	ResourceTemplateErrorCounter metric.Int64Counter
 	ResourceTemplatesDiscovered  metric.Int64Gauge
 	ListResourceTemplatesCounter metric.Int64Counter
+
+	// Audited security decisions
+	AuditDecisionCounter metric.Int64Counter
+
+	// Network egress seen by the proxies
+	EgressCounter      metric.Int64Counter
+	EgressBytesCounter metric.Int64Counter
 )
 
 // Init initializes the telemetry package with global providers
@@ -337,6 +344,36 @@ func Init() {
 		}
 	}
 
+	AuditDecisionCounter, err = meter.Int64Counter("mcp.audit.decisions",
+		metric.WithDescription("Number of audited security decisions"),
+		metric.WithUnit("1"))
+	if err != nil {
+		// Log error but don't fail
+		if os.Getenv("DOCKER_MCP_TELEMETRY_DEBUG") != "" {
+			fmt.Fprintf(os.Stderr, "[MCP-TELEMETRY] Error creating audit decision counter: %v\n", err)
+		}
+	}
+
+	EgressCounter, err = meter.Int64Counter("mcp.egress.connections",
+		metric.WithDescription("Number of outbound connections and requests seen by the network proxies"),
+		metric.WithUnit("1"))
+	if err != nil {
+		// Log error but don't fail
+		if os.Getenv("DOCKER_MCP_TELEMETRY_DEBUG") != "" {
+			fmt.Fprintf(os.Stderr, "[MCP-TELEMETRY] Error creating egress counter: %v\n", err)
+		}
+	}
+
+	EgressBytesCounter, err = meter.Int64Counter("mcp.egress.bytes",
+		metric.WithDescription("Bytes exchanged through the network proxies"),
+		metric.WithUnit("By"))
+	if err != nil {
+		// Log error but don't fail
+		if os.Getenv("DOCKER_MCP_TELEMETRY_DEBUG") != "" {
+			fmt.Fprintf(os.Stderr, "[MCP-TELEMETRY] Error creating egress bytes counter: %v\n", err)
+		}
+	}
+
 	if os.Getenv("DOCKER_MCP_TELEMETRY_DEBUG") != "" {
 		fmt.Fprintf(os.Stderr, "[MCP-TELEMETRY] Metrics created successfully\n")
 	}
@@ -798,3 +835,53 @@ func RecordResourceTemplateList(ctx context.Context, serverName string, template
 			attribute.String("mcp.server.origin", serverName),
 		))
 }
+
+// RecordAuditDecision records a security decision taken by the gateway
+func RecordAuditDecision(ctx context.Context, kind, decision, serverName string) {
+	if AuditDecisionCounter == nil {
+		return // Telemetry not initialized
+	}
+
+	if os.Getenv("DOCKER_MCP_TELEMETRY_DEBUG") != "" {
+		fmt.Fprintf(os.Stderr, "[MCP-TELEMETRY] Audit decision: %s %s for server %s\n",
+			kind, decision, serverName)
+	}
+
+	AuditDecisionCounter.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("mcp.audit.kind", kind),
+			attribute.String("mcp.audit.decision", decision),
+			attribute.String("mcp.server.origin", serverName),
+		))
+}
+
+// RecordEgress records an outbound connection, or request, of an MCP server
+func RecordEgress(ctx context.Context, serverName, host string, allowed bool, bytesSent, bytesReceived int64) {
+	if EgressCounter == nil || EgressBytesCounter == nil {
+		return // Telemetry not initialized
+	}
+
+	if os.Getenv("DOCKER_MCP_TELEMETRY_DEBUG") != "" {
+		fmt.Fprintf(os.Stderr, "[MCP-TELEMETRY] Egress: server %s to %s, allowed=%t, sent=%d, received=%d\n",
+			serverName, host, allowed, bytesSent, bytesReceived)
+	}
+
+	EgressCounter.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("mcp.server.origin", serverName),
+			attribute.String("mcp.egress.host", host),
+			attribute.Bool("mcp.egress.allowed", allowed),
+		))
+	EgressBytesCounter.Add(ctx, bytesSent,
+		metric.WithAttributes(
+			attribute.String("mcp.server.origin", serverName),
+			attribute.String("mcp.egress.host", host),
+			attribute.String("mcp.egress.direction", "sent"),
+		))
+	EgressBytesCounter.Add(ctx, bytesReceived,
+		metric.WithAttributes(
+			attribute.String("mcp.server.origin", serverName),
+			attribute.String("mcp.egress.host", host),
+			attribute.String("mcp.egress.direction", "received"),
+		))
+}
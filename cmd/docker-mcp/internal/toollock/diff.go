@@ -0,0 +1,83 @@
+package toollock
+
+import (
+	"sort"
+)
+
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change describes how a single tool differs from its approved definition.
+type Change struct {
+	Tool   string          `json:"tool"`
+	Kind   ChangeKind      `json:"kind"`
+	Fields []FieldChange   `json:"fields,omitempty"`
+	Before *ToolDefinition `json:"before,omitempty"`
+	After  *ToolDefinition `json:"after,omitempty"`
+}
+
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Diff compares two sets of tool definitions. Changes are sorted by tool name.
+func Diff(approved, current []ToolDefinition) []Change {
+	before := map[string]ToolDefinition{}
+	for _, definition := range approved {
+		before[definition.Name] = definition
+	}
+	after := map[string]ToolDefinition{}
+	for _, definition := range current {
+		after[definition.Name] = definition
+	}
+
+	var changes []Change
+
+	for name, a := range after {
+		b, found := before[name]
+		if !found {
+			changes = append(changes, Change{Tool: name, Kind: Added, After: &a})
+			continue
+		}
+
+		fields := diffFields(b, a)
+		if len(fields) > 0 {
+			changes = append(changes, Change{Tool: name, Kind: Changed, Fields: fields, Before: &b, After: &a})
+		}
+	}
+
+	for name, b := range before {
+		if _, found := after[name]; !found {
+			changes = append(changes, Change{Tool: name, Kind: Removed, Before: &b})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Tool < changes[j].Tool
+	})
+
+	return changes
+}
+
+func diffFields(before, after ToolDefinition) []FieldChange {
+	var fields []FieldChange
+
+	add := func(field, b, a string) {
+		if b != a {
+			fields = append(fields, FieldChange{Field: field, Before: b, After: a})
+		}
+	}
+	add("title", before.Title, after.Title)
+	add("description", before.Description, after.Description)
+	add("inputSchema", before.InputSchema, after.InputSchema)
+	add("annotations", before.Annotations, after.Annotations)
+
+	return fields
+}
@@ -0,0 +1,277 @@
+package toollock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// Filename is the name of the lock file, relative to ~/.docker/mcp.
+const Filename = "tools.lock"
+
+// MetaKey is the key under which the pinning status is attached to a tool's _meta.
+const MetaKey = "docker.com/toollock"
+
+// Mode is what the gateway does when a server's tools differ from the approved ones.
+type Mode string
+
+const (
+	// ModeOff disables pinning.
+	ModeOff Mode = "off"
+	// ModeWarn logs the changes and registers the new tools.
+	ModeWarn Mode = "warn"
+	// ModeApprove registers the unchanged tools only. New and changed
+	// tools are held back until they are approved.
+	ModeApprove Mode = "approve"
+	// ModeBlock doesn't register any of the server's tools until the
+	// changes are approved.
+	ModeBlock Mode = "block"
+)
+
+var modes = []Mode{ModeOff, ModeWarn, ModeApprove, ModeBlock}
+
+func ParseMode(value string) (Mode, error) {
+	mode := Mode(strings.ToLower(strings.TrimSpace(value)))
+	if mode == "" {
+		return ModeWarn, nil
+	}
+	if !slices.Contains(modes, mode) {
+		return "", fmt.Errorf("invalid tool pinning mode %q, expected one of off, warn, approve or block", value)
+	}
+
+	return mode, nil
+}
+
+// Lock is the content of the lock file.
+type Lock struct {
+	Servers map[string]*ServerLock `yaml:"servers,omitempty"`
+
+	mu    sync.Mutex
+	dirty bool
+}
+
+// ServerLock holds the approved tool definitions of a server and, if they
+// differ, the definitions that were last seen.
+type ServerLock struct {
+	Hash       string           `yaml:"hash"`
+	ApprovedAt time.Time        `yaml:"approvedAt"`
+	Tools      []ToolDefinition `yaml:"tools"`
+	Pending    *Pending         `yaml:"pending,omitempty"`
+}
+
+type Pending struct {
+	Hash       string           `yaml:"hash"`
+	DetectedAt time.Time        `yaml:"detectedAt"`
+	Tools      []ToolDefinition `yaml:"tools"`
+}
+
+// ToolDefinition is the part of a tool that is pinned.
+type ToolDefinition struct {
+	Name        string `yaml:"name" json:"name"`
+	Title       string `yaml:"title,omitempty" json:"title,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	InputSchema string `yaml:"inputSchema,omitempty" json:"inputSchema,omitempty"`
+	Annotations string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// Definitions extracts the pinned definitions of tools, sorted by name.
+func Definitions(tools []*mcp.Tool) []ToolDefinition {
+	var definitions []ToolDefinition
+
+	for _, tool := range tools {
+		definitions = append(definitions, ToolDefinition{
+			Name:        tool.Name,
+			Title:       tool.Title,
+			Description: tool.Description,
+			InputSchema: canonicalJSON(tool.InputSchema),
+			Annotations: canonicalJSON(tool.Annotations),
+		})
+	}
+
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
+	})
+
+	return definitions
+}
+
+// Hash computes a stable hash of tool definitions.
+func Hash(definitions []ToolDefinition) string {
+	buf, _ := json.Marshal(definitions)
+	sum := sha256.Sum256(buf)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// canonicalJSON marshals a value with sorted keys, so that equivalent
+// schemas always give the same string.
+func canonicalJSON(v any) string {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+
+	var generic any
+	if err := json.Unmarshal(buf, &generic); err != nil || generic == nil {
+		return ""
+	}
+
+	// encoding/json sorts map keys.
+	buf, err = json.Marshal(generic)
+	if err != nil {
+		return ""
+	}
+	return string(buf)
+}
+
+// ReadFile reads a lock file. A missing file is an empty lock.
+func ReadFile(path string) (*Lock, error) {
+	lock := &Lock{Servers: map[string]*ServerLock{}}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return lock, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(buf, lock); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if lock.Servers == nil {
+		lock.Servers = map[string]*ServerLock{}
+	}
+
+	return lock, nil
+}
+
+// WriteFile writes the lock file if it was modified since it was read.
+func (l *Lock) WriteFile(path string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.dirty {
+		return nil
+	}
+
+	buf, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		return err
+	}
+
+	l.dirty = false
+	return nil
+}
+
+// Check compares the tools listed by a server with the approved ones.
+// The first time a server is seen, its tools are approved. Otherwise,
+// differences are recorded as pending until they are approved.
+func (l *Lock) Check(serverName string, tools []*mcp.Tool) []Change {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	definitions := Definitions(tools)
+	hash := Hash(definitions)
+
+	server, found := l.Servers[serverName]
+	if !found {
+		l.Servers[serverName] = &ServerLock{
+			Hash:       hash,
+			ApprovedAt: time.Now().UTC(),
+			Tools:      definitions,
+		}
+		l.dirty = true
+		return nil
+	}
+
+	if server.Hash == hash {
+		if server.Pending != nil {
+			// The server went back to the approved definitions.
+			server.Pending = nil
+			l.dirty = true
+		}
+		return nil
+	}
+
+	if server.Pending == nil || server.Pending.Hash != hash {
+		server.Pending = &Pending{
+			Hash:       hash,
+			DetectedAt: time.Now().UTC(),
+			Tools:      definitions,
+		}
+		l.dirty = true
+	}
+
+	return Diff(server.Tools, definitions)
+}
+
+// Approve accepts the pending definitions of a server.
+func (l *Lock) Approve(serverName string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	server, found := l.Servers[serverName]
+	if !found {
+		return fmt.Errorf("no tools recorded for server %s", serverName)
+	}
+	if server.Pending == nil {
+		return fmt.Errorf("no pending changes for server %s", serverName)
+	}
+
+	server.Hash = server.Pending.Hash
+	server.Tools = server.Pending.Tools
+	server.ApprovedAt = time.Now().UTC()
+	server.Pending = nil
+	l.dirty = true
+
+	return nil
+}
+
+// PendingServers returns the names of the servers with pending changes, sorted.
+func (l *Lock) PendingServers() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var names []string
+	for name, server := range l.Servers {
+		if server.Pending != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// PendingChanges returns the differences between the approved and the pending
+// definitions of a server.
+func (l *Lock) PendingChanges(serverName string) []Change {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	server, found := l.Servers[serverName]
+	if !found || server.Pending == nil {
+		return nil
+	}
+
+	return Diff(server.Tools, server.Pending.Tools)
+}
@@ -0,0 +1,187 @@
+package toolscan
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	RuleHiddenInstructions = "hidden-instructions"
+	RuleInvisibleUnicode   = "invisible-unicode"
+	RuleCrossToolReference = "cross-tool-reference"
+	RuleExfiltrationURL    = "exfiltration-url"
+)
+
+type rule struct {
+	name    string
+	message string
+	// find returns the [start, end) byte ranges of every match. Those are the
+	// ranges removed when sanitizing.
+	find func(text string) [][]int
+}
+
+// Sentence-level phrases extend to the end of the sentence so that stripping
+// removes the whole instruction and not only its opening words.
+const restOfSentence = `[^.!?\n]*[.!?]?`
+
+var rules = sync.OnceValue(func() []rule {
+	hiddenInstructions := []*regexp.Regexp{
+		// Tags that tool authors use to smuggle instructions to the model.
+		regexp.MustCompile(`(?is)<(important|system|instructions?|secret|hidden|admin|assistant)>.*?</(important|system|instructions?|secret|hidden|admin|assistant)>`),
+		regexp.MustCompile(`(?is)<(important|system|instructions?|secret|hidden|admin|assistant)>` + restOfSentence),
+		regexp.MustCompile(`(?s)<!--.*?-->`),
+		regexp.MustCompile(`(?i)\b(ignore|disregard|forget)\s+(all\s+)?(the\s+)?(previous|prior|above|earlier|other)\s+(instructions|directions|rules|prompts?)` + restOfSentence),
+		regexp.MustCompile(`(?i)\b(do\s+not|don'?t|never)\s+(tell|mention|inform|reveal|show|notify)\b[^.!?\n]{0,40}\b(the\s+)?user` + restOfSentence),
+		regexp.MustCompile(`(?i)\bwithout\s+(telling|informing|notifying|asking)\s+the\s+user` + restOfSentence),
+		regexp.MustCompile(`(?i)\b(read|send|upload|include|attach|pass)\b[^.!?\n]{0,40}(~/\.ssh|id_rsa|\.env\b|/etc/passwd|mcp\.json|credentials|private\s+key)` + restOfSentence),
+	}
+
+	crossToolReferences := []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(before|after|instead\s+of|prior\s+to|whenever|when)\s+(using|calling|invoking|running|executing)\b[^.!?\n]{1,60}\b(you\s+must\s+|always\s+|also\s+|first\s+)+(call|use|invoke|run|execute)` + restOfSentence),
+		regexp.MustCompile(`(?i)\b(always|also|first|must)\s+(call|invoke|use|run|execute)\s+the\s+[\w.:-]+\s+tool` + restOfSentence),
+		regexp.MustCompile(`(?i)\b(this|the)\s+tool\s+(overrides|replaces|supersedes)\b` + restOfSentence),
+	}
+
+	return []rule{
+		{
+			name:    RuleHiddenInstructions,
+			message: "contains hidden instructions aimed at the model",
+			find:    findAll(hiddenInstructions),
+		},
+		{
+			name:    RuleInvisibleUnicode,
+			message: "contains invisible or bidirectional unicode characters",
+			find:    findInvisible,
+		},
+		{
+			name:    RuleCrossToolReference,
+			message: "tries to influence how other tools are used",
+			find:    findAll(crossToolReferences),
+		},
+		{
+			name:    RuleExfiltrationURL,
+			message: "references an exfiltration-style URL",
+			find:    findExfiltrationURLs,
+		},
+	}
+})
+
+func findAll(expressions []*regexp.Regexp) func(string) [][]int {
+	return func(text string) [][]int {
+		var matches [][]int
+		for _, expression := range expressions {
+			matches = append(matches, expression.FindAllStringIndex(text, -1)...)
+		}
+		return matches
+	}
+}
+
+// isInvisible reports whether r is a zero-width, bidirectional control or
+// tag character. Those render as nothing but are still read by the model.
+func isInvisible(r rune) bool {
+	switch {
+	case r == 0x00AD, // soft hyphen
+		r == 0x180E,                  // mongolian vowel separator
+		r == 0xFEFF,                  // zero width no-break space
+		r >= 0x200B && r <= 0x200F,   // zero width spaces, joiners, LRM/RLM
+		r >= 0x202A && r <= 0x202E,   // bidi embeddings and overrides
+		r >= 0x2060 && r <= 0x2064,   // word joiner, invisible operators
+		r >= 0x2066 && r <= 0x2069,   // bidi isolates
+		r >= 0xE0000 && r <= 0xE007F: // tag characters
+		return true
+	}
+	return false
+}
+
+func findInvisible(text string) [][]int {
+	var matches [][]int
+	for i, r := range text {
+		if isInvisible(r) {
+			matches = append(matches, []int{i, i + utf8.RuneLen(r)})
+		}
+	}
+	return matches
+}
+
+var (
+	urlExpression         = regexp.MustCompile(`(?i)\bhttps?://[^\s"'<>()\[\]{}]+(\{[^\s}]*\}[^\s"'<>()\[\]]*)*`)
+	markdownImage         = regexp.MustCompile(`!\[[^\]]*\]\(\s*(https?://[^\s)]+)\s*\)`)
+	placeholderExpression = regexp.MustCompile(`\{\{?[^}]*\}\}?|\$\{?[A-Za-z_]|%[sv]`)
+)
+
+// Services whose only purpose is to capture inbound requests.
+var exfiltrationHosts = []string{
+	"webhook.site",
+	"requestbin.com",
+	"requestbin.net",
+	"pipedream.net",
+	"requestcatcher.com",
+	"hookbin.com",
+	"beeceptor.com",
+	"ngrok.io",
+	"ngrok-free.app",
+	"ngrok.app",
+	"burpcollaborator.net",
+	"oastify.com",
+	"interact.sh",
+	"oast.fun",
+	"oast.live",
+	"oast.me",
+	"oast.pro",
+	"oast.site",
+	"canarytokens.com",
+	"pastebin.com",
+	"transfer.sh",
+}
+
+func findExfiltrationURLs(text string) [][]int {
+	var matches [][]int
+
+	for _, match := range urlExpression.FindAllStringIndex(text, -1) {
+		if isExfiltrationURL(text[match[0]:match[1]]) {
+			matches = append(matches, match)
+		}
+	}
+
+	// Markdown images are fetched by clients as soon as they're rendered,
+	// which leaks whatever was put in the query string.
+	for _, match := range markdownImage.FindAllStringSubmatchIndex(text, -1) {
+		if strings.Contains(text[match[2]:match[3]], "?") {
+			matches = append(matches, match[:2])
+		}
+	}
+
+	return matches
+}
+
+func isExfiltrationURL(rawURL string) bool {
+	u, err := url.Parse(strings.TrimRight(rawURL, ".,;:!?"))
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return !ip.IsLoopback()
+	}
+
+	// Placeholders meant to be filled in by the model with data.
+	if placeholderExpression.MatchString(rawURL) {
+		return true
+	}
+
+	for _, exfiltrationHost := range exfiltrationHosts {
+		if host == exfiltrationHost || strings.HasSuffix(host, "."+exfiltrationHost) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,233 @@
+package toolscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MetaKey is the key under which findings are attached to a tool's _meta.
+const MetaKey = "docker.com/toolscan"
+
+// Action is what the gateway does with a tool when the scanner finds something.
+type Action string
+
+const (
+	// ActionOff disables scanning.
+	ActionOff Action = "off"
+	// ActionWarn logs the findings and registers the tool unchanged.
+	ActionWarn Action = "warn"
+	// ActionStrip removes the offending text and registers the sanitized tool.
+	// Tools that can't be sanitized are refused.
+	ActionStrip Action = "strip"
+	// ActionRefuse doesn't register the tool at all.
+	ActionRefuse Action = "refuse"
+)
+
+var actions = []Action{ActionOff, ActionWarn, ActionStrip, ActionRefuse}
+
+func ParseAction(value string) (Action, error) {
+	action := Action(strings.ToLower(strings.TrimSpace(value)))
+	if action == "" {
+		return ActionWarn, nil
+	}
+	if !slices.Contains(actions, action) {
+		return "", fmt.Errorf("invalid tool scan action %q, expected one of off, warn, strip or refuse", value)
+	}
+
+	return action, nil
+}
+
+// Finding is a single suspicious pattern found in a tool's metadata or in a tool result.
+type Finding struct {
+	Rule    string `json:"rule"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s %s (%s)", f.Field, f.Message, f.Rule)
+}
+
+// ScanText returns the findings for a piece of text. field is used to tell
+// where the text comes from.
+func ScanText(field, text string) []Finding {
+	var findings []Finding
+
+	for _, rule := range rules() {
+		if len(rule.find(text)) > 0 {
+			findings = append(findings, Finding{
+				Rule:    rule.name,
+				Field:   field,
+				Message: rule.message,
+			})
+		}
+	}
+
+	return findings
+}
+
+// StripText removes every suspicious span from text.
+func StripText(text string) string {
+	var ranges [][]int
+	for _, rule := range rules() {
+		ranges = append(ranges, rule.find(text)...)
+	}
+	if len(ranges) == 0 {
+		return text
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i][0] < ranges[j][0]
+	})
+
+	var stripped strings.Builder
+	last := 0
+	for _, r := range ranges {
+		if r[0] > last {
+			stripped.WriteString(text[last:r[0]])
+		}
+		last = max(last, r[1])
+	}
+	stripped.WriteString(text[last:])
+
+	return strings.TrimSpace(stripped.String())
+}
+
+// ScanTool scans the name, title, description and input schema of a tool.
+func ScanTool(tool *mcp.Tool) []Finding {
+	var findings []Finding
+
+	findings = append(findings, ScanText("name", tool.Name)...)
+	findings = append(findings, ScanText("title", tool.Title)...)
+	if tool.Annotations != nil {
+		findings = append(findings, ScanText("annotations.title", tool.Annotations.Title)...)
+	}
+	findings = append(findings, ScanText("description", tool.Description)...)
+
+	if tool.InputSchema != nil {
+		if schema, err := schemaToMap(tool.InputSchema); err == nil {
+			walkStrings(schema, "inputSchema", func(path, value string) string {
+				findings = append(findings, ScanText(path, value)...)
+				return value
+			})
+		}
+	}
+
+	return findings
+}
+
+// StripTool returns a sanitized copy of a tool. The second return value is
+// false if the tool can't be sanitized, for example because its name or one
+// of its schema's property names is suspicious.
+func StripTool(tool *mcp.Tool) (*mcp.Tool, bool) {
+	stripped := *tool
+	stripped.Title = StripText(tool.Title)
+	stripped.Description = StripText(tool.Description)
+	if tool.Annotations != nil {
+		annotations := *tool.Annotations
+		annotations.Title = StripText(annotations.Title)
+		stripped.Annotations = &annotations
+	}
+
+	if tool.InputSchema != nil {
+		schema, err := schemaToMap(tool.InputSchema)
+		if err != nil {
+			return nil, false
+		}
+
+		sanitized := walkStrings(schema, "inputSchema", func(_, value string) string {
+			return StripText(value)
+		})
+
+		buf, err := json.Marshal(sanitized)
+		if err != nil {
+			return nil, false
+		}
+		var inputSchema jsonschema.Schema
+		if err := json.Unmarshal(buf, &inputSchema); err != nil {
+			return nil, false
+		}
+		stripped.InputSchema = &inputSchema
+	}
+
+	if len(ScanTool(&stripped)) > 0 {
+		return nil, false
+	}
+
+	return &stripped, true
+}
+
+// Annotate records findings in the tool's _meta so that clients, including
+// `docker mcp tools ls`, can show them.
+func Annotate(tool *mcp.Tool, findings []Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	if tool.Meta == nil {
+		tool.Meta = mcp.Meta{}
+	}
+	tool.Meta[MetaKey] = findings
+}
+
+// FindingsFromMeta reads back the findings recorded by [Annotate].
+func FindingsFromMeta(meta map[string]any) []Finding {
+	value, found := meta[MetaKey]
+	if !found {
+		return nil
+	}
+
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var findings []Finding
+	if err := json.Unmarshal(buf, &findings); err != nil {
+		return nil
+	}
+
+	return findings
+}
+
+func schemaToMap(schema *jsonschema.Schema) (any, error) {
+	buf, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	if err := json.Unmarshal(buf, &value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// walkStrings calls fn on every string value and every object key of a
+// decoded JSON document and returns the document with the values replaced.
+// Keys are reported but never rewritten.
+func walkStrings(value any, path string, fn func(path, value string) string) any {
+	switch v := value.(type) {
+	case string:
+		return fn(path, v)
+	case []any:
+		for i, item := range v {
+			v[i] = walkStrings(item, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+		return v
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			fn(path+".<key>", key)
+			v[key] = walkStrings(v[key], path+"."+key, fn)
+		}
+		return v
+	default:
+		return v
+	}
+}
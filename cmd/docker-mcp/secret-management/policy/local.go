@@ -0,0 +1,35 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/config"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/secretpolicy"
+)
+
+// DumpLocal prints the policy that the gateway enforces on Docker CE, in the
+// same format as Docker Desktop's.
+func DumpLocal() error {
+	content, err := config.ReadPolicy()
+	if err != nil {
+		return err
+	}
+
+	policy, err := secretpolicy.Parse(string(content))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(policy.String())
+	return nil
+}
+
+// SetLocal replaces the policy that the gateway enforces on Docker CE.
+func SetLocal(data string) error {
+	policy, err := secretpolicy.Parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid policy: %w", err)
+	}
+
+	return config.WritePolicy([]byte(policy.String() + "\n"))
+}
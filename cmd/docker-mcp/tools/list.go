@@ -10,6 +10,9 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/toollock"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/toolscan"
 )
 
 func List(ctx context.Context, version string, gatewayArgs []string, debug bool, show, tool, format string) error {
@@ -49,6 +52,12 @@ func List(ctx context.Context, version string, gatewayArgs []string, debug bool,
 			fmt.Println(len(response.Tools), "tools:")
 			for _, tool := range response.Tools {
 				fmt.Println(" -", tool.Name, "-", toolDescription(tool))
+				for _, finding := range toolscan.FindingsFromMeta(tool.Meta) {
+					fmt.Println("   ! warning:", finding)
+				}
+				if kind, ok := tool.Meta[toollock.MetaKey].(string); ok {
+					fmt.Println("   ! warning: tool", kind, "since it was approved, see `docker mcp tools diff`")
+				}
 			}
 		}
 	case "count":
@@ -79,6 +88,9 @@ func List(ctx context.Context, version string, gatewayArgs []string, debug bool,
 		} else {
 			fmt.Println("Name:", found.Name)
 			fmt.Println("Description:", found.Description)
+			for _, finding := range toolscan.FindingsFromMeta(found.Meta) {
+				fmt.Println("Warning:", finding)
+			}
 
 			// TODO: Need to properly handle the new jsonschema.Schema format
 			if found.InputSchema != nil {
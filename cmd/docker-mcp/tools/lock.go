@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/config"
+	"github.com/docker/mcp-gateway/cmd/docker-mcp/internal/toollock"
+)
+
+func readLock() (*toollock.Lock, string, error) {
+	path, err := config.FilePath(toollock.Filename)
+	if err != nil {
+		return nil, "", err
+	}
+
+	lock, err := toollock.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return lock, path, nil
+}
+
+// Diff shows how the tools of servers changed since they were approved.
+func Diff(serverNames []string, format string) error {
+	lock, _, err := readLock()
+	if err != nil {
+		return err
+	}
+
+	if len(serverNames) == 0 {
+		serverNames = lock.PendingServers()
+	}
+
+	changes := map[string][]toollock.Change{}
+	for _, serverName := range serverNames {
+		if _, found := lock.Servers[serverName]; !found {
+			return fmt.Errorf("no tools recorded for server %s", serverName)
+		}
+		if serverChanges := lock.PendingChanges(serverName); len(serverChanges) > 0 {
+			changes[serverName] = serverChanges
+		}
+	}
+
+	if format == "json" {
+		buf, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshalling changes: %w", err)
+		}
+
+		fmt.Println(string(buf))
+		return nil
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No tool changes pending approval")
+		return nil
+	}
+
+	for _, serverName := range serverNames {
+		serverChanges, found := changes[serverName]
+		if !found {
+			continue
+		}
+
+		fmt.Printf("%s (detected %s):\n", serverName, lock.Servers[serverName].Pending.DetectedAt.Local().Format("2006-01-02 15:04:05"))
+		for _, change := range serverChanges {
+			fmt.Println(" -", change.Tool, "-", change.Kind)
+			for _, field := range change.Fields {
+				fmt.Printf("   %s:\n", field.Field)
+				printIndented("     - ", field.Before)
+				printIndented("     + ", field.After)
+			}
+		}
+	}
+	fmt.Println()
+	fmt.Println("Run `docker mcp tools approve <server>` to accept the changes.")
+
+	return nil
+}
+
+// Approve accepts the pending tool changes of servers.
+func Approve(serverNames []string) error {
+	lock, path, err := readLock()
+	if err != nil {
+		return err
+	}
+
+	for _, serverName := range serverNames {
+		if err := lock.Approve(serverName); err != nil {
+			return err
+		}
+	}
+
+	if err := lock.WriteFile(path); err != nil {
+		return err
+	}
+
+	fmt.Println("Approved tool changes of", strings.Join(serverNames, ", "))
+	return nil
+}
+
+func printIndented(prefix, text string) {
+	if text == "" {
+		fmt.Println(prefix)
+		return
+	}
+
+	for line := range strings.SplitSeq(text, "\n") {
+		fmt.Println(prefix + line)
+	}
+}
@@ -24,9 +24,7 @@ group all {
     "mcp-gateway",
     "mcp-gateway-dind",
     "jcat",
-    "l4proxy",
-    "l7proxy",
-    "dns-forwarder",
+    "proxy",
     "validate-docs",
   ]
 }
@@ -56,22 +54,10 @@ target jcat {
 }
 
 
-target l4proxy {
+target proxy {
   inherits = ["_base"]
-  context = "tools/l4proxy"
-  output = ["type=image,name=docker/mcp-l4proxy:v1"]
-}
-
-target l7proxy {
-  inherits = ["_base"]
-  context = "tools/l7proxy"
-  output = ["type=image,name=docker/mcp-l7proxy:v1"]
-}
-
-target dns-forwarder {
-  inherits = ["_base"]
-  context = "tools/dns-forwarder"
-  output = ["type=image,name=docker/mcp-dns-forwarder:v1"]
+  context = "tools/proxy"
+  output = ["type=image,name=docker/mcp-proxy:v1"]
 }
 
 target mcp-gateway {
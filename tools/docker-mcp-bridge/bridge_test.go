@@ -0,0 +1,416 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain turns the test binary into a fake stdio MCP server when
+// BRIDGE_TEST_SERVER is set.
+func TestMain(m *testing.M) {
+	if os.Getenv("BRIDGE_TEST_SERVER") != "" {
+		fakeServer()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// fakeServer answers every request with its method, params and pid. The
+// "notify" method also sends a notification first.
+func fakeServer() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || !msg.isRequest() {
+			continue
+		}
+		if msg.Method == "notify" {
+			fmt.Printf(`{"jsonrpc":"2.0","method":"notifications/message","params":%s}`+"\n", msg.Params)
+		}
+		fmt.Printf(`{"jsonrpc":"2.0","id":%s,"result":{"method":%q,"pid":%d}}`+"\n", msg.ID, msg.Method, os.Getpid())
+	}
+}
+
+func testBridge(t *testing.T, shared bool) (*bridge, *httpHandler, *httptest.Server) {
+	t.Helper()
+	t.Setenv("BRIDGE_TEST_SERVER", "1")
+
+	b := newBridge(config{
+		Path:        "/mcp",
+		Shared:      shared,
+		IdleTimeout: time.Minute,
+		MaxSessions: 2,
+		Command:     []string{os.Args[0]},
+	})
+	handler := newHTTPHandler(b)
+	server := httptest.NewServer(handler.routes("/mcp"))
+	t.Cleanup(func() {
+		server.Close()
+		handler.close()
+	})
+
+	return b, handler, server
+}
+
+type result struct {
+	Method string `json:"method"`
+	PID    int    `json:"pid"`
+}
+
+func post(t *testing.T, url, sessionID, body string) (*http.Response, *message) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url+"/mcp", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessionID != "" {
+		req.Header.Set(sessionHeader, sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	var msg message
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	return resp, &msg
+}
+
+func initialize(t *testing.T, url string) (string, result) {
+	t.Helper()
+
+	resp, msg := post(t, url, "", `{"jsonrpc":"2.0","id":"init","method":"initialize","params":{}}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("initialize: got status %d", resp.StatusCode)
+	}
+	if string(msg.ID) != `"init"` {
+		t.Fatalf("initialize: got id %s", msg.ID)
+	}
+	sessionID := resp.Header.Get(sessionHeader)
+	if sessionID == "" {
+		t.Fatal("initialize: missing session id")
+	}
+
+	resp, _ = post(t, url, sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("initialized: got status %d", resp.StatusCode)
+	}
+
+	var r result
+	if err := json.Unmarshal(msg.Result, &r); err != nil {
+		t.Fatal(err)
+	}
+	return sessionID, r
+}
+
+func call(t *testing.T, url, sessionID string, id int) result {
+	t.Helper()
+
+	resp, msg := post(t, url, sessionID, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/list"}`, id))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("tools/list: got status %d", resp.StatusCode)
+	}
+	if string(msg.ID) != fmt.Sprint(id) {
+		t.Fatalf("tools/list: got id %s, want %d", msg.ID, id)
+	}
+
+	var r result
+	if err := json.Unmarshal(msg.Result, &r); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestSessionPerProcess(t *testing.T) {
+	_, _, server := testBridge(t, false)
+
+	first, init1 := initialize(t, server.URL)
+	second, init2 := initialize(t, server.URL)
+	if init1.PID == init2.PID {
+		t.Fatal("sessions share a process")
+	}
+
+	// Both sessions can use the same ids.
+	if r := call(t, server.URL, first, 1); r.PID != init1.PID || r.Method != "tools/list" {
+		t.Fatalf("got %+v from the first session", r)
+	}
+	if r := call(t, server.URL, second, 1); r.PID != init2.PID {
+		t.Fatalf("got %+v from the second session", r)
+	}
+
+	// The limit is two sessions.
+	resp, _ := post(t, server.URL, "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("third session: got status %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/mcp", nil)
+	req.Header.Set(sessionHeader, first)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: got status %d", resp.StatusCode)
+	}
+
+	resp, _ = post(t, server.URL, first, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("deleted session: got status %d", resp.StatusCode)
+	}
+	initialize(t, server.URL)
+}
+
+func TestSharedProcess(t *testing.T) {
+	_, _, server := testBridge(t, true)
+
+	first, init1 := initialize(t, server.URL)
+	second, init2 := initialize(t, server.URL)
+	if init1.PID != init2.PID {
+		t.Fatal("sessions don't share the process")
+	}
+	if r := call(t, server.URL, first, 7); r.PID != init1.PID {
+		t.Fatalf("got %+v", r)
+	}
+	if r := call(t, server.URL, second, 7); r.PID != init1.PID {
+		t.Fatalf("got %+v", r)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	_, _, server := testBridge(t, false)
+	sessionID, _ := initialize(t, server.URL)
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/mcp", strings.NewReader(`[
+		{"jsonrpc":"2.0","id":1,"method":"tools/list"},
+		{"jsonrpc":"2.0","method":"notifications/cancelled"},
+		{"jsonrpc":"2.0","id":2,"method":"prompts/list"}
+	]`))
+	req.Header.Set(sessionHeader, sessionID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var responses []message
+	if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 2 || string(responses[0].ID) != "1" || string(responses[1].ID) != "2" {
+		t.Fatalf("got %+v", responses)
+	}
+}
+
+func TestServerMessages(t *testing.T) {
+	_, _, server := testBridge(t, false)
+	sessionID, _ := initialize(t, server.URL)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/mcp", nil)
+	req.Header.Set(sessionHeader, sessionID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("got content type %q", contentType)
+	}
+
+	post(t, server.URL, sessionID, `{"jsonrpc":"2.0","id":1,"method":"notify","params":{"data":"hello"}}`)
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			if !strings.Contains(data, `"notifications/message"`) || !strings.Contains(data, `"hello"`) {
+				t.Fatalf("got %s", data)
+			}
+			return
+		}
+	}
+}
+
+func TestIdleSessions(t *testing.T) {
+	b, handler, server := testBridge(t, false)
+	sessionID, _ := initialize(t, server.URL)
+
+	handler.reap(time.Now())
+	if handler.count() != 1 {
+		t.Fatal("active session was closed")
+	}
+
+	handler.reap(time.Now().Add(b.config.IdleTimeout))
+	if handler.count() != 0 {
+		t.Fatal("idle session wasn't closed")
+	}
+	resp, _ := post(t, server.URL, sessionID, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("got status %d", resp.StatusCode)
+	}
+}
+
+func TestInvalidRequests(t *testing.T) {
+	_, _, server := testBridge(t, false)
+
+	for _, test := range []struct {
+		name      string
+		sessionID string
+		body      string
+		status    int
+	}{
+		{name: "not json", body: "{", status: http.StatusBadRequest},
+		{name: "empty batch", body: "[]", status: http.StatusBadRequest},
+		{name: "missing session", body: `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, status: http.StatusBadRequest},
+		{name: "unknown session", sessionID: "unknown", body: `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, status: http.StatusNotFound},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resp, _ := post(t, server.URL, test.sessionID, test.body)
+			if resp.StatusCode != test.status {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, test.status)
+			}
+		})
+	}
+}
+
+func TestHealth(t *testing.T) {
+	_, _, server := testBridge(t, false)
+	initialize(t, server.URL)
+
+	resp, err := http.Get(server.URL + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var health struct {
+		Status   string `json:"status"`
+		Sessions int    `json:"sessions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		t.Fatal(err)
+	}
+	if health.Status != "ok" || health.Sessions != 1 {
+		t.Fatalf("got %+v", health)
+	}
+}
+
+func TestRunShutdown(t *testing.T) {
+	t.Setenv("BRIDGE_TEST_SERVER", "1")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() {
+		done <- newBridge(config{
+			TCPAddr:     addr,
+			IdleTimeout: time.Minute,
+			Command:     []string{os.Args[0]},
+		}).run(ctx)
+	}()
+
+	var conn net.Conn
+	for range 50 {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintln(conn, `{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(line, []byte(`"method":"ping"`)) {
+		t.Fatalf("got %s", line)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("bridge didn't stop")
+	}
+	if _, err := io.ReadAll(conn); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	c, err := parseFlags([]string{"-http-addr", "", "-shared", "python", "-m", "server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.HTTPAddr != "" || c.TCPAddr != ":4444" || !c.Shared || strings.Join(c.Command, " ") != "python -m server" {
+		t.Fatalf("got %+v", c)
+	}
+
+	if _, err := parseFlags([]string{"-tcp-addr", "", "-http-addr", "", "server"}); err == nil {
+		t.Fatal("expected an error without listeners")
+	}
+	if _, err := parseFlags(nil); err == nil {
+		t.Fatal("expected an error without command")
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	path := t.TempDir() + "/token"
+	if err := os.WriteFile(path, []byte("s3cr3t"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := parseFlags([]string{"-secret", "API_TOKEN=" + path, "--", "server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := c.environ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(env, "API_TOKEN=s3cr3t") {
+		t.Fatal("secret not in the environment")
+	}
+
+	if _, err := parseFlags([]string{"-secret", "API_TOKEN", "server"}); err == nil {
+		t.Fatal("expected an error without a path")
+	}
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	sessionHeader = "Mcp-Session-Id"
+
+	// maxBodySize is the largest POST body accepted.
+	maxBodySize = 16 * 1024 * 1024
+
+	// eventsBuffer is how many server messages are kept for a session until
+	// its client opens a GET stream.
+	eventsBuffer = 64
+)
+
+// session is an MCP session of the streamable HTTP transport. Unless the
+// bridge is shared, each session has its own server process.
+type session struct {
+	id       string
+	process  *process
+	events   chan json.RawMessage
+	lastUsed atomic.Int64
+	streams  atomic.Int32
+	closed   chan struct{}
+	closing  sync.Once
+}
+
+func (s *session) touch() {
+	s.lastUsed.Store(time.Now().UnixNano())
+}
+
+func (s *session) idleSince() time.Time {
+	return time.Unix(0, s.lastUsed.Load())
+}
+
+// deliver queues a server message for the client's GET stream.
+func (s *session) deliver(msg json.RawMessage) {
+	select {
+	case s.events <- msg:
+	default:
+		log.Printf("Dropping a server message for session %s: no client is listening", s.id)
+	}
+}
+
+// httpHandler serves the stdio server over the MCP streamable HTTP transport.
+type httpHandler struct {
+	bridge *bridge
+
+	lock     sync.Mutex
+	sessions map[string]*session
+
+	// In shared mode, all the sessions use the same process, that is
+	// initialized only once.
+	sharedLock sync.Mutex
+	shared     *process
+	initResult *message
+}
+
+func newHTTPHandler(b *bridge) *httpHandler {
+	return &httpHandler{
+		bridge:   b,
+		sessions: map[string]*session{},
+	}
+}
+
+func (h *httpHandler) routes(path string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", h.health)
+	mux.HandleFunc(path, h.mcp)
+	return mux
+}
+
+func (h *httpHandler) mcp(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.post(w, r)
+	case http.MethodGet:
+		h.get(w, r)
+	case http.MethodDelete:
+		h.delete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *httpHandler) post(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	messages, batch, err := parseMessages(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(messages) == 1 && messages[0].Method == "initialize" && messages[0].isRequest() {
+		if r.Header.Get(sessionHeader) != "" {
+			http.Error(w, "Session already initialized", http.StatusBadRequest)
+			return
+		}
+		h.initialize(w, r, messages[0])
+		return
+	}
+
+	s, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+
+	var responses []*message
+	for _, msg := range messages {
+		switch {
+		case msg.isRequest():
+			response, err := s.process.call(r.Context(), msg)
+			if err != nil {
+				h.failed(w, s, err)
+				return
+			}
+			responses = append(responses, response)
+		case msg.isNotification() && msg.Method == "notifications/initialized" && h.bridge.config.Shared:
+			// The shared process was initialized by the first session.
+		default:
+			if err := s.process.send(msg); err != nil {
+				h.failed(w, s, err)
+				return
+			}
+		}
+	}
+
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if batch {
+		writeJSON(w, http.StatusOK, responses)
+	} else {
+		writeJSON(w, http.StatusOK, responses[0])
+	}
+}
+
+func (h *httpHandler) initialize(w http.ResponseWriter, r *http.Request, request *message) {
+	if !h.bridge.acquire() {
+		http.Error(w, "Too many sessions", http.StatusServiceUnavailable)
+		return
+	}
+
+	s := &session{
+		id:     newSessionID(),
+		events: make(chan json.RawMessage, eventsBuffer),
+		closed: make(chan struct{}),
+	}
+	s.touch()
+
+	response, err := h.start(r.Context(), s, request)
+	if err != nil {
+		h.bridge.release()
+		log.Printf("Failed to initialize a session: %v", err)
+		http.Error(w, "Failed to start the server", http.StatusBadGateway)
+		return
+	}
+
+	h.lock.Lock()
+	h.sessions[s.id] = s
+	h.lock.Unlock()
+
+	w.Header().Set(sessionHeader, s.id)
+	writeJSON(w, http.StatusOK, response)
+}
+
+// start gives the session a process and forwards it the initialize request.
+// The shared process only sees the first one, the others get the same result.
+func (h *httpHandler) start(ctx context.Context, s *session, request *message) (*message, error) {
+	if !h.bridge.config.Shared {
+		p, err := startProcess(h.bridge.config, s.deliver)
+		if err != nil {
+			return nil, err
+		}
+		response, err := p.call(ctx, request)
+		if err != nil {
+			go p.close()
+			return nil, err
+		}
+		s.process = p
+		return response, nil
+	}
+
+	h.sharedLock.Lock()
+	defer h.sharedLock.Unlock()
+
+	if h.shared != nil && h.shared.exited() {
+		h.shared, h.initResult = nil, nil
+	}
+	if h.shared == nil {
+		p, err := startProcess(h.bridge.config, h.broadcast)
+		if err != nil {
+			return nil, err
+		}
+		response, err := p.call(ctx, request)
+		if err != nil {
+			go p.close()
+			return nil, err
+		}
+		h.shared, h.initResult = p, response
+	}
+
+	s.process = h.shared
+	response := *h.initResult
+	response.ID = request.ID
+	return &response, nil
+}
+
+func (h *httpHandler) broadcast(msg json.RawMessage) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for _, s := range h.sessions {
+		s.deliver(msg)
+	}
+}
+
+func (h *httpHandler) get(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	s.streams.Add(1)
+	defer func() {
+		s.streams.Add(-1)
+		s.touch()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case msg := <-s.events:
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-s.closed:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (h *httpHandler) delete(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+	h.closeSession(s)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// session finds the session of a request, or writes the error.
+func (h *httpHandler) session(w http.ResponseWriter, r *http.Request) (*session, bool) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		http.Error(w, "Missing "+sessionHeader, http.StatusBadRequest)
+		return nil, false
+	}
+
+	h.lock.Lock()
+	s, found := h.sessions[id]
+	h.lock.Unlock()
+
+	if !found {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return nil, false
+	}
+	if s.process.exited() {
+		h.closeSession(s)
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	s.touch()
+	return s, true
+}
+
+// failed reports that the process of a session couldn't handle a message.
+// Sessions of a server that exited are closed so that the client starts over.
+func (h *httpHandler) failed(w http.ResponseWriter, s *session, err error) {
+	switch {
+	case errors.Is(err, errProcessExited):
+		h.closeSession(s)
+		http.Error(w, "Session not found", http.StatusNotFound)
+	case errors.Is(err, context.Canceled):
+		// The client is gone.
+	default:
+		log.Printf("Session %s: %v", s.id, err)
+		http.Error(w, "Failed to reach the server", http.StatusBadGateway)
+	}
+}
+
+func (h *httpHandler) closeSession(s *session) {
+	s.closing.Do(func() {
+		h.lock.Lock()
+		delete(h.sessions, s.id)
+		h.lock.Unlock()
+
+		close(s.closed)
+		if !h.bridge.config.Shared {
+			s.process.close()
+		}
+		h.bridge.release()
+	})
+}
+
+// reap closes the sessions that haven't been used for the idle timeout.
+// Sessions with an open GET stream are never idle.
+func (h *httpHandler) reap(now time.Time) {
+	h.lock.Lock()
+	var idle []*session
+	for _, s := range h.sessions {
+		if s.streams.Load() == 0 && now.Sub(s.idleSince()) >= h.bridge.config.IdleTimeout {
+			idle = append(idle, s)
+		}
+	}
+	h.lock.Unlock()
+
+	for _, s := range idle {
+		log.Printf("Closing idle session %s", s.id)
+		h.closeSession(s)
+	}
+}
+
+// close closes all the sessions and the shared process.
+func (h *httpHandler) close() {
+	h.lock.Lock()
+	sessions := make([]*session, 0, len(h.sessions))
+	for _, s := range h.sessions {
+		sessions = append(sessions, s)
+	}
+	h.lock.Unlock()
+
+	var wg sync.WaitGroup
+	for _, s := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.closeSession(s)
+		}()
+	}
+	wg.Wait()
+
+	h.sharedLock.Lock()
+	defer h.sharedLock.Unlock()
+	if h.shared != nil {
+		h.shared.close()
+	}
+}
+
+func (h *httpHandler) count() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return len(h.sessions)
+}
+
+func (h *httpHandler) health(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":   "ok",
+		"sessions": h.count(),
+		"shared":   h.bridge.config.Shared,
+	})
+}
+
+// parseMessages parses a single JSON-RPC message or a batch.
+func parseMessages(body []byte) ([]*message, bool, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var messages []*message
+		if err := json.Unmarshal(body, &messages); err != nil {
+			return nil, false, fmt.Errorf("invalid JSON-RPC batch: %w", err)
+		}
+		if len(messages) == 0 {
+			return nil, false, errors.New("empty JSON-RPC batch")
+		}
+		return messages, true, nil
+	}
+
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, false, fmt.Errorf("invalid JSON-RPC message: %w", err)
+	}
+	return []*message{&msg}, false, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newSessionID() string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
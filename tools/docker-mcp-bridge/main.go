@@ -2,49 +2,241 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
+// config is the configuration of the bridge, from its flags.
+type config struct {
+	// TCPAddr is where raw TCP connections are accepted. Empty disables it.
+	TCPAddr string
+	// HTTPAddr is where the streamable HTTP transport is served. Empty disables it.
+	HTTPAddr string
+	// Path is the path of the streamable HTTP endpoint.
+	Path string
+	// Shared makes all the HTTP sessions use the same process, for stateless servers.
+	Shared bool
+	// IdleTimeout is how long an unused HTTP session is kept.
+	IdleTimeout time.Duration
+	// MaxSessions limits the number of concurrent sessions and TCP connections. Zero means no limit.
+	MaxSessions int
+	// Secrets are environment variables of the server read from files, as
+	// NAME=path.
+	Secrets []string
+	// Command is the stdio server to bridge.
+	Command []string
+}
+
+// environ is the environment of a new server process. Secret files are read
+// every time so that a server started after a rotation sees the new value.
+func (c config) environ() ([]string, error) {
+	env := os.Environ()
+	for _, secret := range c.Secrets {
+		name, path, _ := strings.Cut(secret, "=")
+		value, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading secret %s: %w", name, err)
+		}
+		env = append(env, name+"="+string(value))
+	}
+	return env, nil
+}
+
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	if err := run(ctx); err != nil {
-		panic(err)
+	config, err := parseFlags(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	} else if err != nil {
+		log.Fatalf("Invalid arguments: %v", err)
+	}
+
+	if err := newBridge(config).run(ctx); err != nil {
+		log.Fatalf("Failed to run bridge: %v", err)
 	}
 }
 
-func run(ctx context.Context) error {
-	ln, err := net.Listen("tcp", ":4444")
-	if err != nil {
-		return err
+func parseFlags(args []string) (config, error) {
+	var c config
+
+	flags := flag.NewFlagSet("docker-mcp-bridge", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: docker-mcp-bridge [flags] command [args...]")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&c.TCPAddr, "tcp-addr", ":4444", "Address of the raw TCP listener (empty to disable)")
+	flags.StringVar(&c.HTTPAddr, "http-addr", ":8080", "Address of the streamable HTTP listener (empty to disable)")
+	flags.StringVar(&c.Path, "path", "/mcp", "Path of the streamable HTTP endpoint")
+	flags.BoolVar(&c.Shared, "shared", false, "Share a single process between all the HTTP sessions (stateless servers only)")
+	flags.DurationVar(&c.IdleTimeout, "idle-timeout", 5*time.Minute, "Close HTTP sessions unused for that long")
+	flags.IntVar(&c.MaxSessions, "max-sessions", 0, "Maximum number of concurrent sessions and TCP connections (0 for no limit)")
+	flags.Func("secret", "Environment variable of the server read from a file, as NAME=path (repeatable)", func(value string) error {
+		if name, path, found := strings.Cut(value, "="); !found || name == "" || path == "" {
+			return errors.New("expected NAME=path")
+		}
+		c.Secrets = append(c.Secrets, value)
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		return config{}, err
 	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			ln.Close()
-			return ctx.Err()
-		default:
-			conn, err := ln.Accept()
-			if err != nil {
-				log.Println("Error accepting connection:", err)
-				continue
-			}
+	c.Command = flags.Args()
+	switch {
+	case len(c.Command) == 0:
+		return config{}, errors.New("missing command")
+	case c.TCPAddr == "" && c.HTTPAddr == "":
+		return config{}, errors.New("both listeners are disabled")
+	case c.IdleTimeout <= 0:
+		return config{}, errors.New("idle timeout must be positive")
+	case c.MaxSessions < 0:
+		return config{}, errors.New("max sessions can't be negative")
+	}
+
+	return c, nil
+}
+
+// bridge exposes a stdio MCP server over raw TCP and streamable HTTP.
+type bridge struct {
+	config config
+
+	sessionsLock sync.Mutex
+	sessions     int
+}
+
+func newBridge(config config) *bridge {
+	return &bridge{config: config}
+}
+
+// acquire reserves a session, if the limit isn't reached.
+func (b *bridge) acquire() bool {
+	b.sessionsLock.Lock()
+	defer b.sessionsLock.Unlock()
+
+	if b.config.MaxSessions > 0 && b.sessions >= b.config.MaxSessions {
+		return false
+	}
+	b.sessions++
+	return true
+}
+
+func (b *bridge) release() {
+	b.sessionsLock.Lock()
+	defer b.sessionsLock.Unlock()
+	b.sessions--
+}
 
-			go func() {
-				cmd := exec.CommandContext(ctx, os.Args[1], os.Args[2:]...)
-				cmd.Stdin = conn
-				cmd.Stdout = conn
-				cmd.Stderr = conn
-				if err := cmd.Run(); err != nil {
-					log.Println("Error running command:", err)
-				}
-			}()
+// run listens on both addresses first, then serves them until ctx is done.
+// All the servers are stopped before it returns.
+func (b *bridge) run(ctx context.Context) error {
+	var (
+		listenConfig net.ListenConfig
+		wg           sync.WaitGroup
+		errs         = make(chan error, 2)
+	)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if b.config.TCPAddr != "" {
+		ln, err := listenConfig.Listen(ctx, "tcp", b.config.TCPAddr)
+		if err != nil {
+			return fmt.Errorf("listening on %s: %w", b.config.TCPAddr, err)
+		}
+		log.Printf("Accepting raw TCP connections on %s", ln.Addr())
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- b.serveTCP(ctx, ln)
+		}()
+	}
+
+	if b.config.HTTPAddr != "" {
+		ln, err := listenConfig.Listen(ctx, "tcp", b.config.HTTPAddr)
+		if err != nil {
+			return fmt.Errorf("listening on %s: %w", b.config.HTTPAddr, err)
+		}
+		log.Printf("Serving streamable HTTP on %s%s", ln.Addr(), b.config.Path)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- b.serveHTTP(ctx, ln)
+		}()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+	cancel()
+	wg.Wait()
+
+	return err
+}
+
+// serveHTTP serves the streamable HTTP transport until ctx is done, then
+// closes all the sessions.
+func (b *bridge) serveHTTP(ctx context.Context, ln net.Listener) error {
+	handler := newHTTPHandler(b)
+	server := &http.Server{
+		Handler:           handler.routes(b.config.Path),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		ticker := time.NewTicker(max(min(b.config.IdleTimeout/4, time.Minute), time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				handler.reap(now)
+			}
 		}
+	}()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(ln)
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- server.Shutdown(shutdownCtx)
+	}()
+
+	// GET streams only end with their session.
+	handler.close()
+	if shutdownErr := <-shutdown; shutdownErr != nil {
+		_ = server.Close()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
 	}
+	return err
 }
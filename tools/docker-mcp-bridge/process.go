@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// stopTimeout is how long a server has to exit once its stdin is closed,
+// and then once it's sent SIGTERM.
+const stopTimeout = 5 * time.Second
+
+var errProcessExited = errors.New("server exited")
+
+// message is a JSON-RPC 2.0 message: a request, a notification or a response.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+func (m *message) isRequest() bool {
+	return m.Method != "" && len(m.ID) > 0
+}
+
+func (m *message) isNotification() bool {
+	return m.Method != "" && len(m.ID) == 0
+}
+
+func (m *message) isResponse() bool {
+	return m.Method == ""
+}
+
+// process is a stdio MCP server that can be used concurrently. The ids of the
+// requests sent through call are rewritten so that clients sharing a process
+// can use the same ids. Everything else the server sends is passed to
+// onMessage.
+type process struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	onMessage func(json.RawMessage)
+
+	writeLock sync.Mutex
+	nextID    atomic.Int64
+
+	pendingLock sync.Mutex
+	pending     map[string]chan *message
+
+	done    chan struct{}
+	waitErr error
+	closing sync.Once
+}
+
+func startProcess(c config, onMessage func(json.RawMessage)) (*process, error) {
+	env, err := c.environ()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(c.Command[0], c.Command[1:]...)
+	cmd.Env = env
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", c.Command[0], err)
+	}
+
+	p := &process{
+		cmd:       cmd,
+		stdin:     stdin,
+		onMessage: onMessage,
+		pending:   map[string]chan *message{},
+		done:      make(chan struct{}),
+	}
+	go p.read(stdout)
+
+	return p, nil
+}
+
+func (p *process) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			log.Printf("Ignoring invalid message from the server: %v", err)
+			continue
+		}
+
+		if msg.isResponse() {
+			p.pendingLock.Lock()
+			response, found := p.pending[string(msg.ID)]
+			delete(p.pending, string(msg.ID))
+			p.pendingLock.Unlock()
+
+			if found {
+				response <- &msg
+				continue
+			}
+		}
+
+		if p.onMessage != nil {
+			p.onMessage(bytes.Clone(line))
+		}
+	}
+
+	p.waitErr = p.cmd.Wait()
+	close(p.done)
+}
+
+// call sends a request and waits for its response, which carries the id of
+// the original request.
+func (p *process) call(ctx context.Context, request *message) (*message, error) {
+	id := strconv.FormatInt(p.nextID.Add(1), 10)
+	response := make(chan *message, 1)
+
+	p.pendingLock.Lock()
+	p.pending[id] = response
+	p.pendingLock.Unlock()
+	defer func() {
+		p.pendingLock.Lock()
+		delete(p.pending, id)
+		p.pendingLock.Unlock()
+	}()
+
+	rewritten := *request
+	rewritten.ID = json.RawMessage(id)
+	if err := p.send(&rewritten); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-response:
+		msg.ID = request.ID
+		return msg, nil
+	case <-p.done:
+		return nil, errProcessExited
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// send sends a notification, or a response to a request of the server, as is.
+func (p *process) send(msg *message) error {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	p.writeLock.Lock()
+	defer p.writeLock.Unlock()
+
+	select {
+	case <-p.done:
+		return errProcessExited
+	default:
+	}
+
+	if _, err := p.stdin.Write(append(buf, '\n')); err != nil {
+		return fmt.Errorf("writing to the server: %w", err)
+	}
+	return nil
+}
+
+func (p *process) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// close closes the server's stdin, then sends it SIGTERM and finally kills it
+// if it doesn't exit in time.
+func (p *process) close() {
+	p.closing.Do(func() {
+		p.writeLock.Lock()
+		_ = p.stdin.Close()
+		p.writeLock.Unlock()
+
+		for _, signal := range []os.Signal{syscall.SIGTERM, os.Kill} {
+			select {
+			case <-p.done:
+				return
+			case <-time.After(stopTimeout):
+				_ = p.cmd.Process.Signal(signal)
+			}
+		}
+		<-p.done
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// serveTCP spawns a server for each raw TCP connection, with its stdio
+// connected to the connection, until ctx is done.
+func (b *bridge) serveTCP(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			log.Printf("Error accepting connection: %v", err)
+			continue
+		}
+
+		if !b.acquire() {
+			log.Printf("Rejecting connection from %s: too many sessions", conn.RemoteAddr())
+			_ = conn.Close()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer b.release()
+			defer conn.Close()
+
+			env, err := b.config.environ()
+			if err != nil {
+				log.Printf("Error starting the server: %v", err)
+				return
+			}
+
+			// The server reads and writes the socket directly so that nothing
+			// outlives it when it exits.
+			socket, err := conn.(*net.TCPConn).File()
+			if err != nil {
+				log.Printf("Error accepting connection: %v", err)
+				return
+			}
+			defer socket.Close()
+
+			cmd := exec.CommandContext(ctx, b.config.Command[0], b.config.Command[1:]...)
+			cmd.Env = env
+			cmd.Stdin = socket
+			cmd.Stdout = socket
+			cmd.Stderr = os.Stderr
+			cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+			cmd.WaitDelay = stopTimeout
+			if err := cmd.Run(); err != nil && ctx.Err() == nil {
+				log.Printf("Error running command: %v", err)
+			}
+		}()
+	}
+}
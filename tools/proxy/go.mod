@@ -1,8 +1,11 @@
-module http-proxy
+module mcp-proxy
 
 go 1.24.5
 
-require github.com/valyala/fasthttp v1.62.0
+require (
+	github.com/valyala/fasthttp v1.62.0
+	golang.org/x/net v0.41.0
+)
 
 require (
 	github.com/andybalholm/brotli v1.1.1 // indirect
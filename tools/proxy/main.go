@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"mcp-proxy/pkg"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	config, err := pkg.ParseConfig(os.Getenv("PROXY_CONFIG"))
+	if err != nil {
+		log.Fatalf("Invalid proxy config: %v", err)
+	}
+
+	if err := pkg.NewSidecar(config, os.Stdout, os.Stderr).Run(ctx); err != nil {
+		log.Fatalf("Failed to run proxy: %v", err)
+	}
+}
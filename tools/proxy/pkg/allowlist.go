@@ -0,0 +1,185 @@
+package pkg
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Rule is an entry of the allowlist: "[METHOD|METHOD ]host:port[/path/prefix]".
+// host is either a hostname, a wildcard (*.example.com) matching any
+// subdomain, an IP address or a CIDR range.
+type Rule struct {
+	Host       string
+	Wildcard   bool
+	Prefix     netip.Prefix
+	Port       string
+	Methods    []string
+	PathPrefix string
+}
+
+// Allowlist is the list of rules a request must match one of.
+type Allowlist []Rule
+
+// ParseAllowlist parses a comma separated list of rules.
+func ParseAllowlist(rules string) (Allowlist, error) {
+	var allowlist Allowlist
+	for rule := range strings.SplitSeq(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		parsed, err := ParseRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		allowlist = append(allowlist, parsed)
+	}
+	return allowlist, nil
+}
+
+// ParseRule parses a single rule.
+func ParseRule(rule string) (Rule, error) {
+	var parsed Rule
+
+	rest := rule
+	if methods, after, found := strings.Cut(rule, " "); found {
+		for method := range strings.SplitSeq(methods, "|") {
+			if method == "" {
+				return Rule{}, fmt.Errorf("invalid rule %q: empty method", rule)
+			}
+			parsed.Methods = append(parsed.Methods, strings.ToUpper(method))
+		}
+		rest = strings.TrimSpace(after)
+	}
+
+	// host:port goes up to the first slash after the port separator, so that
+	// CIDR ranges keep their prefix length.
+	hostPort := rest
+	colon := strings.Index(rest, ":")
+	if strings.HasPrefix(rest, "[") {
+		colon = strings.Index(rest, "]:")
+	}
+	searchFrom := max(colon, 0)
+	if slash := strings.Index(rest[searchFrom:], "/"); slash >= 0 {
+		hostPort, parsed.PathPrefix = rest[:searchFrom+slash], rest[searchFrom+slash:]
+	}
+
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid rule %q: %w", rule, err)
+	}
+	if port == "" {
+		return Rule{}, fmt.Errorf("invalid rule %q: missing port", rule)
+	}
+	parsed.Port = port
+
+	switch {
+	case strings.Contains(host, "/"):
+		prefix, err := netip.ParsePrefix(host)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid rule %q: %w", rule, err)
+		}
+		parsed.Prefix = prefix.Masked()
+	case strings.HasPrefix(host, "*."):
+		parsed.Wildcard = true
+		parsed.Host = strings.ToLower(strings.TrimPrefix(host, "*."))
+		if parsed.Host == "" || strings.Contains(parsed.Host, "*") {
+			return Rule{}, fmt.Errorf("invalid rule %q: invalid wildcard", rule)
+		}
+	default:
+		parsed.Host = canonicalHost(host)
+	}
+
+	return parsed, nil
+}
+
+// NeedsAddresses tells whether matching a hostname requires its IP addresses.
+func (a Allowlist) NeedsAddresses() bool {
+	for _, rule := range a {
+		if rule.Prefix.IsValid() {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows tells whether a request is allowed. For CONNECT requests, path is
+// empty and only the rules without method and path restrictions can match,
+// since the proxy can't see inside the tunnel. addrs are the IP addresses host
+// resolves to, used to match CIDR ranges: all of them must be in the range.
+func (a Allowlist) Allows(method, host, port, requestPath string, addrs []netip.Addr) bool {
+	method = strings.ToUpper(method)
+	host = canonicalHost(strings.TrimSuffix(host, "."))
+	if ip, err := netip.ParseAddr(host); err == nil {
+		addrs = []netip.Addr{ip}
+	}
+
+	for _, rule := range a {
+		if rule.Port != port || !rule.matchesHost(host, addrs) {
+			continue
+		}
+
+		if method == "CONNECT" {
+			if len(rule.Methods) == 0 && rule.PathPrefix == "" {
+				return true
+			}
+			continue
+		}
+
+		if len(rule.Methods) > 0 && !slices.Contains(rule.Methods, method) {
+			continue
+		}
+		if rule.PathPrefix != "" && !matchesPath(rule.PathPrefix, requestPath) {
+			continue
+		}
+		return true
+	}
+
+	return false
+}
+
+func (r Rule) matchesHost(host string, addrs []netip.Addr) bool {
+	switch {
+	case r.Prefix.IsValid():
+		if len(addrs) == 0 {
+			return false
+		}
+		for _, addr := range addrs {
+			if !r.Prefix.Contains(addr.Unmap()) {
+				return false
+			}
+		}
+		return true
+	case r.Wildcard:
+		return strings.HasSuffix(host, "."+r.Host)
+	default:
+		return host == r.Host
+	}
+}
+
+// canonicalHost lowercases hostnames and formats IP addresses the same way,
+// whatever the way they are written: 2001:DB8:0::1 is 2001:db8::1.
+func canonicalHost(host string) string {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return ip.Unmap().String()
+	}
+	return strings.ToLower(host)
+}
+
+// matchesPath matches a cleaned request path against a prefix, on a segment
+// boundary: /v1 matches /v1 and /v1/users but not /v10.
+func matchesPath(prefix, requestPath string) bool {
+	if requestPath == "" {
+		return false
+	}
+	cleaned := path.Clean("/" + requestPath)
+	if strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(cleaned+"/", prefix)
+	}
+	return cleaned == prefix || strings.HasPrefix(cleaned, prefix+"/")
+}
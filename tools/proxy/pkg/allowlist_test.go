@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestAllowlist(t *testing.T) {
+	allowlist, err := ParseAllowlist("api.github.com:443,*.githubusercontent.com:443,10.0.0.0/8:8080,[fd00::/8]:443,[2001:DB8:0::1]:443,GET|HEAD example.com:80/v1,POST example.com:80/upload/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name    string
+		method  string
+		host    string
+		port    string
+		path    string
+		addrs   []string
+		allowed bool
+	}{
+		{name: "exact host", method: "CONNECT", host: "api.github.com", port: "443", allowed: true},
+		{name: "exact host, case insensitive", method: "CONNECT", host: "API.GitHub.com.", port: "443", allowed: true},
+		{name: "exact host, wrong port", method: "CONNECT", host: "api.github.com", port: "80"},
+		{name: "other host", method: "CONNECT", host: "github.com", port: "443"},
+		{name: "wildcard", method: "CONNECT", host: "raw.githubusercontent.com", port: "443", allowed: true},
+		{name: "wildcard, nested", method: "CONNECT", host: "a.b.githubusercontent.com", port: "443", allowed: true},
+		{name: "wildcard doesn't match the apex", method: "CONNECT", host: "githubusercontent.com", port: "443"},
+		{name: "wildcard suffix trick", method: "CONNECT", host: "evilgithubusercontent.com", port: "443"},
+		{name: "cidr, ip", method: "GET", host: "10.1.2.3", port: "8080", path: "/", allowed: true},
+		{name: "cidr, ip out of range", method: "GET", host: "11.1.2.3", port: "8080", path: "/"},
+		{name: "cidr, resolved", method: "GET", host: "internal", port: "8080", path: "/", addrs: []string{"10.0.0.1"}, allowed: true},
+		{name: "cidr, partly out of range", method: "GET", host: "internal", port: "8080", path: "/", addrs: []string{"10.0.0.1", "192.168.0.1"}},
+		{name: "cidr, ipv6", method: "CONNECT", host: "fd00::1", port: "443", allowed: true},
+		{name: "cidr, ipv4-mapped ipv6", method: "GET", host: "::ffff:10.0.0.1", port: "8080", path: "/", allowed: true},
+		{name: "ipv6", method: "CONNECT", host: "2001:db8::1", port: "443", allowed: true},
+		{name: "ipv6, other form", method: "CONNECT", host: "2001:0db8:0:0::1", port: "443", allowed: true},
+		{name: "ipv6, other address", method: "CONNECT", host: "2001:db8::2", port: "443"},
+		{name: "method and path", method: "GET", host: "example.com", port: "80", path: "/v1/items", allowed: true},
+		{name: "method and exact path", method: "HEAD", host: "example.com", port: "80", path: "/v1", allowed: true},
+		{name: "wrong method", method: "DELETE", host: "example.com", port: "80", path: "/v1/items"},
+		{name: "path on a segment boundary", method: "GET", host: "example.com", port: "80", path: "/v10"},
+		{name: "path traversal", method: "GET", host: "example.com", port: "80", path: "/v1/../admin"},
+		{name: "trailing slash prefix", method: "POST", host: "example.com", port: "80", path: "/upload/file", allowed: true},
+		{name: "connect to a path rule", method: "CONNECT", host: "example.com", port: "80"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var addrs []netip.Addr
+			for _, addr := range tc.addrs {
+				addrs = append(addrs, netip.MustParseAddr(addr))
+			}
+
+			if allowed := allowlist.Allows(tc.method, tc.host, tc.port, tc.path, addrs); allowed != tc.allowed {
+				t.Errorf("Allows(%s %s:%s%s) = %v, want %v", tc.method, tc.host, tc.port, tc.path, allowed, tc.allowed)
+			}
+		})
+	}
+
+	if !allowlist.NeedsAddresses() {
+		t.Error("NeedsAddresses() = false, want true")
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	for _, rule := range []string{"foobar", "foobar:", "*.:443", "10.0.0.0/33:443", "| example.com:80"} {
+		if _, err := ParseRule(rule); err == nil {
+			t.Errorf("ParseRule(%q) should fail", rule)
+		}
+	}
+}
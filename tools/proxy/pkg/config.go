@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// DefaultHTTPPort is where the HTTP/CONNECT proxy listens by default.
+const DefaultHTTPPort = 8080
+
+// Config is the configuration of the sidecar, passed as JSON in PROXY_CONFIG.
+type Config struct {
+	// HTTPPort is where the HTTP/CONNECT proxy listens.
+	HTTPPort int `json:"httpPort,omitempty"`
+	// AllowedHosts are the allowlist rules of the HTTP/CONNECT proxy.
+	AllowedHosts []string `json:"allowedHosts,omitempty"`
+	// Forwards are TCP and UDP ports forwarded as is to a single host.
+	Forwards []Forward `json:"forwards,omitempty"`
+	// LogDNS logs every DNS query on stderr.
+	LogDNS bool `json:"logDNS,omitempty"`
+}
+
+// Forward forwards a TCP or UDP port of the sidecar to the same port of a host.
+type Forward struct {
+	Protocol string `json:"protocol"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+}
+
+// ParseConfig parses and validates a JSON configuration.
+func ParseConfig(data string) (Config, error) {
+	var config Config
+	if strings.TrimSpace(data) != "" {
+		if err := json.Unmarshal([]byte(data), &config); err != nil {
+			return Config{}, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+	if config.HTTPPort == 0 {
+		config.HTTPPort = DefaultHTTPPort
+	}
+
+	if _, err := ParseAllowlist(strings.Join(config.AllowedHosts, ",")); err != nil {
+		return Config{}, err
+	}
+
+	// A port can only be forwarded to a single host.
+	used := map[string]string{}
+	if len(config.AllowedHosts) > 0 {
+		used[fmt.Sprintf("tcp/%d", config.HTTPPort)] = "the http proxy"
+	}
+	for _, forward := range config.Forwards {
+		if forward.Protocol != "tcp" && forward.Protocol != "udp" {
+			return Config{}, fmt.Errorf("invalid forward to %s: unknown protocol %q", forward.Host, forward.Protocol)
+		}
+		if forward.Port <= 0 || forward.Port > 65535 {
+			return Config{}, fmt.Errorf("invalid forward to %s: invalid port %d", forward.Host, forward.Port)
+		}
+		if forward.Host == "" {
+			return Config{}, fmt.Errorf("invalid forward of %s port %d: missing host", forward.Protocol, forward.Port)
+		}
+
+		key := fmt.Sprintf("%s/%d", forward.Protocol, forward.Port)
+		if other, found := used[key]; found && other != forward.Host {
+			return Config{}, fmt.Errorf("%s port %d is used by both %s and %s", forward.Protocol, forward.Port, other, forward.Host)
+		}
+		used[key] = forward.Host
+	}
+
+	return config, nil
+}
+
+// Names lists the hostnames, and *.domain wildcards, the sidecar resolves to
+// itself. IP addresses and ranges are not resolved.
+func (c Config) Names() []string {
+	var names []string
+	add := func(name string) {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if _, err := netip.ParseAddr(name); err == nil {
+			return
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	allowlist, _ := ParseAllowlist(strings.Join(c.AllowedHosts, ","))
+	for _, rule := range allowlist {
+		switch {
+		case rule.Prefix.IsValid():
+		case rule.Wildcard:
+			add("*." + rule.Host)
+		default:
+			add(rule.Host)
+		}
+	}
+	for _, forward := range c.Forwards {
+		add(forward.Host)
+	}
+
+	return names
+}
+
+// dnsUpstream is the resolver DNS queries for other names are forwarded to,
+// when port 53 is forwarded. Empty if there is none.
+func (c Config) dnsUpstream() string {
+	for _, forward := range c.Forwards {
+		if forward.Protocol == "udp" && forward.Port == 53 {
+			return forward.Host
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package pkg
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(`{
+		"allowedHosts": ["api.github.com:443", "*.githubusercontent.com:443", "10.0.0.0/8:80", "GET example.com:80/v1"],
+		"forwards": [
+			{"protocol": "tcp", "host": "db.example.com", "port": 5432},
+			{"protocol": "udp", "host": "2001:db8::53", "port": 53},
+			{"protocol": "tcp", "host": "API.github.com", "port": 22}
+		]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.HTTPPort != DefaultHTTPPort {
+		t.Errorf("HTTPPort = %d, want %d", config.HTTPPort, DefaultHTTPPort)
+	}
+
+	want := []string{"api.github.com", "*.githubusercontent.com", "example.com", "db.example.com"}
+	if names := config.Names(); !slices.Equal(names, want) {
+		t.Errorf("Names() = %v, want %v", names, want)
+	}
+
+	if upstream := config.dnsUpstream(); upstream != "2001:db8::53" {
+		t.Errorf("dnsUpstream() = %q, want 2001:db8::53", upstream)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for _, config := range []string{
+		`{`,
+		`{"allowedHosts": ["foobar"]}`,
+		`{"forwards": [{"protocol": "sctp", "host": "db", "port": 5432}]}`,
+		`{"forwards": [{"protocol": "tcp", "host": "db", "port": 0}]}`,
+		`{"forwards": [{"protocol": "tcp", "port": 5432}]}`,
+		`{"forwards": [{"protocol": "tcp", "host": "db1", "port": 5432}, {"protocol": "tcp", "host": "db2", "port": 5432}]}`,
+		`{"allowedHosts": ["api.github.com:443"], "forwards": [{"protocol": "tcp", "host": "db", "port": 8080}]}`,
+	} {
+		if _, err := ParseConfig(config); err == nil {
+			t.Errorf("ParseConfig(%s) should fail", config)
+		}
+	}
+
+	// The same port can be forwarded with both protocols.
+	if _, err := ParseConfig(`{"forwards": [{"protocol": "tcp", "host": "dns", "port": 53}, {"protocol": "udp", "host": "dns", "port": 53}]}`); err != nil {
+		t.Error(err)
+	}
+}
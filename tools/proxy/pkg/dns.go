@@ -0,0 +1,248 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const dnsTTL = 60
+
+// DNSServer resolves the allowed names to the sidecar, so that clients
+// connect to the proxies, and everything else to NXDOMAIN. If a resolver is
+// forwarded on port 53, queries for other names are forwarded to it.
+type DNSServer struct {
+	names     map[string]bool
+	wildcards []string
+	upstream  string
+	// upstreamAddr is upstream:53.
+	upstreamAddr string
+	events       *eventWriter
+	queryLog     io.Writer
+
+	// localAddr returns the address of the sidecar a client can reach.
+	localAddr func(client netip.Addr) (netip.Addr, bool)
+}
+
+// NewDNSServer creates a DNS server for a list of hostnames and *.domain
+// wildcards. upstream is an optional resolver. Each query is logged to
+// queryLog, if not nil.
+func NewDNSServer(names []string, upstream string, events, queryLog io.Writer) *DNSServer {
+	s := &DNSServer{
+		names:     map[string]bool{},
+		upstream:  upstream,
+		events:    &eventWriter{w: events},
+		queryLog:  queryLog,
+		localAddr: interfaceAddr,
+	}
+	if upstream != "" {
+		s.upstreamAddr = net.JoinHostPort(upstream, "53")
+	}
+	for _, name := range names {
+		if domain, found := strings.CutPrefix(name, "*."); found {
+			s.wildcards = append(s.wildcards, domain)
+		} else {
+			s.names[name] = true
+		}
+	}
+	return s
+}
+
+// Serve answers the DNS queries received on conn until ctx is done.
+func (s *DNSServer) Serve(ctx context.Context, conn net.PacketConn) error {
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	buf := make([]byte, 65535)
+	for {
+		n, client, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		query := append([]byte(nil), buf[:n]...)
+		go func() {
+			if response, ok := s.handle(ctx, query, addrOf(client)); ok {
+				_, _ = conn.WriteTo(response, client)
+			}
+		}()
+	}
+}
+
+func (s *DNSServer) handle(ctx context.Context, query []byte, client netip.Addr) ([]byte, bool) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil || header.Response {
+		return nil, false
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, false
+	}
+	name := strings.ToLower(strings.TrimSuffix(question.Name.String(), "."))
+
+	if s.allows(name) {
+		response, err := s.answer(header, question, client)
+		if err != nil {
+			return nil, false
+		}
+		s.logQuery(question, name, "proxy")
+		return response, true
+	}
+
+	if s.upstream != "" {
+		response, err := s.forward(ctx, query)
+		if err != nil {
+			s.logQuery(question, name, "error: "+err.Error())
+			return nil, false
+		}
+		s.logQuery(question, name, "forwarded to "+s.upstream)
+		return response, true
+	}
+
+	s.logQuery(question, name, "NXDOMAIN")
+	s.events.emit(Event{Proxy: "dns", Host: name, Port: 53})
+	response, err := reply(header, question, dnsmessage.RCodeNameError, nil)
+	if err != nil {
+		return nil, false
+	}
+	return response, true
+}
+
+func (s *DNSServer) allows(name string) bool {
+	if s.names[name] {
+		return true
+	}
+	for _, domain := range s.wildcards {
+		if strings.HasSuffix(name, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// answer resolves an allowed name to the address of the sidecar. Queries for
+// other types, or the other IP family, get an empty answer.
+func (s *DNSServer) answer(header dnsmessage.Header, question dnsmessage.Question, client netip.Addr) ([]byte, error) {
+	local, ok := s.localAddr(client)
+	if !ok {
+		return reply(header, question, dnsmessage.RCodeServerFailure, nil)
+	}
+
+	resourceHeader := dnsmessage.ResourceHeader{Name: question.Name, Type: question.Type, Class: dnsmessage.ClassINET, TTL: dnsTTL}
+	switch {
+	case question.Type == dnsmessage.TypeA && local.Is4():
+		return reply(header, question, dnsmessage.RCodeSuccess, func(b *dnsmessage.Builder) error {
+			return b.AResource(resourceHeader, dnsmessage.AResource{A: local.As4()})
+		})
+	case question.Type == dnsmessage.TypeAAAA && local.Is6():
+		return reply(header, question, dnsmessage.RCodeSuccess, func(b *dnsmessage.Builder) error {
+			return b.AAAAResource(resourceHeader, dnsmessage.AAAAResource{AAAA: local.As16()})
+		})
+	default:
+		return reply(header, question, dnsmessage.RCodeSuccess, nil)
+	}
+}
+
+func (s *DNSServer) forward(ctx context.Context, query []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", s.upstreamAddr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	s.events.emit(Event{Proxy: "dns", Host: s.upstream, Port: 53, BytesSent: int64(len(query)), BytesReceived: int64(n), Allowed: true})
+
+	return buf[:n], nil
+}
+
+func (s *DNSServer) logQuery(question dnsmessage.Question, name, result string) {
+	if s.queryLog != nil {
+		fmt.Fprintf(s.queryLog, "dns: %s %s: %s\n", question.Type, name, result)
+	}
+}
+
+func reply(query dnsmessage.Header, question dnsmessage.Question, rcode dnsmessage.RCode, answer func(*dnsmessage.Builder) error) ([]byte, error) {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 query.ID,
+		Response:           true,
+		OpCode:             query.OpCode,
+		Authoritative:      true,
+		RecursionDesired:   query.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              rcode,
+	})
+	b.EnableCompression()
+
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(question); err != nil {
+		return nil, err
+	}
+	if answer != nil {
+		if err := b.StartAnswers(); err != nil {
+			return nil, err
+		}
+		if err := answer(&b); err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+// interfaceAddr finds the address of the sidecar on the network of a client.
+func interfaceAddr(client netip.Addr) (netip.Addr, bool) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		local, ok := netip.AddrFromSlice(ipNet.IP)
+		if !ok {
+			continue
+		}
+		ones, _ := ipNet.Mask.Size()
+		if prefix := netip.PrefixFrom(local.Unmap(), ones); prefix.Contains(client) {
+			return local.Unmap(), true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+func addrOf(addr net.Addr) netip.Addr {
+	if addrPort, err := netip.ParseAddrPort(addr.String()); err == nil {
+		return addrPort.Addr().Unmap()
+	}
+	return netip.Addr{}
+}
@@ -0,0 +1,126 @@
+package pkg
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestDNSServer(t *testing.T) {
+	var events strings.Builder
+	server := NewDNSServer([]string{"api.github.com", "*.githubusercontent.com"}, "", &events, nil)
+	server.localAddr = func(netip.Addr) (netip.Addr, bool) {
+		return netip.MustParseAddr("172.18.0.2"), true
+	}
+
+	testcases := []struct {
+		name   string
+		qtype  dnsmessage.Type
+		rcode  dnsmessage.RCode
+		answer string
+	}{
+		{name: "api.github.com.", qtype: dnsmessage.TypeA, answer: "172.18.0.2"},
+		{name: "API.GitHub.com.", qtype: dnsmessage.TypeA, answer: "172.18.0.2"},
+		{name: "raw.githubusercontent.com.", qtype: dnsmessage.TypeA, answer: "172.18.0.2"},
+		{name: "api.github.com.", qtype: dnsmessage.TypeAAAA},
+		{name: "api.github.com.", qtype: dnsmessage.TypeMX},
+		{name: "githubusercontent.com.", qtype: dnsmessage.TypeA, rcode: dnsmessage.RCodeNameError},
+		{name: "evil.com.", qtype: dnsmessage.TypeA, rcode: dnsmessage.RCodeNameError},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name+" "+tc.qtype.String(), func(t *testing.T) {
+			response, ok := server.handle(context.Background(), query(t, tc.name, tc.qtype), netip.MustParseAddr("172.18.0.3"))
+			if !ok {
+				t.Fatal("no response")
+			}
+
+			var msg dnsmessage.Message
+			if err := msg.Unpack(response); err != nil {
+				t.Fatal(err)
+			}
+			if msg.Header.ID != 42 || !msg.Header.Response {
+				t.Errorf("invalid header %+v", msg.Header)
+			}
+			if msg.Header.RCode != tc.rcode {
+				t.Errorf("rcode = %v, want %v", msg.Header.RCode, tc.rcode)
+			}
+
+			var answer string
+			if len(msg.Answers) > 0 {
+				if a, ok := msg.Answers[0].Body.(*dnsmessage.AResource); ok {
+					answer = netip.AddrFrom4(a.A).String()
+				}
+			}
+			if answer != tc.answer {
+				t.Errorf("answer = %q, want %q", answer, tc.answer)
+			}
+		})
+	}
+
+	want := `{"type":"egress","proxy":"dns","host":"githubusercontent.com","port":53,"bytes_sent":0,"bytes_received":0,"allowed":false}` + "\n" +
+		`{"type":"egress","proxy":"dns","host":"evil.com","port":53,"bytes_sent":0,"bytes_received":0,"allowed":false}` + "\n"
+	if events.String() != want {
+		t.Errorf("events = %s, want %s", events.String(), want)
+	}
+}
+
+func TestDNSServerUpstream(t *testing.T) {
+	// A fake resolver that answers NOERROR to everything.
+	upstream, err := (&net.ListenConfig{}).ListenPacket(context.Background(), "udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := upstream.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var parser dnsmessage.Parser
+			header, _ := parser.Start(buf[:n])
+			question, _ := parser.Question()
+			response, _ := reply(header, question, dnsmessage.RCodeSuccess, nil)
+			_, _ = upstream.WriteTo(response, addr)
+		}
+	}()
+
+	server := NewDNSServer([]string{"api.github.com"}, "127.0.0.1", nil, nil)
+	server.upstreamAddr = upstream.LocalAddr().String()
+
+	response, ok := server.handle(context.Background(), query(t, "example.com.", dnsmessage.TypeA), netip.MustParseAddr("172.18.0.3"))
+	if !ok {
+		t.Fatal("no response")
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(response); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header.RCode != dnsmessage.RCodeSuccess {
+		t.Errorf("rcode = %v, want success", msg.Header.RCode)
+	}
+}
+
+func query(t *testing.T, name string, qtype dnsmessage.Type) []byte {
+	t.Helper()
+
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: 42, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName(name),
+			Type:  qtype,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	buf, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
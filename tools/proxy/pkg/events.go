@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// Event is an egress event, written as a JSON line on stdout for the gateway
+// to collect.
+type Event struct {
+	Type          string `json:"type"`
+	Proxy         string `json:"proxy"`
+	Host          string `json:"host"`
+	Port          int    `json:"port"`
+	Method        string `json:"method,omitempty"`
+	Path          string `json:"path,omitempty"`
+	BytesSent     int64  `json:"bytes_sent"`
+	BytesReceived int64  `json:"bytes_received"`
+	Allowed       bool   `json:"allowed"`
+}
+
+type eventWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (e *eventWriter) emit(event Event) {
+	if e == nil || e.w == nil {
+		return
+	}
+
+	event.Type = "egress"
+
+	buf, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, _ = e.w.Write(append(buf, '\n'))
+}
+
+func parsePort(port string) int {
+	n, _ := strconv.Atoi(port)
+	return n
+}
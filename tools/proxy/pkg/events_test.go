@@ -0,0 +1,18 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEventWriter(t *testing.T) {
+	var buf strings.Builder
+	events := &eventWriter{w: &buf}
+
+	events.emit(Event{Proxy: "l7", Host: "api.github.com", Port: 443, Method: "CONNECT", BytesSent: 10, BytesReceived: 20, Allowed: true})
+
+	want := `{"type":"egress","proxy":"l7","host":"api.github.com","port":443,"method":"CONNECT","bytes_sent":10,"bytes_received":20,"allowed":true}` + "\n"
+	if buf.String() != want {
+		t.Errorf("got %s, want %s", buf.String(), want)
+	}
+}
@@ -0,0 +1,180 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// udpIdleTimeout is how long a UDP flow is kept without any traffic.
+const udpIdleTimeout = 60 * time.Second
+
+// Forwarder forwards the connections, or datagrams, it receives as is to a
+// single host.
+type Forwarder struct {
+	forward     Forward
+	target      string
+	events      *eventWriter
+	idleTimeout time.Duration
+}
+
+func NewForwarder(forward Forward, events io.Writer) *Forwarder {
+	return &Forwarder{
+		forward:     forward,
+		target:      net.JoinHostPort(forward.Host, strconv.Itoa(forward.Port)),
+		events:      &eventWriter{w: events},
+		idleTimeout: udpIdleTimeout,
+	}
+}
+
+// ServeTCP forwards the connections accepted on ln until ctx is done.
+func (f *Forwarder) ServeTCP(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		go f.forwardTCP(ctx, conn)
+	}
+}
+
+func (f *Forwarder) forwardTCP(ctx context.Context, clientConn net.Conn) {
+	defer clientConn.Close()
+
+	event := f.event()
+
+	var dialer net.Dialer
+	upstreamConn, err := dialer.DialContext(ctx, "tcp", f.target)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to connect to", f.target, err)
+		f.events.emit(event)
+		return
+	}
+	defer upstreamConn.Close()
+
+	sent := make(chan int64, 1)
+	go func() {
+		n, _ := io.Copy(upstreamConn, clientConn)
+		closeWrite(upstreamConn)
+		sent <- n
+	}()
+	event.BytesReceived, _ = io.Copy(clientConn, upstreamConn)
+	closeWrite(clientConn)
+
+	event.BytesSent = <-sent
+	f.events.emit(event)
+}
+
+// ServeUDP forwards the datagrams received on conn until ctx is done. Each
+// client gets its own upstream socket, so that replies go back to it.
+func (f *Forwarder) ServeUDP(ctx context.Context, conn net.PacketConn) error {
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	var (
+		mu    sync.Mutex
+		flows = map[string]*udpFlow{}
+	)
+
+	buf := make([]byte, 65535)
+	for {
+		n, client, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		mu.Lock()
+		flow, found := flows[client.String()]
+		if !found {
+			var dialer net.Dialer
+			upstreamConn, err := dialer.DialContext(ctx, "udp", f.target)
+			if err != nil {
+				mu.Unlock()
+				fmt.Fprintln(os.Stderr, "Failed to connect to", f.target, err)
+				f.events.emit(f.event())
+				continue
+			}
+
+			flow = &udpFlow{upstream: upstreamConn}
+			flows[client.String()] = flow
+			go func() {
+				f.relayUDP(conn, client, flow)
+
+				mu.Lock()
+				delete(flows, client.String())
+				mu.Unlock()
+			}()
+		}
+		mu.Unlock()
+
+		if written, err := flow.upstream.Write(buf[:n]); err == nil {
+			flow.sent.Add(int64(written))
+		}
+	}
+}
+
+type udpFlow struct {
+	upstream net.Conn
+	sent     atomic.Int64
+}
+
+// relayUDP sends the replies of the upstream back to the client, until the
+// flow is idle.
+func (f *Forwarder) relayUDP(conn net.PacketConn, client net.Addr, flow *udpFlow) {
+	defer flow.upstream.Close()
+
+	event := f.event()
+	buf := make([]byte, 65535)
+	for {
+		_ = flow.upstream.SetReadDeadline(time.Now().Add(f.idleTimeout))
+		// Stop on errors, including the idle timeout.
+		n, err := flow.upstream.Read(buf)
+		if err != nil {
+			break
+		}
+		if _, err := conn.WriteTo(buf[:n], client); err != nil {
+			break
+		}
+		event.BytesReceived += int64(n)
+	}
+
+	event.BytesSent = flow.sent.Load()
+	f.events.emit(event)
+}
+
+func (f *Forwarder) event() Event {
+	return Event{
+		Proxy:   "l4",
+		Host:    f.forward.Host,
+		Port:    f.forward.Port,
+		Allowed: true,
+	}
+}
+
+func closeWrite(conn net.Conn) {
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.CloseWrite()
+	} else {
+		_ = conn.Close()
+	}
+}
@@ -0,0 +1,155 @@
+package pkg
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuilder is a strings.Builder safe for concurrent use.
+type syncBuilder struct {
+	mu sync.Mutex
+	sb strings.Builder
+}
+
+func (b *syncBuilder) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sb.Write(p)
+}
+
+func (b *syncBuilder) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sb.String()
+}
+
+func TestForwarderTCP(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Upstream echoes everything back.
+	upstream := listenTCP(t)
+	defer upstream.Close()
+	go func() {
+		for {
+			conn, err := upstream.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	var events syncBuilder
+	forwarder := NewForwarder(Forward{Protocol: "tcp", Host: "127.0.0.1", Port: upstream.Addr().(*net.TCPAddr).Port}, &events)
+	ln := listenTCP(t)
+	go func() { _ = forwarder.ServeTCP(ctx, ln) }()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.(*net.TCPConn).CloseWrite()
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if string(reply) != "hello" {
+		t.Errorf("reply = %q, want hello", reply)
+	}
+
+	want := `"proxy":"l4","host":"127.0.0.1","port":` + strings.TrimPrefix(upstream.Addr().String(), "127.0.0.1:") + `,"bytes_sent":5,"bytes_received":5,"allowed":true}`
+	waitFor(t, func() bool { return strings.Contains(events.String(), want) })
+}
+
+func TestForwarderUDP(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Upstream echoes every datagram back.
+	upstream := listenUDP(t)
+	defer upstream.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := upstream.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = upstream.WriteTo(buf[:n], addr)
+		}
+	}()
+
+	var events syncBuilder
+	forwarder := NewForwarder(Forward{Protocol: "udp", Host: "127.0.0.1", Port: upstream.LocalAddr().(*net.UDPAddr).Port}, &events)
+	forwarder.idleTimeout = 100 * time.Millisecond
+	conn := listenUDP(t)
+	go func() { _ = forwarder.ServeUDP(ctx, conn) }()
+
+	client, err := (&net.Dialer{}).DialContext(ctx, "udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	for _, msg := range []string{"ping", "pong!"} {
+		if _, err := client.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 512)
+		_ = client.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := client.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf[:n]) != msg {
+			t.Errorf("reply = %q, want %q", buf[:n], msg)
+		}
+	}
+
+	// The event is emitted once the flow is idle.
+	waitFor(t, func() bool {
+		return strings.Contains(events.String(), `"bytes_sent":9,"bytes_received":9,"allowed":true}`)
+	})
+}
+
+func listenTCP(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := (&net.ListenConfig{}).Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ln
+}
+
+func listenUDP(t *testing.T) net.PacketConn {
+	t.Helper()
+	conn, err := (&net.ListenConfig{}).ListenPacket(context.Background(), "udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	for range 100 {
+		if condition() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("condition not met")
+}
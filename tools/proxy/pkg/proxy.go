@@ -0,0 +1,149 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+type ProxyServer struct {
+	allowlist Allowlist
+	events    *eventWriter
+}
+
+// NewProxyServer creates a proxy that enforces an allowlist and writes an
+// egress event to events for each request.
+func NewProxyServer(allowedHosts string, events io.Writer) (*ProxyServer, error) {
+	allowlist, err := ParseAllowlist(allowedHosts)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range strings.Split(allowedHosts, ",") {
+		fmt.Fprintln(os.Stderr, "Allowed host:", strings.TrimSpace(rule))
+	}
+
+	return &ProxyServer{
+		allowlist: allowlist,
+		events:    &eventWriter{w: events},
+	}, nil
+}
+
+func (p *ProxyServer) Run(ctx context.Context, ln net.Listener) error {
+	server := &fasthttp.Server{
+		Handler:            p.handleRequest,
+		MaxConnsPerIP:      10000,
+		MaxRequestsPerConn: 10000,
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown()
+	}()
+
+	return server.Serve(ln)
+}
+
+func (p *ProxyServer) handleRequest(ctx *fasthttp.RequestCtx) {
+	host := string(ctx.Host())
+	method := string(ctx.Method())
+
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		// No port, IPv6 addresses are still in brackets.
+		hostname, port = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), "80"
+	}
+
+	var requestPath string
+	if method != http.MethodConnect {
+		requestPath = string(ctx.Path())
+	}
+
+	// CIDR ranges are matched against the resolved addresses, which are then
+	// the ones we connect to, so that DNS can't change in between.
+	var addrs []netip.Addr
+	if p.allowlist.NeedsAddresses() {
+		if _, err := netip.ParseAddr(hostname); err != nil {
+			addrs, _ = net.DefaultResolver.LookupNetIP(ctx, "ip", hostname)
+		}
+	}
+
+	event := Event{
+		Proxy:  "l7",
+		Host:   hostname,
+		Port:   parsePort(port),
+		Method: method,
+		Path:   requestPath,
+	}
+
+	if !p.allowlist.Allows(method, hostname, port, requestPath, addrs) {
+		fmt.Fprintln(os.Stderr, "Access DENIED to", method, host+requestPath)
+		ctx.Response.SetStatusCode(http.StatusForbidden)
+		p.events.emit(event)
+		return
+	}
+	event.Allowed = true
+
+	destination := net.JoinHostPort(hostname, port)
+	if len(addrs) > 0 {
+		destination = net.JoinHostPort(addrs[0].String(), port)
+	}
+
+	fmt.Fprintln(os.Stderr, "Access GRANTED to", method, host+requestPath)
+	if method == http.MethodConnect {
+		p.handleTunneling(ctx, destination, event)
+	} else {
+		p.handleHTTP(ctx, destination, event)
+	}
+}
+
+func (p *ProxyServer) handleTunneling(ctx *fasthttp.RequestCtx, destination string, event Event) {
+	destinationConn, err := net.Dial("tcp", destination)
+	if err != nil {
+		ctx.Error("Failed to connect to destination", http.StatusServiceUnavailable)
+		p.events.emit(event)
+		return
+	}
+
+	ctx.SetStatusCode(http.StatusOK)
+	ctx.Response.SetBodyRaw(nil)
+	ctx.Hijack(func(clientConn net.Conn) {
+		defer clientConn.Close()
+		defer destinationConn.Close()
+
+		sent := make(chan int64, 1)
+		go func() {
+			n, _ := io.Copy(destinationConn, clientConn)
+			sent <- n
+		}()
+		event.BytesReceived, _ = io.Copy(clientConn, destinationConn)
+
+		// Unblock the other direction.
+		_ = clientConn.Close()
+		event.BytesSent = <-sent
+		p.events.emit(event)
+	})
+}
+
+func (p *ProxyServer) handleHTTP(ctx *fasthttp.RequestCtx, destination string, event Event) {
+	client := &fasthttp.Client{
+		Dial: func(string) (net.Conn, error) {
+			return fasthttp.DialDualStack(destination)
+		},
+	}
+
+	event.BytesSent = int64(len(ctx.Request.Body()))
+	if err := client.Do(&ctx.Request, &ctx.Response); err != nil {
+		ctx.Error("Failed to process request: "+err.Error(), http.StatusInternalServerError)
+		p.events.emit(event)
+		return
+	}
+	event.BytesReceived = int64(len(ctx.Response.Body()))
+	p.events.emit(event)
+}
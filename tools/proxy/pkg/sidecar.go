@@ -0,0 +1,111 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Sidecar runs all the proxies of a server: the HTTP/CONNECT proxy, the TCP
+// and UDP forwards and the DNS server.
+type Sidecar struct {
+	config Config
+	events io.Writer
+	logs   io.Writer
+}
+
+// NewSidecar creates a sidecar that writes egress events to events and
+// everything else to logs.
+func NewSidecar(config Config, events, logs io.Writer) *Sidecar {
+	return &Sidecar{
+		config: config,
+		events: events,
+		logs:   logs,
+	}
+}
+
+// Run listens on all the ports first, then serves them until ctx is done or
+// one of them fails.
+func (s *Sidecar) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		listenConfig net.ListenConfig
+		serves       []func() error
+	)
+
+	if len(s.config.AllowedHosts) > 0 {
+		proxy, err := NewProxyServer(strings.Join(s.config.AllowedHosts, ","), s.events)
+		if err != nil {
+			return err
+		}
+		ln, err := listenConfig.Listen(ctx, "tcp", address(s.config.HTTPPort))
+		if err != nil {
+			return fmt.Errorf("listening on port %d: %w", s.config.HTTPPort, err)
+		}
+		serves = append(serves, func() error { return proxy.Run(ctx, ln) })
+	}
+
+	for _, forward := range s.config.Forwards {
+		// The DNS server takes care of the forwarded resolver.
+		if forward.Protocol == "udp" && forward.Port == 53 {
+			continue
+		}
+
+		forwarder := NewForwarder(forward, s.events)
+		if forward.Protocol == "udp" {
+			conn, err := listenConfig.ListenPacket(ctx, "udp", address(forward.Port))
+			if err != nil {
+				return fmt.Errorf("listening on udp port %d: %w", forward.Port, err)
+			}
+			serves = append(serves, func() error { return forwarder.ServeUDP(ctx, conn) })
+		} else {
+			ln, err := listenConfig.Listen(ctx, "tcp", address(forward.Port))
+			if err != nil {
+				return fmt.Errorf("listening on tcp port %d: %w", forward.Port, err)
+			}
+			serves = append(serves, func() error { return forwarder.ServeTCP(ctx, ln) })
+		}
+		fmt.Fprintf(s.logs, "Forwarding %s port %d to %s\n", forward.Protocol, forward.Port, forward.Host)
+	}
+
+	var queryLog io.Writer
+	if s.config.LogDNS {
+		queryLog = s.logs
+	}
+	dns := NewDNSServer(s.config.Names(), s.config.dnsUpstream(), s.events, queryLog)
+	conn, err := listenConfig.ListenPacket(ctx, "udp", address(53))
+	if err != nil {
+		return fmt.Errorf("listening on udp port 53: %w", err)
+	}
+	fmt.Fprintf(s.logs, "Resolving %s\n", strings.Join(s.config.Names(), ", "))
+	serves = append(serves, func() error { return dns.Serve(ctx, conn) })
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for _, serve := range serves {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := serve(); err != nil {
+				once.Do(func() { firstErr = err })
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+func address(port int) string {
+	return ":" + strconv.Itoa(port)
+}